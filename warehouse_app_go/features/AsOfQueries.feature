@@ -0,0 +1,119 @@
+Feature: AsOfQueries
+
+  #------------------------------------------
+  # Scenario 1: Capacity as known at a past time
+  #------------------------------------------
+  Scenario: Available capacity as the service knew it
+    Given today is "2025-01-01"
+    And the warehouses are stored in an event log
+    When I add warehouse 1 with total volume 10.0
+    And I reserve volume 4.0 from "2025-01-10" to "2025-01-12"
+    Given today is "2025-01-05"
+    When I reserve volume 5.0 from "2025-01-11" to "2025-01-11"
+    And I cancel item 1
+    And I call CalculateAvailableCapacity from "2025-01-10" to "2025-01-11" as known on "2025-01-03"
+    Then the available capacities should be:
+      | date       | capacity |
+      | 2025-01-10 | 6.0      |
+      | 2025-01-11 | 6.0      |
+    When I call CalculateAvailableCapacity from "2025-01-10" to "2025-01-11" as known on "2025-01-05"
+    Then the available capacities should be:
+      | date       | capacity |
+      | 2025-01-10 | 10.0     |
+      | 2025-01-11 | 5.0      |
+
+  #------------------------------------------
+  # Scenario 2: Search as known at a past time
+  #------------------------------------------
+  Scenario: A warehouse that had space when the customer asked
+    Given today is "2025-01-01"
+    And the warehouses are stored in an event log
+    When I add warehouse 1 with total volume 10.0
+    And I add warehouse 2 with total volume 10.0
+    Given today is "2025-01-03"
+    When I reserve volume 8.0 from "2025-01-10" to "2025-01-12"
+    And I call FindAvailableWarehouse from "2025-01-10" to "2025-01-12" as known on "2025-01-02" with dimensions:
+      | height | width | length |
+      | 9      | 1     | 1      |
+    Then I should receive warehouse ID 1
+    When I call FindAvailableWarehouse from "2025-01-10" to "2025-01-12" as known on "2025-01-03" with dimensions:
+      | height | width | length |
+      | 9      | 1     | 1      |
+    Then I should receive warehouse ID 2
+
+  Scenario: Start dates are checked against the knowledge time
+    Given today is "2025-01-01"
+    And the warehouses are stored in an event log
+    When I add warehouse 1 with total volume 10.0
+    Given today is "2025-01-20"
+    When I call FindAvailableWarehouse from "2025-01-10" to "2025-01-12" as known on "2025-01-02" with dimensions:
+      | height | width | length |
+      | 1      | 1     | 1      |
+    Then I should receive warehouse ID 1
+
+  Scenario: Nothing was known before the first change
+    Given today is "2025-01-05"
+    And the warehouses are stored in an event log
+    When I add warehouse 1 with total volume 10.0
+    And I call CalculateAvailableCapacity from "2025-01-10" to "2025-01-11" as known on "2025-01-01"
+    Then an error should be returned with message "no warehouses available"
+
+  #------------------------------------------
+  # Scenario 3: Customers and waitlist as known at a past time
+  #------------------------------------------
+  Scenario: Customers and the waitlist as the service knew them
+    Given today is "2025-01-01"
+    And the warehouses are stored in an event log
+    When I add warehouse 1 with total volume 10.0
+    And I register customer 7
+    And I reserve volume 6.0 from "2025-01-10" to "2025-01-10"
+    Given today is "2025-01-03"
+    When I register customer 8
+    And I reserve with priority 5 from "2025-01-10" to "2025-01-10" with dimensions:
+      | height | width | length |
+      | 8.0    | 1.0   | 1.0    |
+    Then the service should have known 1 customer and 0 waitlisted items on "2025-01-02"
+    And the service should have known 2 customers and 1 waitlisted item on "2025-01-03"
+
+  #------------------------------------------
+  # Scenario 4: Repositories with and without a history
+  #------------------------------------------
+  Scenario: The database keeps a history too
+    Given today is "2025-01-01"
+    And the warehouses are stored in a database
+    When I add warehouse 1 with total volume 10.0
+    And I register customer 7
+    And I reserve volume 4.0 from "2025-01-10" to "2025-01-12"
+    Given today is "2025-01-05"
+    When I register customer 8
+    And I reserve volume 5.0 from "2025-01-11" to "2025-01-11"
+    And I cancel item 1
+    And I call CalculateAvailableCapacity from "2025-01-10" to "2025-01-11" as known on "2025-01-03"
+    Then the available capacities should be:
+      | date       | capacity |
+      | 2025-01-10 | 6.0      |
+      | 2025-01-11 | 6.0      |
+    When I call CalculateAvailableCapacity from "2025-01-10" to "2025-01-11" as known on "2025-01-05"
+    Then the available capacities should be:
+      | date       | capacity |
+      | 2025-01-10 | 10.0     |
+      | 2025-01-11 | 5.0      |
+    And the service should have known 1 customer and 0 waitlisted items on "2025-01-03"
+    And the service should have known 2 customers and 0 waitlisted items on "2025-01-05"
+
+  Scenario: Repositories without a history cannot answer
+    Given I have 1 warehouse with total volume 10.0
+    When I call CalculateAvailableCapacity from "2025-01-10" to "2025-01-11" as known on "2025-01-01"
+    Then an error should be returned with message "the repository does not keep a history"
+
+  #------------------------------------------
+  # Scenario 5: The past is read-only
+  #------------------------------------------
+  Scenario: Changes made as known at a past time are refused
+    Given today is "2025-01-01"
+    And the warehouses are stored in an event log
+    When I add warehouse 1 with total volume 10.0
+    Given today is "2025-01-05"
+    When I reserve volume 4.0 from "2025-01-10" to "2025-01-12" as known on "2025-01-03"
+    Then an error should be returned with message "the service only answers what was known in the past and cannot be changed"
+    And warehouse 1 should hold 0 items
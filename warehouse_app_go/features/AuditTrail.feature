@@ -0,0 +1,187 @@
+Feature: AuditTrail
+
+  #------------------------------------------
+  # Scenario 1: Recording who changed what
+  #------------------------------------------
+  Scenario: Every change is recorded with its actor and reason
+    Given today is "2025-01-01"
+    And the service keeps an audit log
+    And "alice" is acting because "new site"
+    When I add warehouse 1 with total volume 10.0
+    Given "bob" is acting because "order 17"
+    When I reserve volume 4.0 from "2025-01-10" to "2025-01-12"
+    Given today is "2025-01-02"
+    And "alice" is acting because "customer asked for two more days"
+    When I extend item 1 to "2025-01-14"
+    Given "carol" is acting because "order withdrawn"
+    When I cancel item 1
+    Then the audit entries for item 1 should be:
+      | sequence | recorded   | actor | reason                           | operation     | warehouse | item | changes  |
+      | 2        | 2025-01-01 | bob   | order 17                         | ItemReserved  | 1         | 1    | created  |
+      | 3        | 2025-01-02 | alice | customer asked for two more days | ItemExtended  | 1         | 1    | EndDate  |
+      | 4        | 2025-01-02 | carol | order withdrawn                  | ItemCancelled | 1         | 1    | IsActive |
+    And audit entry 3 should change the end date from "2025-01-12" to "2025-01-14"
+
+  Scenario: Moving an item records both warehouses
+    Given today is "2025-01-01"
+    And the service keeps an audit log
+    And "alice" is acting because "new sites"
+    When I add warehouse 1 with total volume 10.0
+    And I add warehouse 2 with total volume 10.0
+    And I reserve volume 4.0 from "2025-01-10" to "2025-01-12"
+    Given "bob" is acting because "dock 1 under repair"
+    When I transfer item 1 to warehouse 2 on "2025-01-11"
+    Then the audit entries for warehouse 2 should be:
+      | sequence | recorded   | actor | reason              | operation        | warehouse | item | changes |
+      | 2        | 2025-01-01 | alice | new sites           | WarehouseCreated | 2         |      | created |
+      | 5        | 2025-01-01 | bob   | dock 1 under repair | ItemTransferred  | 2         | 2    | created |
+
+  #------------------------------------------
+  # Scenario 2: Querying the audit trail
+  #------------------------------------------
+  Scenario: Entries are listed by actor and by time range
+    Given today is "2025-01-01"
+    And the service keeps an audit log
+    And "alice" is acting because "new site"
+    When I add warehouse 1 with total volume 10.0
+    Given today is "2025-01-03"
+    And "bob" is acting because "order 17"
+    When I reserve volume 4.0 from "2025-01-10" to "2025-01-12"
+    Given today is "2025-01-05"
+    And "alice" is acting because "order 18"
+    When I reserve volume 2.0 from "2025-01-10" to "2025-01-12"
+    Then the audit entries of "alice" should be:
+      | sequence | recorded   | actor | reason   | operation        | warehouse | item | changes |
+      | 1        | 2025-01-01 | alice | new site | WarehouseCreated | 1         |      | created |
+      | 3        | 2025-01-05 | alice | order 18 | ItemReserved     | 1         | 2    | created |
+    And the audit entries recorded from "2025-01-02" to "2025-01-04" should be:
+      | sequence | recorded   | actor | reason   | operation    | warehouse | item | changes |
+      | 2        | 2025-01-03 | bob   | order 17 | ItemReserved | 1         | 1    | created |
+
+  #------------------------------------------
+  # Scenario 3: Guarding the audit trail
+  #------------------------------------------
+  Scenario: Changes without an actor are refused
+    Given today is "2025-01-01"
+    And the service keeps an audit log
+    And "alice" is acting because "new site"
+    When I add warehouse 1 with total volume 10.0
+    Given nobody is acting
+    When I reserve volume 4.0 from "2025-01-10" to "2025-01-12"
+    Then an error should be returned with message "changes to audited data must name an actor"
+    And the audit entries for warehouse 1 should be:
+      | sequence | recorded   | actor | reason   | operation        | warehouse | item | changes |
+      | 1        | 2025-01-01 | alice | new site | WarehouseCreated | 1         |      | created |
+
+  Scenario: Failed changes leave no entry
+    Given today is "2025-01-01"
+    And the service keeps an audit log
+    And "alice" is acting because "new site"
+    When I add warehouse 1 with total volume 10.0
+    And I reserve volume 4.0 from "2025-01-10" to "2025-01-12"
+    And I extend item 1 to "2025-01-11"
+    Then an error should be returned with message "the new end date must be later than the current end date"
+    And the audit entries for item 1 should be:
+      | sequence | recorded   | actor | reason   | operation    | warehouse | item | changes |
+      | 2        | 2025-01-01 | alice | new site | ItemReserved | 1         | 1    | created |
+
+  Scenario: Audit entries cannot be altered
+    Given today is "2025-01-01"
+    And the service keeps an audit log
+    And "alice" is acting because "new site"
+    When I add warehouse 1 with total volume 10.0
+    And I reserve volume 4.0 from "2025-01-10" to "2025-01-12"
+    And I extend item 1 to "2025-01-14"
+    Then the audit entries for item 1 should not change when the returned entries are modified
+
+  #------------------------------------------
+  # Scenario 4: Acting on the service
+  #------------------------------------------
+  Scenario: Reservations made on behalf of someone keep the waitlist of the service
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 6.0 from "2025-01-10" to "2025-01-12"
+    When "alice" reserves volume 8.0 with priority 5 from "2025-01-11" to "2025-01-11" because "urgent order"
+    Then the reservation should be placed in warehouse 1
+    And the waitlist should hold 1 item
+
+  Scenario: A service without a repository shares the one its first view starts
+    Given today is "2025-01-01"
+    When "alice" adds warehouse 1 with total volume 10.0 because "new site"
+    And "bob" adds warehouse 2 with total volume 20.0 because "second site"
+    Then warehouse 1 should have total volume 10.0
+    And warehouse 2 should have total volume 20.0
+
+  #------------------------------------------
+  # Scenario 5: Storing the audit trail
+  #------------------------------------------
+  Scenario: Audit entries are stored with the warehouses in a file
+    Given today is "2025-01-01"
+    And the warehouses are stored in a file
+    And the service keeps an audit log
+    And "alice" is acting because "new site"
+    When I add warehouse 1 with total volume 10.0
+    And I reserve volume 4.0 from "2025-01-10" to "2025-01-12"
+    And I reopen the file
+    Given "bob" is acting because "customer asked for two more days"
+    When I extend item 1 to "2025-01-14"
+    Then the audit entries for item 1 should be:
+      | sequence | recorded   | actor | reason                           | operation    | warehouse | item | changes |
+      | 2        | 2025-01-01 | alice | new site                         | ItemReserved | 1         | 1    | created |
+      | 3        | 2025-01-01 | bob   | customer asked for two more days | ItemExtended | 1         | 1    | EndDate |
+    And audit entry 3 should change the end date from "2025-01-12" to "2025-01-14"
+
+  Scenario: Audit entries are stored with the warehouses in an event log
+    Given today is "2025-01-01"
+    And the warehouses are stored in an event log
+    And the service keeps an audit log
+    And "alice" is acting because "new site"
+    When I add warehouse 1 with total volume 10.0
+    And I reserve volume 4.0 from "2025-01-10" to "2025-01-12"
+    And I reopen the event log
+    Given "bob" is acting because "customer asked for two more days"
+    When I extend item 1 to "2025-01-14"
+    Then the audit entries for item 1 should be:
+      | sequence | recorded   | actor | reason                           | operation    | warehouse | item | changes |
+      | 2        | 2025-01-01 | alice | new site                         | ItemReserved | 1         | 1    | created |
+      | 3        | 2025-01-01 | bob   | customer asked for two more days | ItemExtended | 1         | 1    | EndDate |
+    And audit entry 3 should change the end date from "2025-01-12" to "2025-01-14"
+
+  Scenario: Audit entries are stored with the warehouses in a database
+    Given today is "2025-01-01"
+    And the warehouses are stored in a database
+    And the service keeps an audit log
+    And "alice" is acting because "new site"
+    When I add warehouse 1 with total volume 10.0
+    And I reserve volume 4.0 from "2025-01-10" to "2025-01-12"
+    And I reopen the database
+    Given "bob" is acting because "customer asked for two more days"
+    When I extend item 1 to "2025-01-14"
+    Then the audit entries for item 1 should be:
+      | sequence | recorded   | actor | reason                           | operation    | warehouse | item | changes |
+      | 2        | 2025-01-01 | alice | new site                         | ItemReserved | 1         | 1    | created |
+      | 3        | 2025-01-01 | bob   | customer asked for two more days | ItemExtended | 1         | 1    | EndDate |
+    And audit entry 3 should change the end date from "2025-01-12" to "2025-01-14"
+
+  Scenario: Changes made at the same time are numbered apart
+    Given today is "2025-01-01"
+    And the warehouses are stored in a database
+    And the service keeps an audit log
+    And "alice" is acting because "new site"
+    When I add warehouse 1 with total volume 100.0
+    And 10 reservations of volume 1.0 from "2025-01-10" to "2025-01-12" are made at the same time
+    Then every rejected reservation should report a conflict or missing space
+    When I reopen the database
+    Then the warehouse and every reservation made should have an audit entry, numbered one after another
+
+  Scenario: Audit entries are saved with the service state
+    Given today is "2025-01-01"
+    And the service keeps an audit log
+    And "alice" is acting because "new site"
+    When I add warehouse 1 with total volume 10.0
+    And I reserve volume 4.0 from "2025-01-10" to "2025-01-12"
+    And I save the service state and load it into a new service
+    Then the loaded state should match the saved state
+    And the audit entries for item 1 should be:
+      | sequence | recorded   | actor | reason   | operation    | warehouse | item | changes |
+      | 2        | 2025-01-01 | alice | new site | ItemReserved | 1         | 1    | created |
@@ -0,0 +1,47 @@
+Feature: AvailabilityMatrix
+
+  #------------------------------------------
+  # Scenario 1: Start dates by warehouses
+  #------------------------------------------
+  Scenario: Matrix shows availability and free margin
+    Given today is "2025-01-01"
+    And I have 2 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 7.0 from "2025-01-11" to "2025-01-11"
+    And warehouse 2 is booked with volume 2.0 from "2025-01-10" to "2025-01-12"
+    When I request the availability matrix of 2 days between "2025-01-09" and "2025-01-11" with dimensions:
+      | height | width | length |
+      | 4.0    | 1.0   | 1.0    |
+    Then the availability matrix should be:
+      | start      | warehouse | available | margin |
+      | 2025-01-09 | 1         | yes       | 6.0    |
+      | 2025-01-09 | 2         | yes       | 4.0    |
+      | 2025-01-10 | 1         | no        | -1.0   |
+      | 2025-01-10 | 2         | yes       | 4.0    |
+      | 2025-01-11 | 1         | no        | -1.0   |
+      | 2025-01-11 | 2         | yes       | 4.0    |
+
+  #------------------------------------------
+  # Scenario 2: Closed days
+  #------------------------------------------
+  Scenario: Enough space but the warehouse is closed for check-in
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is closed from "2025-01-10" to "2025-01-10"
+    When I request the availability matrix of 1 day between "2025-01-09" and "2025-01-10" with dimensions:
+      | height | width | length |
+      | 4.0    | 1.0   | 1.0    |
+    Then the availability matrix should be:
+      | start      | warehouse | available | margin |
+      | 2025-01-09 | 1         | yes       | 6.0    |
+      | 2025-01-10 | 1         | no        | 6.0    |
+
+  #------------------------------------------
+  # Scenario 3: Invalid window
+  #------------------------------------------
+  Scenario: Window start after window end
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    When I request the availability matrix of 2 days between "2025-01-12" and "2025-01-10" with dimensions:
+      | height | width | length |
+      | 4.0    | 1.0   | 1.0    |
+    Then an error should be returned with message "the start date cannot be later than the end date"
@@ -0,0 +1,58 @@
+Feature: BufferDays
+
+  #------------------------------------------
+  # Scenario 1: Buffers take up capacity
+  #------------------------------------------
+  Scenario: Receiving and dispatch days are not free
+    Given I have 1 warehouse with total volume 10.0
+    And warehouse 1 needs 1 day before and 1 day after each stay
+    And warehouse 1 is booked with volume 4.0 from "2025-01-10" to "2025-01-11"
+    When I call CalculateAvailableCapacity from "2025-01-08" to "2025-01-13"
+    Then the available capacities should be:
+      | date       | capacity |
+      | 2025-01-08 | 10.0     |
+      | 2025-01-09 | 6.0      |
+      | 2025-01-10 | 6.0      |
+      | 2025-01-11 | 6.0      |
+      | 2025-01-12 | 6.0      |
+      | 2025-01-13 | 10.0     |
+
+  #------------------------------------------
+  # Scenario 2: The new stay needs buffers too
+  #------------------------------------------
+  Scenario: Dispatch day of the new stay overlaps a full day
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 needs 1 day before and 1 day after each stay
+    And warehouse 1 is booked with volume 8.0 from "2025-01-12" to "2025-01-12"
+    When I call FindAvailableWarehouse from "2025-01-09" to "2025-01-10" with dimensions:
+      | height | width | length |
+      | 4.0    | 1.0   | 1.0    |
+    Then an error should be returned with message "required volume cannot be accommodated within the specified dates"
+
+  #------------------------------------------
+  # Scenario 3: Item override
+  #------------------------------------------
+  Scenario: Item without buffers frees the surrounding days
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 needs 2 days before and 2 days after each stay
+    And warehouse 1 is booked with volume 8.0 from "2025-01-13" to "2025-01-13"
+    And item 1 needs 0 days before and 0 days after its stay
+    When I call FindAvailableWarehouse from "2025-01-09" to "2025-01-10" with dimensions:
+      | height | width | length |
+      | 4.0    | 1.0   | 1.0    |
+    Then I should receive warehouse ID 1
+
+  #------------------------------------------
+  # Scenario 4: Buffered versus stored volume
+  #------------------------------------------
+  Scenario: Report shows buffered and stored volume separately
+    Given I have 2 warehouse with total volume 10.0
+    And warehouse 1 needs 1 day before and 0 days after each stay
+    And warehouse 1 is booked with volume 4.0 from "2025-01-10" to "2025-01-10"
+    And warehouse 2 is booked with volume 2.0 from "2025-01-09" to "2025-01-09"
+    Then the occupancy breakdown from "2025-01-09" to "2025-01-10" should be:
+      | date       | stored | buffered |
+      | 2025-01-09 | 2.0    | 4.0      |
+      | 2025-01-10 | 4.0    | 0.0      |
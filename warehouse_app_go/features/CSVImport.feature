@@ -0,0 +1,103 @@
+Feature: CSVImport
+
+  #------------------------------------------
+  # Scenario 1: Valid files
+  #------------------------------------------
+  Scenario: Warehouses and items are created from the files
+    Given I have 1 warehouse with total volume 10.0
+    And the warehouse CSV:
+      """
+      id,height,width,length
+      2,10,2,1
+      3,5,1,1
+      """
+    And the item CSV:
+      """
+      id,name,height,width,length,start,end,active,warehouse_id
+      1,Pallet,2,1,1,2025-01-10,2025-01-12,true,1
+      2,Crate,1,1,1,2025-01-11,2025-01-11,,2
+      3,"Boxes, small",1,1,1,2025-01-11,2025-01-12,false,3
+      """
+    When I import the CSV files
+    Then 2 warehouses and 3 items should be imported
+    And warehouse 1 should hold 1 items
+    And warehouse 2 should hold 1 items
+    And warehouse 3 should hold 1 items
+    When I call CalculateAvailableCapacity from "2025-01-11" to "2025-01-11"
+    Then the available capacities should be:
+      | date       | capacity |
+      | 2025-01-11 | 32.0     |
+
+  Scenario: Columns may come in any order
+    Given the warehouse CSV:
+      """
+      length, width, height, id
+      1,1,10,1
+      """
+    And the item CSV:
+      """
+      warehouse_id,id,name,start,end,active,height,width,length
+      1,7,Pallet,2025-01-10,2025-01-12,true,2,1,1
+      """
+    When I import the CSV files
+    Then 1 warehouses and 1 items should be imported
+    And warehouse 1 should hold 1 items
+
+  #------------------------------------------
+  # Scenario 2: Invalid rows
+  #------------------------------------------
+  Scenario: Every invalid row is reported and nothing is imported
+    Given I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 1.0 from "2025-01-01" to "2025-01-02"
+    And the warehouse CSV:
+      """
+      id,height,width,length
+      1,10,1,1
+      2,-1,1,1
+      3,10,1,1
+      3,abc,1,1
+      4,NaN,1,1
+      5,10,+Inf,1
+      """
+    And the item CSV:
+      """
+      id,name,height,width,length,start,end,active,warehouse_id
+      1,Pallet,1,1,1,2025-01-10,2025-01-12,true,3
+      5,Pallet,1,1,1,2025-02-30,2025-01-12,true,3
+      6,Pallet,1,0,1,2025-01-12,2025-01-10,yes,9
+      6,Pallet,1,1,1,2025-01-10,2025-01-12,true,3
+      """
+    When I import the CSV files
+    Then an error should be returned with message "the import contains invalid rows"
+    And the import failures should be:
+      | file       | line | reason                                                 |
+      | warehouses | 2    | a warehouse with this id already exists                |
+      | warehouses | 3    | the 3D model has invalid dimensions (zero or negative) |
+      | warehouses | 5    | the height "abc" is not a number                       |
+      | warehouses | 5    | the warehouse id is listed more than once              |
+      | warehouses | 6    | the height "NaN" is not a finite number                |
+      | warehouses | 7    | the width "+Inf" is not a finite number                |
+      | items      | 2    | an item with this id already exists                    |
+      | items      | 3    | the start date "2025-02-30" is not a valid date        |
+      | items      | 4    | the active value "yes" is not true or false            |
+      | items      | 4    | the 3D model has invalid dimensions (zero or negative) |
+      | items      | 4    | the start date cannot be later than the end date       |
+      | items      | 4    | unknown warehouse 9                                    |
+      | items      | 5    | the item id is listed more than once                   |
+    And warehouse 1 should hold 1 items
+
+  Scenario: Missing columns are reported
+    Given the warehouse CSV:
+      """
+      id,height,depth
+      1,10,1
+      """
+    And the item CSV:
+      """
+      """
+    When I import the CSV files
+    Then the import failures should be:
+      | file       | line | reason                        |
+      | warehouses | 1    | the column "width" is missing  |
+      | warehouses | 1    | the column "length" is missing |
+      | items      | 1    | the file is empty             |
@@ -0,0 +1,92 @@
+Feature: CapacityBlocks
+
+  #------------------------------------------
+  # Scenario 1: Blocked space is occupied for others
+  #------------------------------------------
+  Scenario: Other reservations cannot use a customer's block
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And a customer with id 7
+    And customer 7 has a block of 6.0 in warehouse 1 from "2025-01-05" to "2025-01-20"
+    When I call FindAvailableWarehouse from "2025-01-10" to "2025-01-12" with dimensions:
+      | height | width | length |
+      | 5.0    | 1.0   | 1.0    |
+    Then an error should be returned with message "required volume cannot be accommodated within the specified dates"
+
+  #------------------------------------------
+  # Scenario 2: The customer consumes its block
+  #------------------------------------------
+  Scenario: The block customer can store items inside its block
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And a customer with id 7
+    And customer 7 has a block of 6.0 in warehouse 1 from "2025-01-05" to "2025-01-20"
+    When customer 7 looks for a warehouse from "2025-01-10" to "2025-01-12" with dimensions:
+      | height | width | length |
+      | 9.0    | 1.0   | 1.0    |
+    Then I should receive warehouse ID 1
+
+  #------------------------------------------
+  # Scenario 3: Capacity reflects the blocks
+  #------------------------------------------
+  Scenario: Unused block volume is not available to everyone
+    Given I have 1 warehouse with total volume 10.0
+    And a customer with id 7
+    And customer 7 has a block of 6.0 in warehouse 1 from "2025-01-02" to "2025-01-03"
+    And warehouse 1 is booked with volume 2.0 from "2025-01-03" to "2025-01-03"
+    And item 1 belongs to customer 7
+    When I call CalculateAvailableCapacity from "2025-01-01" to "2025-01-03"
+    Then the available capacities should be:
+      | date       | capacity |
+      | 2025-01-01 | 10.0     |
+      | 2025-01-02 | 4.0      |
+      | 2025-01-03 | 4.0      |
+    When I calculate the available capacity for customer 7 from "2025-01-01" to "2025-01-03"
+    Then the available capacities should be:
+      | date       | capacity |
+      | 2025-01-01 | 10.0     |
+      | 2025-01-02 | 10.0     |
+      | 2025-01-03 | 8.0      |
+
+  #------------------------------------------
+  # Scenario 4: Blocks must fit
+  #------------------------------------------
+  Scenario: A block cannot take space that is already booked
+    Given I have 1 warehouse with total volume 10.0
+    And a customer with id 7
+    And warehouse 1 is booked with volume 6.0 from "2025-01-03" to "2025-01-03"
+    And customer 7 has a block of 6.0 in warehouse 1 from "2025-01-02" to "2025-01-04"
+    Then an error should be returned with message "the capacity block cannot be accommodated within the specified dates"
+
+  #------------------------------------------
+  # Scenario 5: Utilization reporting
+  #------------------------------------------
+  Scenario: Block utilization counts the customer's items inside the block
+    Given I have 1 warehouse with total volume 10.0
+    And a customer with id 7
+    And customer 7 has a block of 4.0 in warehouse 1 from "2025-01-01" to "2025-01-04"
+    And warehouse 1 is booked with volume 2.0 from "2025-01-02" to "2025-01-03"
+    And item 1 belongs to customer 7
+    And warehouse 1 is booked with volume 6.0 from "2025-01-03" to "2025-01-03"
+    And item 2 belongs to customer 7
+    Then capacity block 1 should be used:
+      | date       | usage |
+      | 2025-01-01 | 0.0   |
+      | 2025-01-02 | 2.0   |
+      | 2025-01-03 | 4.0   |
+      | 2025-01-04 | 0.0   |
+    And capacity block 1 should be 37.5 percent utilized
+
+  #------------------------------------------
+  # Scenario 6: Removing a block
+  #------------------------------------------
+  Scenario: Removing a block releases its space
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And a customer with id 7
+    And customer 7 has a block of 6.0 in warehouse 1 from "2025-01-05" to "2025-01-20"
+    When I remove capacity block 1
+    And I call FindAvailableWarehouse from "2025-01-10" to "2025-01-12" with dimensions:
+      | height | width | length |
+      | 5.0    | 1.0   | 1.0    |
+    Then I should receive warehouse ID 1
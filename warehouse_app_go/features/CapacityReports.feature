@@ -0,0 +1,99 @@
+Feature: CapacityReports
+
+  #------------------------------------------
+  # Scenario 1: Available capacity
+  #------------------------------------------
+  Scenario: Available capacity as CSV
+    Given I have 2 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 4.0 from "2025-01-02" to "2025-01-03"
+    And warehouse 2 is booked with volume 2.5 from "2025-01-03" to "2025-01-03"
+    When I export the available capacity report from "2025-01-01" to "2025-01-03" as CSV
+    Then the report should be:
+      """
+      date,available
+      2025-01-01,20
+      2025-01-02,16
+      2025-01-03,13.5
+
+      """
+
+  Scenario: Available capacity per warehouse as CSV
+    Given I have 2 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 4.0 from "2025-01-02" to "2025-01-03"
+    And warehouse 2 is booked with volume 2.5 from "2025-01-03" to "2025-01-03"
+    When I export the available capacity report from "2025-01-01" to "2025-01-03" as CSV per warehouse
+    Then the report should be:
+      """
+      date,available,warehouse_1,warehouse_2
+      2025-01-01,20,10,10
+      2025-01-02,16,6,10
+      2025-01-03,13.5,6,7.5
+
+      """
+
+  Scenario: Available capacity as JSON
+    Given I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 4.0 from "2025-01-02" to "2025-01-02"
+    When I export the available capacity report from "2025-01-01" to "2025-01-02" as JSON per warehouse
+    Then the report should be:
+      """
+      [
+        {
+          "date": "2025-01-01",
+          "available": 10,
+          "warehouse_1": 10
+        },
+        {
+          "date": "2025-01-02",
+          "available": 6,
+          "warehouse_1": 6
+        }
+      ]
+
+      """
+
+  #------------------------------------------
+  # Scenario 2: Fully utilized dates
+  #------------------------------------------
+  Scenario: Fully utilized dates with the occupied share per warehouse
+    Given I have 2 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 10.0 from "2025-01-02" to "2025-01-03"
+    And warehouse 2 is booked with volume 10.0 from "2025-01-03" to "2025-01-04"
+    When I export the fully utilized dates report from "2025-01-01" to "2025-01-05" as CSV per warehouse
+    Then the report should be:
+      """
+      date,warehouse_1,warehouse_2
+      2025-01-03,1,1
+
+      """
+
+  Scenario: No fully utilized dates as JSON
+    Given I have 1 warehouse with total volume 10.0
+    When I export the fully utilized dates report from "2025-01-01" to "2025-01-05" as JSON
+    Then the report should be:
+      """
+      []
+
+      """
+
+  #------------------------------------------
+  # Scenario 3: Warehouse usage
+  #------------------------------------------
+  Scenario: Warehouses ranked by usage
+    Given I have 3 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 5.0 from "2025-01-01" to "2025-01-02"
+    And warehouse 3 is booked with volume 1.0 from "2025-01-01" to "2025-01-02"
+    When I export the warehouse usage report from "2025-01-01" to "2025-01-02" as CSV
+    Then the report should be:
+      """
+      warehouse_id,usage
+      2,0
+      3,0.1
+      1,0.5
+
+      """
+
+  Scenario: Invalid range
+    Given I have 1 warehouse with total volume 10.0
+    When I export the available capacity report from "2025-01-05" to "2025-01-01" as CSV
+    Then an error should be returned with message "the start date cannot be later than the end date"
@@ -0,0 +1,69 @@
+Feature: CapacitySchedule
+
+  #------------------------------------------
+  # Scenario 1: Extension and temporary loss
+  #------------------------------------------
+  Scenario: Capacity follows the schedule
+    Given I have 1 warehouse with total volume 100.0
+    And warehouse 1 is resized from "2025-03-02" to:
+      | height | width | length |
+      | 150.0  | 1.0   | 1.0    |
+    And warehouse 1 loses 30.0 volume from "2025-03-03" to "2025-03-03"
+    When I call CalculateAvailableCapacity from "2025-03-01" to "2025-03-04"
+    Then the available capacities should be:
+      | date       | capacity |
+      | 2025-03-01 | 100.0    |
+      | 2025-03-02 | 150.0    |
+      | 2025-03-03 | 120.0    |
+      | 2025-03-04 | 150.0    |
+
+  #------------------------------------------
+  # Scenario 2: Stay during renovation
+  #------------------------------------------
+  Scenario: Lost space is not offered for new stays
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 loses 5.0 volume from "2025-06-01" to "2025-06-30"
+    When I call FindAvailableWarehouse from "2025-05-30" to "2025-06-02" with dimensions:
+      | height | width | length |
+      | 6.0    | 1.0   | 1.0    |
+    Then an error should be returned with message "required volume cannot be accommodated within the specified dates"
+
+  #------------------------------------------
+  # Scenario 3: Reduced capacity fills up
+  #------------------------------------------
+  Scenario: Day becomes fully utilized while capacity is reduced
+    Given I have 1 warehouse with total volume 100.0
+    And warehouse 1 loses 50.0 volume from "2025-01-11" to "2025-01-11"
+    And warehouse usage on "2025-01-10" is 50.0
+    And warehouse usage on "2025-01-11" is 50.0
+    When I call GetFullyUtilizedDates from "2025-01-10" to "2025-01-11"
+    Then the fully utilized dates should be:
+      | date       |
+      | 2025-01-11 |
+
+  #------------------------------------------
+  # Scenario 4: Least used by booked volume
+  #------------------------------------------
+  Scenario: A smaller schedule does not change which warehouse is least used
+    Given I have warehouses with usage:
+      | id | volume | usage |
+      | 1  | 100.0  | 40.0  |
+      | 2  | 100.0  | 30.0  |
+    And warehouse 2 is resized from "2025-01-01" to:
+      | height | width | length |
+      | 50.0   | 1.0   | 1.0    |
+    When I call GetLeastUsedWarehouse from "2025-01-10" to "2025-01-10"
+    Then the least used warehouse should be 2
+
+  #------------------------------------------
+  # Scenario 5: Ties go to the lowest id
+  #------------------------------------------
+  Scenario: Warehouses with equal usage go to the lowest id
+    Given I have warehouses with usage:
+      | id | volume | usage |
+      | 3  | 100.0  | 30.0  |
+      | 2  | 50.0   | 30.0  |
+      | 4  | 100.0  | 30.0  |
+    When I call GetLeastUsedWarehouse from "2025-01-10" to "2025-01-10"
+    Then the least used warehouse should be 2
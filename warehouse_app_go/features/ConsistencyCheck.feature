@@ -0,0 +1,138 @@
+Feature: ConsistencyCheck
+
+  #------------------------------------------
+  # Scenario 1: Consistent data
+  #------------------------------------------
+  Scenario: Data stored through the service is consistent
+    Given today is "2025-01-01"
+    When I add warehouse 1 with total volume 10.0
+    And I reserve volume 4.0 from "2025-01-10" to "2025-01-12"
+    And I reserve volume 6.0 from "2025-01-10" to "2025-01-12"
+    And I check the stored data
+    Then no violations should be found
+
+  #------------------------------------------
+  # Scenario 2: Broken records
+  #------------------------------------------
+  Scenario: Every broken record is reported with its location
+    When I load the document:
+      """
+      {
+        "Version": 1,
+        "DateFormat": "2006-01-02T15:04:05Z07:00",
+        "Customers": [ { "Id": 1 } ],
+        "Warehouses": [
+          {
+            "Id": 1,
+            "MaxCapacity": { "Height": 10, "Width": -1, "Length": 1 },
+            "CapacitySchedule": [
+              {
+                "EffectiveFrom": "2025-02-10T00:00:00Z",
+                "EffectiveTo": "2025-02-01T00:00:00Z",
+                "Room": { "Height": 0, "Width": 1, "Length": 1 }
+              }
+            ]
+          },
+          {
+            "Id": 2,
+            "MaxCapacity": { "Height": 10, "Width": 1, "Length": 1 },
+            "Items": [
+              {
+                "ItemId": 1,
+                "ItemHeight": 2, "ItemWidth": 1, "ItemLength": 1,
+                "StartDate": "2025-01-12T00:00:00Z",
+                "EndDate": "2025-01-10T00:00:00Z",
+                "IsActive": true
+              },
+              {
+                "ItemId": 1,
+                "ItemHeight": 2, "ItemWidth": 0, "ItemLength": 1,
+                "StartDate": "2025-01-10T00:00:00Z",
+                "EndDate": "2025-01-12T00:00:00Z",
+                "IsActive": true,
+                "CustomerId": 7
+              }
+            ]
+          }
+        ],
+        "Waitlist": [
+          {
+            "ItemId": 1,
+            "ItemHeight": 1, "ItemWidth": 1, "ItemLength": 1,
+            "StartDate": "2025-01-10T00:00:00Z"
+          }
+        ]
+      }
+      """
+    And I check the stored data
+    Then the violations should be:
+      | location                            | problem                                                        |
+      | warehouse 1                         | the 3D model has invalid dimensions (zero or negative)         |
+      | warehouse 1, capacity change 1      | the 3D model has invalid dimensions (zero or negative)         |
+      | warehouse 1, capacity change 1      | the end date 2025-02-01 is earlier than the start date 2025-02-10 |
+      | warehouse 2, item 1                 | the end date 2025-01-10 is earlier than the start date 2025-01-12 |
+      | warehouse 2, item 1                 | the item id is already used in warehouse 2                     |
+      | warehouse 2, item 1                 | the 3D model has invalid dimensions (zero or negative)         |
+      | warehouse 2, item 1                 | unknown customer 7                                             |
+      | waitlist, item 1                    | the item id is already used in warehouse 2                     |
+      | waitlist, item 1                    | the end date is missing                                        |
+
+  #------------------------------------------
+  # Scenario 3: Overbooked warehouses
+  #------------------------------------------
+  Scenario: Overbooked days are reported as runs
+    When I load the document:
+      """
+      {
+        "Version": 1,
+        "DateFormat": "2006-01-02T15:04:05Z07:00",
+        "Warehouses": [
+          {
+            "Id": 1,
+            "MaxCapacity": { "Height": 10, "Width": 1, "Length": 1 },
+            "Items": [
+              {
+                "ItemId": 1,
+                "ItemHeight": 8, "ItemWidth": 1, "ItemLength": 1,
+                "StartDate": "2025-01-10T00:00:00Z",
+                "EndDate": "2025-01-15T00:00:00Z",
+                "IsActive": true
+              },
+              {
+                "ItemId": 2,
+                "ItemHeight": 3, "ItemWidth": 1, "ItemLength": 1,
+                "StartDate": "2025-01-11T00:00:00Z",
+                "EndDate": "2025-01-12T00:00:00Z",
+                "IsActive": true
+              },
+              {
+                "ItemId": 3,
+                "ItemHeight": 4, "ItemWidth": 1, "ItemLength": 1,
+                "StartDate": "2025-01-12T00:00:00Z",
+                "EndDate": "2025-01-12T00:00:00Z",
+                "IsActive": true
+              },
+              {
+                "ItemId": 4,
+                "ItemHeight": 5, "ItemWidth": 1, "ItemLength": 1,
+                "StartDate": "2025-01-15T00:00:00Z",
+                "EndDate": "2025-01-15T00:00:00Z",
+                "IsActive": true
+              },
+              {
+                "ItemId": 5,
+                "ItemHeight": 9, "ItemWidth": 1, "ItemLength": 1,
+                "StartDate": "2025-01-10T00:00:00Z",
+                "EndDate": "2025-01-15T00:00:00Z",
+                "IsActive": false
+              }
+            ]
+          }
+        ]
+      }
+      """
+    And I check the stored data
+    Then the violations should be:
+      | location    | problem                                              |
+      | warehouse 1 | overbooked by up to 5 from 2025-01-11 to 2025-01-12  |
+      | warehouse 1 | overbooked by 3 on 2025-01-15                        |
@@ -0,0 +1,62 @@
+Feature: CustomerQuotas
+
+  #------------------------------------------
+  # Scenario 1: Per-warehouse quota
+  #------------------------------------------
+  Scenario: Customer is sent elsewhere when its warehouse quota is used up
+    Given today is "2025-01-01"
+    And I have 2 warehouse with total volume 10.0
+    And customer 7 may store 5.0 in warehouse 1
+    And warehouse 1 is booked with volume 4.0 from "2025-01-10" to "2025-01-10"
+    And item 1 belongs to customer 7
+    When customer 7 looks for a warehouse from "2025-01-10" to "2025-01-10" with dimensions:
+      | height | width | length |
+      | 2.0    | 1.0   | 1.0    |
+    Then I should receive warehouse ID 2
+
+  #------------------------------------------
+  # Scenario 2: Global quota
+  #------------------------------------------
+  Scenario: Global quota stops a customer from taking more space
+    Given today is "2025-01-01"
+    And I have 2 warehouse with total volume 10.0
+    And customer 7 may store 5.0 in total
+    And warehouse 1 is booked with volume 4.0 from "2025-01-10" to "2025-01-10"
+    And item 1 belongs to customer 7
+    When customer 7 looks for a warehouse from "2025-01-09" to "2025-01-10" with dimensions:
+      | height | width | length |
+      | 2.0    | 1.0   | 1.0    |
+    Then an error should be returned with message "the customer quota would be exceeded"
+
+  #------------------------------------------
+  # Scenario 3: Other customers are not limited
+  #------------------------------------------
+  Scenario: Quota of one customer does not affect anonymous stays
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And customer 7 may store 5.0 in total
+    And warehouse 1 is booked with volume 5.0 from "2025-01-10" to "2025-01-10"
+    And item 1 belongs to customer 7
+    When I call FindAvailableWarehouse from "2025-01-10" to "2025-01-10" with dimensions:
+      | height | width | length |
+      | 5.0    | 1.0   | 1.0    |
+    Then I should receive warehouse ID 1
+
+  #------------------------------------------
+  # Scenario 4: Capacity and usage per customer
+  #------------------------------------------
+  Scenario: Available capacity is capped by the remaining quota
+    Given I have 2 warehouse with total volume 10.0
+    And customer 7 may store 12.0 in total
+    And customer 7 may store 3.0 in warehouse 2
+    And warehouse 1 is booked with volume 4.0 from "2025-01-10" to "2025-01-10"
+    And item 1 belongs to customer 7
+    When I calculate the available capacity for customer 7 from "2025-01-09" to "2025-01-10"
+    Then the available capacities should be:
+      | date       | capacity |
+      | 2025-01-09 | 12.0     |
+      | 2025-01-10 | 8.0      |
+    And the usage of customer 7 from "2025-01-09" to "2025-01-10" should be:
+      | date       | usage |
+      | 2025-01-09 | 0.0   |
+      | 2025-01-10 | 4.0   |
@@ -0,0 +1,164 @@
+Feature: EventLog
+
+  #------------------------------------------
+  # Scenario 1: Recording changes
+  #------------------------------------------
+  Scenario: Every change is recorded as an event
+    Given today is "2025-01-01"
+    And the warehouses are stored in an event log
+    When I add warehouse 1 with total volume 10.0
+    And I add warehouse 2 with total volume 10.0
+    And I reserve volume 4.0 from "2025-01-10" to "2025-01-12"
+    And I reserve volume 2.0 from "2025-01-10" to "2025-01-12"
+    And I cancel item 2
+    And I extend item 1 to "2025-01-14"
+    And I transfer item 1 to warehouse 2 on "2025-01-11"
+    And I reduce the capacity of warehouse 1 by 5.0 from "2025-01-20" to "2025-01-25"
+    Then the recorded events should be:
+      | sequence | type             |
+      | 1        | WarehouseCreated |
+      | 2        | WarehouseCreated |
+      | 3        | ItemReserved     |
+      | 4        | ItemReserved     |
+      | 5        | ItemCancelled    |
+      | 6        | ItemExtended     |
+      | 7        | ItemTransferred  |
+      | 8        | CapacityChanged  |
+
+  Scenario: Failed operations record nothing
+    Given today is "2025-01-01"
+    And the warehouses are stored in an event log
+    When I add warehouse 1 with total volume 10.0
+    And I add warehouse 1 with total volume 5.0
+    Then an error should be returned with message "a warehouse with this id already exists"
+    When I reserve volume 4.0 from "2025-01-10" to "2025-01-12"
+    And I extend item 1 to "2025-01-11"
+    Then an error should be returned with message "the new end date must be later than the current end date"
+    When I cancel item 1
+    And I cancel item 1
+    Then an error should be returned with message "the item is not active"
+    And the recorded events should be:
+      | sequence | type             |
+      | 1        | WarehouseCreated |
+      | 2        | ItemReserved     |
+      | 3        | ItemCancelled    |
+
+  Scenario: An extension must fit in the warehouse
+    Given today is "2025-01-01"
+    And the warehouses are stored in an event log
+    When I add warehouse 1 with total volume 10.0
+    And I reserve volume 6.0 from "2025-01-10" to "2025-01-12"
+    And I reserve volume 5.0 from "2025-01-13" to "2025-01-15"
+    And I extend item 1 to "2025-01-13"
+    Then an error should be returned with message "required volume cannot be accommodated within the specified dates"
+
+  #------------------------------------------
+  # Scenario 2: Rebuilding the state
+  #------------------------------------------
+  Scenario: Reopening the log replays the events
+    Given today is "2025-01-01"
+    And the warehouses are stored in an event log
+    When I add warehouse 1 with total volume 10.0
+    And I reserve volume 4.0 from "2025-01-10" to "2025-01-12"
+    And I reserve volume 2.0 from "2025-01-10" to "2025-01-12"
+    And I cancel item 2
+    And I extend item 1 to "2025-01-13"
+    And I reopen the event log
+    Then warehouse 1 should hold 2 items
+    When I call CalculateAvailableCapacity from "2025-01-12" to "2025-01-14"
+    Then the available capacities should be:
+      | date       | capacity |
+      | 2025-01-12 | 6.0      |
+      | 2025-01-13 | 6.0      |
+      | 2025-01-14 | 10.0     |
+
+  Scenario: Snapshots are taken periodically and used on reopening
+    Given today is "2025-01-01"
+    And the warehouses are stored in an event log
+    And the event log takes a snapshot every 3 events
+    When I add warehouse 1 with total volume 10.0
+    And I reserve volume 4.0 from "2025-01-10" to "2025-01-12"
+    And I reserve volume 2.0 from "2025-01-10" to "2025-01-12"
+    And I reserve volume 1.0 from "2025-01-10" to "2025-01-12"
+    Then the snapshot should cover 3 events
+    When I reopen the event log
+    Then warehouse 1 should hold 3 items
+    When I call CalculateAvailableCapacity from "2025-01-10" to "2025-01-10"
+    Then the available capacities should be:
+      | date       | capacity |
+      | 2025-01-10 | 3.0      |
+
+  Scenario: An event cut off while it was written is discarded
+    Given today is "2025-01-01"
+    And the warehouses are stored in an event log
+    When I add warehouse 1 with total volume 10.0
+    And I reserve volume 4.0 from "2025-01-10" to "2025-01-12"
+    And I reserve volume 2.0 from "2025-01-10" to "2025-01-12"
+    And the last event is only partially written
+    And I reopen the event log
+    Then warehouse 1 should hold 1 items
+    When I reserve volume 1.0 from "2025-01-10" to "2025-01-12"
+    Then the recorded events should be:
+      | sequence | type             |
+      | 1        | WarehouseCreated |
+      | 2        | ItemReserved     |
+      | 3        | ItemReserved     |
+
+  #------------------------------------------
+  # Scenario 3: Failed writes
+  #------------------------------------------
+  Scenario: Events that failed to reach the disk leave no trace
+    Given today is "2025-01-01"
+    And the warehouses are stored in an event log
+    When I add warehouse 1 with total volume 10.0
+    And the disk fails while the next event is written
+    And I reserve volume 4.0 from "2025-01-10" to "2025-01-12"
+    Then an error should be returned with message "no space left on device"
+    When the disk fails while the next event is synced
+    And I reserve volume 3.0 from "2025-01-10" to "2025-01-12"
+    Then an error should be returned with message "input/output error"
+    When I reserve volume 2.0 from "2025-01-10" to "2025-01-12"
+    And I reopen the event log
+    Then warehouse 1 should hold 1 items
+    And the recorded events should be:
+      | sequence | type             |
+      | 1        | WarehouseCreated |
+      | 2        | ItemReserved     |
+
+  #------------------------------------------
+  # Scenario 4: Log segments
+  #------------------------------------------
+  Scenario: Reopening only reads the segment written since the snapshot
+    Given today is "2025-01-01"
+    And the warehouses are stored in an event log
+    And the event log takes a snapshot every 2 events
+    When I add warehouse 1 with total volume 10.0
+    And I reserve volume 4.0 from "2025-01-10" to "2025-01-12"
+    And I reserve volume 2.0 from "2025-01-10" to "2025-01-12"
+    Then the event log should have 2 segments
+    When the first log segment is damaged
+    And I reopen the event log
+    Then warehouse 1 should hold 2 items
+
+  #------------------------------------------
+  # Scenario 5: State of the service
+  #------------------------------------------
+  Scenario: Customers and the waitlist survive reopening
+    Given today is "2025-01-01"
+    And the warehouses are stored in an event log
+    And the event log takes a snapshot every 3 events
+    When I add warehouse 1 with total volume 10.0
+    And I register customer 7
+    And I reserve volume 6.0 from "2025-01-10" to "2025-01-10"
+    And I reserve with priority 5 from "2025-01-10" to "2025-01-10" with dimensions:
+      | height | width | length |
+      | 8.0    | 1.0   | 1.0    |
+    And I reopen the event log
+    Then the service should know 1 customer
+    And the waitlist should hold 1 item
+    And the recorded events should be:
+      | sequence | type                 |
+      | 1        | WarehouseCreated     |
+      | 2        | CustomersChanged     |
+      | 3        | ItemReserved         |
+      | 4        | ReservationPreempted |
@@ -56,4 +56,26 @@ Feature: FindAvailableWarehouse
     When I call FindAvailableWarehouse from "2025-01-10" to "2025-01-11" with dimensions:
       | height | width | length |
       | 1.0    | 1.0   | 1.0    |
-    Then an error should be returned with message "no warehouses available"
\ No newline at end of file
+    Then an error should be returned with message "no warehouses available"
+
+  #------------------------------------------
+  # Scenario 6: Falling back to the next warehouse
+  #------------------------------------------
+  Scenario: A full warehouse is skipped for the next one with space
+    Given today is "2025-01-09"
+    And I have 2 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 8.0 from "2025-01-10" to "2025-01-11"
+    When I call FindAvailableWarehouse from "2025-01-10" to "2025-01-11" with dimensions:
+      | height | width | length |
+      | 5.0    | 1.0   | 1.0    |
+    Then I should receive warehouse ID 2
+
+  Scenario: An error is returned only when no warehouse has space
+    Given today is "2025-01-09"
+    And I have 2 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 8.0 from "2025-01-10" to "2025-01-11"
+    And warehouse 2 is booked with volume 6.0 from "2025-01-11" to "2025-01-11"
+    When I call FindAvailableWarehouse from "2025-01-10" to "2025-01-11" with dimensions:
+      | height | width | length |
+      | 5.0    | 1.0   | 1.0    |
+    Then an error should be returned with message "required volume cannot be accommodated within the specified dates"
@@ -0,0 +1,63 @@
+Feature: FindEarliestAvailableSlot
+
+  #------------------------------------------
+  # Scenario 1: Empty warehouse
+  #------------------------------------------
+  Scenario: Slot starts on the first allowed day
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    When I search the earliest slot of 10 days between "2025-01-10" and "2025-01-31" with dimensions:
+      | height | width | length |
+      | 4.0    | 1.0   | 1.0    |
+    Then the earliest slot should be from "2025-01-10" to "2025-01-19" in warehouse 1
+
+  #------------------------------------------
+  # Scenario 2: Existing booking in the way
+  #------------------------------------------
+  Scenario: Slot starts after the booking ends
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 8.0 from "2025-01-12" to "2025-01-14"
+    When I search the earliest slot of 3 days between "2025-01-10" and "2025-01-31" with dimensions:
+      | height | width | length |
+      | 4.0    | 1.0   | 1.0    |
+    Then the earliest slot should be from "2025-01-15" to "2025-01-17" in warehouse 1
+
+  #------------------------------------------
+  # Scenario 3: Another warehouse is free earlier
+  #------------------------------------------
+  Scenario: Earliest start across warehouses
+    Given today is "2025-01-01"
+    And I have 2 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 8.0 from "2025-01-10" to "2025-01-20"
+    And warehouse 2 is booked with volume 8.0 from "2025-01-10" to "2025-01-11"
+    When I search the earliest slot of 2 days between "2025-01-10" and "2025-01-31" with dimensions:
+      | height | width | length |
+      | 4.0    | 1.0   | 1.0    |
+    Then the earliest slot should be from "2025-01-12" to "2025-01-13" in warehouse 2
+
+  #------------------------------------------
+  # Scenario 4: Buffers and closures
+  #------------------------------------------
+  Scenario: Slot respects buffer days and closed days
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 needs 1 day before and 1 day after each stay
+    And warehouse 1 is booked with volume 8.0 from "2025-01-10" to "2025-01-10"
+    And warehouse 1 is closed from "2025-01-13" to "2025-01-13"
+    When I search the earliest slot of 2 days between "2025-01-10" and "2025-01-31" with dimensions:
+      | height | width | length |
+      | 4.0    | 1.0   | 1.0    |
+    Then the earliest slot should be from "2025-01-14" to "2025-01-15" in warehouse 1
+
+  #------------------------------------------
+  # Scenario 5: Nothing fits in the window
+  #------------------------------------------
+  Scenario: No slot within the search window
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 8.0 from "2025-01-10" to "2025-01-20"
+    When I search the earliest slot of 2 days between "2025-01-10" and "2025-01-15" with dimensions:
+      | height | width | length |
+      | 4.0    | 1.0   | 1.0    |
+    Then an error should be returned with message "no warehouse can accommodate the item within the search window"
@@ -0,0 +1,415 @@
+Feature: HTTPAPI
+
+  #------------------------------------------
+  # Scenario 1: Warehouses
+  #------------------------------------------
+  Scenario: Warehouses are created, read, updated and removed
+    Given today is "2025-01-01"
+    When I send a POST request to "/warehouses" with:
+      """
+      { "Id": 1, "MaxCapacity": { "Height": 10, "Width": 1, "Length": 1 } }
+      """
+    Then the response status should be 201
+    And the response header "Location" should be "/warehouses/1"
+    And the response body should include:
+      """
+      { "Id": 1, "Version": 1, "MaxCapacity": { "Height": 10 } }
+      """
+    When I send a PUT request to "/warehouses/1" with:
+      """
+      { "MaxCapacity": { "Height": 20, "Width": 1, "Length": 1 }, "Version": 1 }
+      """
+    Then the response status should be 200
+    And the response body should include:
+      """
+      { "Id": 1, "Version": 2, "MaxCapacity": { "Height": 20 } }
+      """
+    When I send a GET request to "/warehouses"
+    Then the response status should be 200
+    And the response body should include:
+      """
+      [ { "Id": 1, "Version": 2 } ]
+      """
+    When I send a DELETE request to "/warehouses/1"
+    Then the response status should be 204
+    When I send a GET request to "/warehouses/1"
+    Then the response status should be 404
+    And the response body should be:
+      """
+      { "Error": "warehouse not found" }
+      """
+
+  Scenario: Stale warehouse updates are rejected with the current state
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    When I send a PUT request to "/warehouses/1" with:
+      """
+      { "MaxCapacity": { "Height": 20, "Width": 1, "Length": 1 }, "Version": 1 }
+      """
+    And I send a PUT request to "/warehouses/1" with:
+      """
+      { "MaxCapacity": { "Height": 30, "Width": 1, "Length": 1 }, "Version": 1 }
+      """
+    Then the response status should be 409
+    And the response body should include:
+      """
+      {
+        "Error": "warehouse 1 was changed concurrently (expected version 1, found version 2): MaxCapacity",
+        "Current": { "Id": 1, "Version": 2, "MaxCapacity": { "Height": 20 } },
+        "Changes": [ "MaxCapacity" ]
+      }
+      """
+
+  Scenario: Warehouses holding items are kept
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 4.0 from "2025-01-10" to "2025-01-12"
+    When I send a DELETE request to "/warehouses/1"
+    Then the response status should be 409
+    And the response body should be:
+      """
+      { "Error": "the warehouse still holds active items" }
+      """
+
+  #------------------------------------------
+  # Scenario 2: Items
+  #------------------------------------------
+  Scenario: Items are reserved, read, updated and cancelled
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    When I send a POST request to "/items" with:
+      """
+      {
+        "ItemName": "Pallet",
+        "ItemHeight": 4, "ItemWidth": 1, "ItemLength": 1,
+        "StartDate": "2025-01-10T00:00:00Z",
+        "EndDate": "2025-01-12T00:00:00Z"
+      }
+      """
+    Then the response status should be 201
+    And the response header "Location" should be "/items/1"
+    And the response body should include:
+      """
+      { "ItemId": 1, "WarehouseId": 1, "IsActive": true, "Version": 1 }
+      """
+    When I send a PUT request to "/items/1" with:
+      """
+      {
+        "ItemName": "Pallet",
+        "ItemHeight": 6, "ItemWidth": 1, "ItemLength": 1,
+        "StartDate": "2025-01-10T00:00:00Z",
+        "EndDate": "2025-01-14T00:00:00Z",
+        "Version": 1
+      }
+      """
+    Then the response status should be 200
+    And the response body should include:
+      """
+      { "ItemId": 1, "ItemHeight": 6, "EndDate": "2025-01-14T00:00:00Z", "Version": 2 }
+      """
+    When I send a PUT request to "/items/1" with:
+      """
+      {
+        "ItemHeight": 11, "ItemWidth": 1, "ItemLength": 1,
+        "StartDate": "2025-01-10T00:00:00Z",
+        "EndDate": "2025-01-14T00:00:00Z",
+        "Version": 2
+      }
+      """
+    Then the response status should be 409
+    And the response body should be:
+      """
+      { "Error": "required volume cannot be accommodated within the specified dates" }
+      """
+    When I send a DELETE request to "/items/1"
+    Then the response status should be 204
+    When I send a GET request to "/items/1"
+    Then the response status should be 200
+    And the response body should include:
+      """
+      { "ItemId": 1, "WarehouseId": 1, "IsActive": false }
+      """
+    When I send a DELETE request to "/items/1"
+    Then the response status should be 409
+    And the response body should be:
+      """
+      { "Error": "the item is not active" }
+      """
+
+  Scenario: Stale item updates are rejected
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    When I send a POST request to "/items" with:
+      """
+      {
+        "ItemHeight": 4, "ItemWidth": 1, "ItemLength": 1,
+        "StartDate": "2025-01-10T00:00:00Z",
+        "EndDate": "2025-01-12T00:00:00Z"
+      }
+      """
+    And I send a PUT request to "/items/1" with:
+      """
+      {
+        "ItemHeight": 5, "ItemWidth": 1, "ItemLength": 1,
+        "StartDate": "2025-01-10T00:00:00Z",
+        "EndDate": "2025-01-12T00:00:00Z",
+        "Version": 1
+      }
+      """
+    And I send a PUT request to "/items/1" with:
+      """
+      {
+        "ItemHeight": 3, "ItemWidth": 1, "ItemLength": 1,
+        "StartDate": "2025-01-10T00:00:00Z",
+        "EndDate": "2025-01-12T00:00:00Z",
+        "Version": 1
+      }
+      """
+    Then the response status should be 409
+    And the response body should include:
+      """
+      { "Error": "item 1 in warehouse 1 was changed concurrently (expected version 1, found version 2): ItemHeight" }
+      """
+
+  #------------------------------------------
+  # Scenario 3: Capacity queries
+  #------------------------------------------
+  Scenario: Capacity is queried by date range
+    Given today is "2025-01-01"
+    And I have 2 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 10.0 from "2025-01-10" to "2025-01-11"
+    And warehouse 2 is booked with volume 4.0 from "2025-01-11" to "2025-01-12"
+    When I send a GET request to "/capacity?start=2025-01-10&end=2025-01-12"
+    Then the response status should be 200
+    And the response body should be:
+      """
+      [
+        { "Date": "2025-01-10", "Available": 10 },
+        { "Date": "2025-01-11", "Available": 6 },
+        { "Date": "2025-01-12", "Available": 16 }
+      ]
+      """
+    When I send a GET request to "/fully-utilized-dates?start=2025-01-10&end=2025-01-12"
+    Then the response status should be 200
+    And the response body should be:
+      """
+      []
+      """
+    When I send a GET request to "/warehouses/available?start=2025-01-11&end=2025-01-11&height=5&width=1&length=1"
+    Then the response status should be 200
+    And the response body should be:
+      """
+      { "WarehouseId": 2 }
+      """
+    When I send a GET request to "/warehouses/least-used?start=2025-01-10&end=2025-01-12"
+    Then the response status should be 200
+    And the response body should be:
+      """
+      { "WarehouseId": 2 }
+      """
+
+  #------------------------------------------
+  # Scenario 4: Invalid requests
+  #------------------------------------------
+  Scenario Outline: Invalid requests are answered with a client error
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    When I send a GET request to "<target>"
+    Then the response status should be <status>
+    And the response body should be:
+      """
+      { "Error": "<error>" }
+      """
+
+    Examples:
+      | target                                                                     | status | error                                                    |
+      | /capacity?start=2025-01-10&end=soon                                        | 400    | the end date \"soon\" is not a valid date                |
+      | /capacity?start=2025-01-12&end=2025-01-10                                  | 400    | the start date cannot be later than the end date         |
+      | /warehouses/available?start=2025-01-10&end=2025-01-12&height=0&width=1&length=1 | 400 | the 3D model has invalid dimensions (zero or negative) |
+      | /warehouses/available?start=2025-01-10&end=2025-01-12&height=20&width=1&length=1 | 409 | required volume cannot be accommodated within the specified dates |
+      | /warehouses/abc                                                            | 400    | the id \"abc\" is not a whole number                     |
+      | /items/7                                                                   | 404    | item not found                                           |
+
+  Scenario: Unknown fields in a body are rejected
+    When I send a POST request to "/warehouses" with:
+      """
+      { "Id": 1, "Shelves": 3 }
+      """
+    Then the response status should be 400
+    And the response body should include:
+      """
+      { "Error": "the request body is not valid: json: unknown field \"Shelves\"" }
+      """
+
+  Scenario Outline: Fields the service keeps are refused in new resources
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    When I send a POST request to "<target>" with:
+      """
+      <body>
+      """
+    Then the response status should be 400
+    And the response body should be:
+      """
+      { "Error": "the request body is not valid: json: unknown field \"<field>\"" }
+      """
+
+    Examples:
+      | target      | body                                                                                          | field        |
+      | /warehouses | { "Id": 2, "MaxCapacity": { "Height": 10, "Width": 1, "Length": 1 }, "Items": [] }            | Items        |
+      | /warehouses | { "Id": 2, "MaxCapacity": { "Height": 10, "Width": 1, "Length": 1 }, "Version": 5 }           | Version      |
+      | /items      | { "ItemHeight": 1, "ItemWidth": 1, "ItemLength": 1, "CheckedInAt": "2025-01-10T00:00:00Z" }   | CheckedInAt  |
+      | /items      | { "ItemHeight": 1, "ItemWidth": 1, "ItemLength": 1, "CheckedOutAt": "2025-01-10T00:00:00Z" }  | CheckedOutAt |
+      | /items      | { "ItemHeight": 1, "ItemWidth": 1, "ItemLength": 1, "ShipmentId": 4 }                         | ShipmentId   |
+      | /items      | { "ItemHeight": 1, "ItemWidth": 1, "ItemLength": 1, "Version": 7 }                            | Version      |
+
+  #------------------------------------------
+  # Scenario 5: Acting for the caller
+  #------------------------------------------
+  Scenario: Changes are audited for the actor named in the request
+    Given today is "2025-01-01"
+    And the service keeps an audit log
+    When I send a POST request to "/warehouses" as "alice" because "new site" with:
+      """
+      { "Id": 1, "MaxCapacity": { "Height": 10, "Width": 1, "Length": 1 } }
+      """
+    Then the response status should be 201
+    When I send a POST request to "/items" as "bob" because "order 17" with:
+      """
+      {
+        "ItemHeight": 4, "ItemWidth": 1, "ItemLength": 1,
+        "StartDate": "2025-01-10T00:00:00Z",
+        "EndDate": "2025-01-12T00:00:00Z"
+      }
+      """
+    Then the response status should be 201
+    And the audit entries for warehouse 1 should be:
+      | sequence | recorded   | actor | reason   | operation        | warehouse | item | changes |
+      | 1        | 2025-01-01 | alice | new site | WarehouseCreated | 1         |      | created |
+      | 2        | 2025-01-01 | bob   | order 17 | ItemReserved     | 1         | 1    | created |
+
+  Scenario: Audited changes without an actor are refused
+    Given today is "2025-01-01"
+    And the service keeps an audit log
+    When I send a POST request to "/warehouses" with:
+      """
+      { "Id": 1, "MaxCapacity": { "Height": 10, "Width": 1, "Length": 1 } }
+      """
+    Then the response status should be 400
+    And the response body should be:
+      """
+      { "Error": "changes to audited data must name an actor" }
+      """
+
+  #------------------------------------------
+  # Scenario 6: Storage failures
+  #------------------------------------------
+  Scenario: Storage failures are answered without their details
+    Given today is "2025-01-01"
+    And the warehouses are stored in an event log
+    When I add warehouse 1 with total volume 10.0
+    And the disk fails while the next event is written
+    And I send a POST request to "/items" with:
+      """
+      {
+        "ItemHeight": 4, "ItemWidth": 1, "ItemLength": 1,
+        "StartDate": "2025-01-10T00:00:00Z",
+        "EndDate": "2025-01-12T00:00:00Z"
+      }
+      """
+    Then the response status should be 500
+    And the response body should be:
+      """
+      { "Error": "internal server error" }
+      """
+
+  #------------------------------------------
+  # Scenario 7: Errors of the other operations
+  #------------------------------------------
+  Scenario: A transfer to an unknown warehouse is answered with not found
+    Given today is "2025-01-01"
+    And I have 2 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 4.0 from "2025-01-10" to "2025-01-20"
+    When I transfer item 1 to warehouse 3 on "2025-01-15"
+    And the API reports the error
+    Then the response status should be 404
+    And the response body should be:
+      """
+      { "Error": "target warehouse not found" }
+      """
+
+  Scenario: A transfer the target cannot hold is answered with a conflict
+    Given today is "2025-01-01"
+    And I have 2 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 4.0 from "2025-01-10" to "2025-01-20"
+    And warehouse 2 is booked with volume 8.0 from "2025-01-10" to "2025-01-20"
+    When I transfer item 1 to warehouse 2 on "2025-01-15"
+    And the API reports the error
+    Then the response status should be 409
+    And the response body should be:
+      """
+      { "Error": "the target warehouse cannot accommodate the item for the remaining days" }
+      """
+
+  Scenario: A transfer dated in the past is answered with a client error
+    Given today is "2025-01-16"
+    And I have 2 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 4.0 from "2025-01-10" to "2025-01-20"
+    When I transfer item 1 to warehouse 2 on "2025-01-15"
+    And the API reports the error
+    Then the response status should be 400
+    And the response body should be:
+      """
+      { "Error": "the transfer date cannot be in the past" }
+      """
+
+  Scenario: Removing an unknown capacity block is answered with not found
+    Given I have 1 warehouse with total volume 10.0
+    When I remove capacity block 9
+    And the API reports the error
+    Then the response status should be 404
+    And the response body should be:
+      """
+      { "Error": "capacity block not found" }
+      """
+
+  Scenario: Checking out an item never checked in is answered with a conflict
+    Given today is "2025-01-11"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 4.0 from "2025-01-10" to "2025-01-12"
+    And item 1 was checked out on "2025-01-11"
+    When the API reports the error
+    Then the response status should be 409
+    And the response body should be:
+      """
+      { "Error": "the item has not been checked in" }
+      """
+
+  Scenario: Extending a stay to an earlier end is answered with a client error
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 4.0 from "2025-01-10" to "2025-01-12"
+    When I extend item 1 to "2025-01-11"
+    And the API reports the error
+    Then the response status should be 400
+    And the response body should be:
+      """
+      { "Error": "the new end date must be later than the current end date" }
+      """
+
+  Scenario: An unbounded recurrence is answered with a client error
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And a recurring reservation from "2025-01-04" to "2025-01-05" with dimensions:
+      | height | width | length |
+      | 1.0    | 1.0   | 1.0    |
+    And the reservation recurs:
+      | frequency | interval | by day | by month day | count | until |
+      | daily     | 1        |        |              |       |       |
+    When I reserve the recurring reservation accepting all occurrences
+    And the API reports the error
+    Then the response status should be 400
+    And the response body should be:
+      """
+      { "Error": "the recurrence must be bounded by a count or an until date" }
+      """
@@ -0,0 +1,64 @@
+Feature: OperatingCalendar
+
+  #------------------------------------------
+  # Scenario 1: Check-in on a closed day
+  #------------------------------------------
+  Scenario: Stay starting during a closure is refused
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is closed from "2025-01-10" to "2025-01-10"
+    When I call FindAvailableWarehouse from "2025-01-10" to "2025-01-12" with dimensions:
+      | height | width | length |
+      | 1.0    | 1.0   | 1.0    |
+    Then an error should be returned with message "no warehouse is open for check-in and check-out on the specified dates"
+
+  #------------------------------------------
+  # Scenario 2: Closure in the middle of the stay
+  #------------------------------------------
+  Scenario: Goods can stay while the warehouse is closed
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is closed from "2025-01-11" to "2025-01-11"
+    When I call FindAvailableWarehouse from "2025-01-10" to "2025-01-12" with dimensions:
+      | height | width | length |
+      | 1.0    | 1.0   | 1.0    |
+    Then I should receive warehouse ID 1
+
+  #------------------------------------------
+  # Scenario 3: Weekly closing day
+  #------------------------------------------
+  Scenario: Check-out on a weekly closing day moves the stay to another warehouse
+    Given today is "2025-01-01"
+    And I have 2 warehouse with total volume 10.0
+    And warehouse 1 is closed every Sunday
+    When I call FindAvailableWarehouse from "2025-01-10" to "2025-01-12" with dimensions:
+      | height | width | length |
+      | 1.0    | 1.0   | 1.0    |
+    Then I should receive warehouse ID 2
+
+  #------------------------------------------
+  # Scenario 4: Warehouse lifetime
+  #------------------------------------------
+  Scenario: No capacity before commissioning or after decommissioning
+    Given I have 1 warehouse with total volume 10.0
+    And warehouse 1 is commissioned on "2025-01-11"
+    And warehouse 1 is decommissioned on "2025-01-13"
+    When I call CalculateAvailableCapacity from "2025-01-10" to "2025-01-13"
+    Then the available capacities should be:
+      | date       | capacity |
+      | 2025-01-10 | 0.0      |
+      | 2025-01-11 | 10.0     |
+      | 2025-01-12 | 10.0     |
+      | 2025-01-13 | 0.0      |
+
+  #------------------------------------------
+  # Scenario 5: Stay beyond decommissioning
+  #------------------------------------------
+  Scenario: Stay must end before the warehouse is decommissioned
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is decommissioned on "2025-01-12"
+    When I call FindAvailableWarehouse from "2025-01-10" to "2025-01-12" with dimensions:
+      | height | width | length |
+      | 1.0    | 1.0   | 1.0    |
+    Then an error should be returned with message "no warehouse is open for check-in and check-out on the specified dates"
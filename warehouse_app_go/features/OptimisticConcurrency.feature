@@ -0,0 +1,102 @@
+Feature: OptimisticConcurrency
+
+  #------------------------------------------
+  # Scenario 1: Versions
+  #------------------------------------------
+  Scenario: Every stored change advances the version
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    Then warehouse 1 should be at version 1
+    When I reserve volume 4.0 from "2025-01-10" to "2025-01-12"
+    Then warehouse 1 should be at version 2
+    And item 1 should be at version 1
+    When I extend item 1 to "2025-01-13"
+    Then warehouse 1 should be at version 3
+    And item 1 should be at version 2
+
+  #------------------------------------------
+  # Scenario 2: Concurrent edits of a warehouse
+  #------------------------------------------
+  Scenario: An edit based on an outdated version is rejected
+    Given I have 1 warehouse with total volume 10.0
+    And planner "A" opens warehouse 1
+    And planner "B" opens warehouse 1
+    When planner "A" sets the volume of warehouse 1 to 20.0
+    And planner "B" closes warehouse 1 every Sunday
+    Then an error should be returned with message "warehouse 1 was changed concurrently (expected version 1, found version 2): MaxCapacity, Calendar"
+    And warehouse 1 should be at version 2
+
+  Scenario: The edit succeeds after reopening the warehouse
+    Given I have 1 warehouse with total volume 10.0
+    And planner "A" opens warehouse 1
+    And planner "B" opens warehouse 1
+    When planner "A" sets the volume of warehouse 1 to 20.0
+    And planner "B" closes warehouse 1 every Sunday
+    Then an error should be returned with message "was changed concurrently"
+    Given planner "B" opens warehouse 1
+    When planner "B" closes warehouse 1 every Sunday
+    Then warehouse 1 should be at version 3
+    And warehouse 1 should have total volume 20.0
+    And warehouse 1 should be closed on 1 weekday
+
+  Scenario: The conflict names the items that were added in the meantime
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And planner "A" opens warehouse 1
+    When I reserve volume 4.0 from "2025-01-10" to "2025-01-12"
+    And planner "A" sets the volume of warehouse 1 to 2.0
+    Then an error should be returned with message "warehouse 1 was changed concurrently (expected version 1, found version 2): MaxCapacity, item 1 added"
+
+  #------------------------------------------
+  # Scenario 3: Concurrent edits of an item
+  #------------------------------------------
+  Scenario: An item update based on an outdated version is rejected
+    Given I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 1.0 from "2025-01-10" to "2025-01-12"
+    And planner "A" opens warehouse 1
+    And item 1 was checked in on "2025-01-10"
+    When planner "A" moves the end of item 1 to "2025-01-14"
+    Then an error should be returned with message "item 1 in warehouse 1 was changed concurrently (expected version 1, found version 2): EndDate, CheckedInAt"
+
+  Scenario: The same checks apply to the database
+    Given the warehouses are stored in a database
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 1.0 from "2025-01-10" to "2025-01-12"
+    And planner "A" opens warehouse 1
+    And planner "B" opens warehouse 1
+    And item 1 was checked in on "2025-01-10"
+    When planner "A" moves the end of item 1 to "2025-01-14"
+    Then an error should be returned with message "item 1 in warehouse 1 was changed concurrently (expected version 1, found version 2): EndDate, CheckedInAt"
+    When planner "B" sets the volume of warehouse 1 to 20.0
+    Then an error should be returned with message "warehouse 1 was changed concurrently (expected version 2, found version 3): MaxCapacity, item 1 changed"
+
+  #------------------------------------------
+  # Scenario 4: Concurrent reservations
+  #------------------------------------------
+  Scenario: Reservations made at the same time never overbook a warehouse
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    When 25 reservations of volume 1.0 from "2025-01-10" to "2025-01-12" are made at the same time
+    Then no day from "2025-01-10" to "2025-01-12" should be overbooked
+    And every rejected reservation should report a conflict or missing space
+
+  #------------------------------------------
+  # Scenario 5: Item ids
+  #------------------------------------------
+  Scenario: An item id taken by a reservation made at the same time is a conflict
+    Given today is "2025-01-01"
+    And I have 2 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 6.0 from "2025-01-10" to "2025-01-12"
+    When I reserve volume 4.0 from "2025-01-10" to "2025-01-12" while a reservation of volume 8.0 is made
+    Then an error should be returned with message "item 2 in warehouse 2 was changed concurrently (expected version 0, found version 1): item 2 added"
+    When I reserve volume 4.0 from "2025-01-10" to "2025-01-12"
+    Then warehouse 1 should hold 2 items
+    And warehouse 2 should hold 1 items
+
+  Scenario: The database reports the same conflict
+    Given today is "2025-01-01"
+    And the warehouses are stored in a database
+    And I have 2 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 6.0 from "2025-01-10" to "2025-01-12"
+    When I reserve volume 4.0 from "2025-01-10" to "2025-01-12" while a reservation of volume 8.0 is made
+    Then an error should be returned with message "item 2 in warehouse 2 was changed concurrently (expected version 0, found version 1): item 2 added"
@@ -0,0 +1,82 @@
+Feature: OptimizeAssignment
+
+  #------------------------------------------
+  # Scenario 1: Local search after best-fit
+  #------------------------------------------
+  Scenario: Relocating a request makes room for a rejected one
+    Given today is "2025-01-01"
+    And I have warehouses with usage:
+      | id | volume | usage |
+      | 1  | 7.0    | 0.0   |
+      | 2  | 8.0    | 0.0   |
+    And the pending storage requests:
+      | id | volume | start      | end        |
+      | 1  | 4.0    | 2025-01-12 | 2025-01-13 |
+      | 2  | 3.0    | 2025-01-11 | 2025-01-13 |
+      | 3  | 2.0    | 2025-01-11 | 2025-01-13 |
+      | 4  | 6.0    | 2025-01-10 | 2025-01-11 |
+    When I optimize the assignment for volume
+    Then the requests should be assigned as:
+      | request | warehouse |
+      | 1       | 1         |
+      | 2       | 2         |
+      | 3       | 2         |
+      | 4       | 1         |
+    And the accepted volume should be 35.0
+
+  #------------------------------------------
+  # Scenario 2: Not everything fits
+  #------------------------------------------
+  Scenario: Smallest request is rejected with a reason
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And the pending storage requests:
+      | id | volume | start      | end        |
+      | 1  | 5.0    | 2025-01-10 | 2025-01-10 |
+      | 2  | 6.0    | 2025-01-10 | 2025-01-10 |
+      | 3  | 4.0    | 2025-01-10 | 2025-01-10 |
+      | 4  | 0.0    | 2025-01-10 | 2025-01-10 |
+    When I optimize the assignment for volume
+    Then the requests should be assigned as:
+      | request | warehouse |
+      | 2       | 1         |
+      | 3       | 1         |
+    And the rejected requests should be:
+      | request | reason                                                            |
+      | 1       | required volume cannot be accommodated within the specified dates |
+      | 4       | the 3D model has invalid dimensions (zero or negative)            |
+
+  #------------------------------------------
+  # Scenario 3: Maximize value
+  #------------------------------------------
+  Scenario: Valuable requests win over large ones
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And the pending storage requests:
+      | id | volume | start      | end        | value |
+      | 1  | 8.0    | 2025-01-10 | 2025-01-10 | 1.0   |
+      | 2  | 5.0    | 2025-01-10 | 2025-01-10 | 10.0  |
+      | 3  | 5.0    | 2025-01-10 | 2025-01-10 | 10.0  |
+    When I optimize the assignment for value
+    Then the requests should be assigned as:
+      | request | warehouse |
+      | 2       | 1         |
+      | 3       | 1         |
+
+  #------------------------------------------
+  # Scenario 4: Priority before size
+  #------------------------------------------
+  Scenario: High priority request is placed first
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And the pending storage requests:
+      | id | volume | start      | end        | priority |
+      | 1  | 8.0    | 2025-01-10 | 2025-01-10 | 0        |
+      | 2  | 3.0    | 2025-01-10 | 2025-01-10 | 1        |
+    When I optimize the assignment for volume
+    Then the requests should be assigned as:
+      | request | warehouse |
+      | 2       | 1         |
+    And the rejected requests should be:
+      | request | reason                                                            |
+      | 1       | required volume cannot be accommodated within the specified dates |
@@ -0,0 +1,108 @@
+Feature: PersistState
+
+  #------------------------------------------
+  # Scenario 1: Round trip
+  #------------------------------------------
+  Scenario: Saved state loads back unchanged
+    Given today is "2025-01-01"
+    And I have 2 warehouse with total volume 10.0
+    And warehouse 1 needs 1 day before and 2 days after each stay
+    And warehouse 2 is closed every Sunday
+    And warehouse 2 is closed from "2025-02-01" to "2025-02-03"
+    And warehouse 2 loses 3.0 volume from "2025-01-10" to "2025-01-20"
+    And customer 7 may store 5.0 in warehouse 1
+    And customer 7 has a block of 2.0 in warehouse 2 from "2025-01-05" to "2025-01-08"
+    And warehouse 1 is booked with volume 4.0 from "2025-01-10" to "2025-01-12"
+    And item 1 belongs to customer 7
+    And item 1 needs 0 days before and 1 day after its stay
+    And item 1 has priority 3
+    And item 1 was checked in on "2025-01-10"
+    When I save the service state and load it into a new service
+    Then the loaded state should match the saved state
+    And warehouse 1 should hold 1 items
+
+  #------------------------------------------
+  # Scenario 2: Loaded state is usable
+  #------------------------------------------
+  Scenario: Reservations see the loaded items
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 8.0 from "2025-01-10" to "2025-01-12"
+    When I save the service state and load it into a new service
+    And I call FindAvailableWarehouse from "2025-01-11" to "2025-01-11" with dimensions:
+      | height | width | length |
+      | 3.0    | 1.0   | 1.0    |
+    Then an error should be returned with message "required volume cannot be accommodated within the specified dates"
+
+  #------------------------------------------
+  # Scenario 3: Explicit document
+  #------------------------------------------
+  Scenario: A hand-written document can be loaded
+    Given today is "2025-01-01"
+    When I load the document:
+      """
+      {
+        "Version": 1,
+        "DateFormat": "2006-01-02T15:04:05Z07:00",
+        "Warehouses": [
+          {
+            "Id": 1,
+            "MaxCapacity": { "Height": 10, "Width": 1, "Length": 1 },
+            "Items": [
+              {
+                "ItemId": 1,
+                "ItemHeight": 6, "ItemWidth": 1, "ItemLength": 1,
+                "StartDate": "2025-01-10T00:00:00Z",
+                "EndDate": "2025-01-12T00:00:00Z",
+                "IsActive": true
+              }
+            ]
+          }
+        ]
+      }
+      """
+    Then warehouse 1 should hold 1 items
+    When I call CalculateAvailableCapacity from "2025-01-09" to "2025-01-10"
+    Then the available capacities should be:
+      | date       | capacity |
+      | 2025-01-09 | 10.0     |
+      | 2025-01-10 | 4.0      |
+
+  #------------------------------------------
+  # Scenario 4: Versioning
+  #------------------------------------------
+  Scenario: Documents of another version are rejected
+    When I load the document:
+      """
+      { "Version": 4, "DateFormat": "2006-01-02T15:04:05Z07:00" }
+      """
+    Then an error should be returned with message "unsupported document version"
+
+  Scenario: Documents of version 1 start every version at 1
+    When I load the document:
+      """
+      {
+        "Version": 1,
+        "DateFormat": "2006-01-02T15:04:05Z07:00",
+        "Warehouses": [ { "Id": 1, "MaxCapacity": { "Height": 10, "Width": 1, "Length": 1 } } ]
+      }
+      """
+    Then warehouse 1 should be at version 1
+
+  Scenario: Documents of version 2 keep the versions they hold
+    When I load the document:
+      """
+      {
+        "Version": 2,
+        "DateFormat": "2006-01-02T15:04:05Z07:00",
+        "Warehouses": [ { "Id": 1, "MaxCapacity": { "Height": 10, "Width": 1, "Length": 1 }, "Version": 4 } ]
+      }
+      """
+    Then warehouse 1 should be at version 4
+
+  Scenario: Unknown attributes are rejected
+    When I load the document:
+      """
+      { "Version": 1, "DateFormat": "2006-01-02T15:04:05Z07:00", "Shelves": [] }
+      """
+    Then an error should be returned with message "the document is not valid"
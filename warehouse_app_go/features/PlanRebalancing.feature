@@ -0,0 +1,75 @@
+Feature: PlanRebalancing
+
+  #------------------------------------------
+  # Scenario 1: Move to another warehouse
+  #------------------------------------------
+  Scenario: Cheapest relocation relieves the full warehouse
+    Given today is "2025-01-01"
+    And I have 2 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 6.0 from "2025-01-10" to "2025-01-12"
+    And warehouse 1 is booked with volume 4.0 from "2025-01-11" to "2025-01-11"
+    When I plan rebalancing from "2025-01-10" to "2025-01-12" allowing shifts of 0 days
+    Then the best move plan should be:
+      | move     | item | warehouse | days |
+      | relocate | 2    | 2         | 0    |
+    When I apply the best move plan
+    Then warehouse 1 should hold 1 item
+    And warehouse 2 should hold 1 item
+
+  #------------------------------------------
+  # Scenario 2: Running stay
+  #------------------------------------------
+  Scenario: Rest of a running stay is transferred
+    Given today is "2025-01-11"
+    And I have 2 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 6.0 from "2025-01-10" to "2025-01-14"
+    And warehouse 1 is booked with volume 4.0 from "2025-01-05" to "2025-01-13"
+    When I plan rebalancing from "2025-01-11" to "2025-01-14" allowing shifts of 0 days
+    Then the best move plan should be:
+      | move     | item | warehouse | days |
+      | transfer | 2    | 2         | 0    |
+
+  #------------------------------------------
+  # Scenario 3: Shift within the warehouse
+  #------------------------------------------
+  Scenario: Shifting a stay by a day removes the fully utilized day
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 6.0 from "2025-01-10" to "2025-01-10"
+    And warehouse 1 is booked with volume 4.0 from "2025-01-10" to "2025-01-10"
+    When I plan rebalancing from "2025-01-09" to "2025-01-11" allowing shifts of 1 day
+    Then the best move plan should be:
+      | move  | item | warehouse | days |
+      | shift | 2    | 1         | 1    |
+    And the best move plan should lower the fully utilized days from 1 to 0
+    When I apply the best move plan
+    Then the service should report the fully utilized dates from "2025-01-09" to "2025-01-11" as:
+      | date |
+
+  #------------------------------------------
+  # Scenario 4: Nothing to improve
+  #------------------------------------------
+  Scenario: No plans when no move helps
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 10.0 from "2025-01-10" to "2025-01-10"
+    When I plan rebalancing from "2025-01-10" to "2025-01-10" allowing shifts of 0 days
+    Then there should be no move plans
+
+  #------------------------------------------
+  # Scenario 5: Days full across all warehouses
+  #------------------------------------------
+  Scenario: Only a shift removes a day on which every warehouse is full
+    Given today is "2025-01-01"
+    And I have 2 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 10.0 from "2025-01-10" to "2025-01-10"
+    And warehouse 2 is booked with volume 6.0 from "2025-01-10" to "2025-01-10"
+    And warehouse 2 is booked with volume 4.0 from "2025-01-10" to "2025-01-10"
+    When I plan rebalancing from "2025-01-09" to "2025-01-11" allowing shifts of 1 day
+    Then the best move plan should be:
+      | move  | item | warehouse | days |
+      | shift | 3    | 2         | 1    |
+    And the best move plan should lower the fully utilized days from 1 to 0
+    When I apply the best move plan
+    Then the service should report the fully utilized dates from "2025-01-09" to "2025-01-11" as:
+      | date |
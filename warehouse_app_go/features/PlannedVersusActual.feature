@@ -0,0 +1,108 @@
+Feature: PlannedVersusActual
+
+  #------------------------------------------
+  # Scenario 1: Late arrival and early departure
+  #------------------------------------------
+  Scenario: Capacity uses the actual stay in the past
+    Given today is "2025-01-20"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 4.0 from "2025-01-10" to "2025-01-15"
+    And item 1 was checked in on "2025-01-12"
+    And item 1 was checked out on "2025-01-14"
+    When I call CalculateAvailableCapacity from "2025-01-10" to "2025-01-15"
+    Then the available capacities should be:
+      | date       | capacity |
+      | 2025-01-10 | 10.0     |
+      | 2025-01-11 | 10.0     |
+      | 2025-01-12 | 6.0      |
+      | 2025-01-13 | 6.0      |
+      | 2025-01-14 | 6.0      |
+      | 2025-01-15 | 10.0     |
+
+  #------------------------------------------
+  # Scenario 2: Stay still in progress
+  #------------------------------------------
+  Scenario: Capacity uses the plan for the future
+    Given today is "2025-01-12"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 4.0 from "2025-01-10" to "2025-01-14"
+    And item 1 was checked in on "2025-01-11"
+    When I call CalculateAvailableCapacity from "2025-01-10" to "2025-01-15"
+    Then the available capacities should be:
+      | date       | capacity |
+      | 2025-01-10 | 10.0     |
+      | 2025-01-11 | 6.0      |
+      | 2025-01-12 | 6.0      |
+      | 2025-01-13 | 6.0      |
+      | 2025-01-14 | 6.0      |
+      | 2025-01-15 | 10.0     |
+
+  #------------------------------------------
+  # Scenario 3: Variance report
+  #------------------------------------------
+  Scenario: Planned versus actual volume-days per warehouse
+    Given today is "2025-01-20"
+    And I have 2 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 4.0 from "2025-01-10" to "2025-01-15"
+    And warehouse 2 is booked with volume 2.0 from "2025-01-10" to "2025-01-11"
+    And item 1 was checked in on "2025-01-12"
+    And item 1 was checked out on "2025-01-14"
+    Then the storage variance from "2025-01-01" to "2025-01-31" should be:
+      | warehouse | planned | actual |
+      | 1         | 24.0    | 12.0   |
+      | 2         | 4.0     | 4.0    |
+
+  #------------------------------------------
+  # Scenario 4: Check-out without check-in
+  #------------------------------------------
+  Scenario: Item cannot leave before it arrived
+    Given today is "2025-01-20"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 4.0 from "2025-01-10" to "2025-01-15"
+    And item 1 was checked out on "2025-01-14"
+    Then an error should be returned with message "the item has not been checked in"
+
+  #------------------------------------------
+  # Scenario 5: Check-in in the future
+  #------------------------------------------
+  Scenario: Actual timestamps cannot be in the future
+    Given today is "2025-01-05"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 4.0 from "2025-01-10" to "2025-01-15"
+    And item 1 was checked in on "2025-01-10"
+    Then an error should be returned with message "actual timestamps cannot be in the future"
+
+  #------------------------------------------
+  # Scenario 6: Item still in after its planned end
+  #------------------------------------------
+  Scenario: An overstaying item occupies space until today
+    Given today is "2025-01-18"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 4.0 from "2025-01-10" to "2025-01-15"
+    And item 1 was checked in on "2025-01-10"
+    When I call CalculateAvailableCapacity from "2025-01-14" to "2025-01-19"
+    Then the available capacities should be:
+      | date       | capacity |
+      | 2025-01-14 | 6.0      |
+      | 2025-01-15 | 6.0      |
+      | 2025-01-16 | 6.0      |
+      | 2025-01-17 | 6.0      |
+      | 2025-01-18 | 6.0      |
+      | 2025-01-19 | 10.0     |
+
+  #------------------------------------------
+  # Scenario 7: Item that never arrived
+  #------------------------------------------
+  Scenario: A stay nobody checked in keeps its planned dates
+    Given today is "2025-01-12"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 4.0 from "2025-01-10" to "2025-01-14"
+    When I call CalculateAvailableCapacity from "2025-01-10" to "2025-01-15"
+    Then the available capacities should be:
+      | date       | capacity |
+      | 2025-01-10 | 6.0      |
+      | 2025-01-11 | 6.0      |
+      | 2025-01-12 | 6.0      |
+      | 2025-01-13 | 6.0      |
+      | 2025-01-14 | 6.0      |
+      | 2025-01-15 | 10.0     |
@@ -0,0 +1,74 @@
+Feature: ReserveBatch
+
+  #------------------------------------------
+  # Scenario 1: Every item fits
+  #------------------------------------------
+  Scenario: Batch is committed when all items fit
+    Given today is "2025-01-01"
+    And I have 2 warehouse with total volume 10.0
+    And a batch of items:
+      | id | volume | start      | end        |
+      | 11 | 6.0    | 2025-01-10 | 2025-01-12 |
+      | 12 | 6.0    | 2025-01-11 | 2025-01-13 |
+      | 13 | 4.0    | 2025-01-10 | 2025-01-10 |
+    When I reserve the batch
+    Then the batch should be placed as:
+      | item | warehouse |
+      | 11   | 1         |
+      | 12   | 2         |
+      | 13   | 1         |
+    And warehouse 1 should hold 2 items
+    And warehouse 2 should hold 1 item
+
+  #------------------------------------------
+  # Scenario 2: Items compete with each other
+  #------------------------------------------
+  Scenario: Nothing is stored when one item does not fit
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And a batch of items:
+      | id | volume | start      | end        |
+      | 11 | 6.0    | 2025-01-10 | 2025-01-12 |
+      | 12 | 6.0    | 2025-01-11 | 2025-01-13 |
+      | 13 | 0.0    | 2025-01-10 | 2025-01-10 |
+    When I reserve the batch
+    Then an error should be returned with message "the batch cannot be reserved"
+    And the batch failures should be:
+      | item | reason                                                            |
+      | 12   | required volume cannot be accommodated within the specified dates |
+      | 13   | the 3D model has invalid dimensions (zero or negative)            |
+    And warehouse 1 should hold 0 items
+
+  #------------------------------------------
+  # Scenario 3: Duplicate item ids
+  #------------------------------------------
+  Scenario: Item id already in use
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 1.0 from "2025-01-10" to "2025-01-10"
+    And a batch of items:
+      | id | volume | start      | end        |
+      | 1  | 1.0    | 2025-01-10 | 2025-01-10 |
+    When I reserve the batch
+    Then the batch failures should be:
+      | item | reason                              |
+      | 1    | an item with this id already exists |
+    And warehouse 1 should hold 1 item
+
+  #------------------------------------------
+  # Scenario 4: Assigned ids skip those in the batch
+  #------------------------------------------
+  Scenario: Item without an id does not take an id asked for later in the batch
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 1.0 from "2025-01-10" to "2025-01-10"
+    And a batch of items:
+      | id | volume | start      | end        |
+      | 0  | 1.0    | 2025-01-10 | 2025-01-10 |
+      | 2  | 1.0    | 2025-01-10 | 2025-01-10 |
+    When I reserve the batch
+    Then the batch should be placed as:
+      | item | warehouse |
+      | 3    | 1         |
+      | 2    | 1         |
+    And warehouse 1 should hold 3 items
@@ -0,0 +1,98 @@
+Feature: ReserveRecurring
+
+  #------------------------------------------
+  # Scenario 1: Every weekend
+  #------------------------------------------
+  Scenario: Weekly reservation on Saturdays
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And a recurring reservation from "2025-01-04" to "2025-01-05" with dimensions:
+      | height | width | length |
+      | 4.0    | 1.0   | 1.0    |
+    And the reservation recurs:
+      | frequency | interval | by day   | by month day | count | until |
+      | weekly    | 1        | Saturday |              | 3     |       |
+    When I reserve the recurring reservation accepting all occurrences
+    Then the reserved occurrences should be:
+      | start      | end        | warehouse |
+      | 2025-01-04 | 2025-01-05 | 1         |
+      | 2025-01-11 | 2025-01-12 | 1         |
+      | 2025-01-18 | 2025-01-19 | 1         |
+    And warehouse 1 should hold 3 items
+
+  #------------------------------------------
+  # Scenario 2: First week of every month
+  #------------------------------------------
+  Scenario: Monthly reservation bounded by an until date
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And a recurring reservation from "2025-01-01" to "2025-01-07" with dimensions:
+      | height | width | length |
+      | 4.0    | 1.0   | 1.0    |
+    And the reservation recurs:
+      | frequency | interval | by day | by month day | count | until      |
+      | monthly   | 1        |        | 1            |       | 2025-03-31 |
+    When I reserve the recurring reservation accepting all occurrences
+    Then the reserved occurrences should be:
+      | start      | end        | warehouse |
+      | 2025-01-01 | 2025-01-07 | 1         |
+      | 2025-02-01 | 2025-02-07 | 1         |
+      | 2025-03-01 | 2025-03-07 | 1         |
+
+  #------------------------------------------
+  # Scenario 3: One occurrence does not fit
+  #------------------------------------------
+  Scenario: All-or-nothing leaves the warehouse untouched
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 8.0 from "2025-01-11" to "2025-01-11"
+    And a recurring reservation from "2025-01-04" to "2025-01-05" with dimensions:
+      | height | width | length |
+      | 4.0    | 1.0   | 1.0    |
+    And the reservation recurs:
+      | frequency | interval | by day   | by month day | count | until |
+      | weekly    | 1        | Saturday |              | 3     |       |
+    When I reserve the recurring reservation accepting all occurrences
+    Then an error should be returned with message "not all occurrences can be accommodated"
+    And the rejected occurrences should be:
+      | start      | end        |
+      | 2025-01-11 | 2025-01-12 |
+    And warehouse 1 should hold 1 item
+
+  #------------------------------------------
+  # Scenario 4: Only the fitting occurrences
+  #------------------------------------------
+  Scenario: Accept fitting occurrences and report the rest
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 8.0 from "2025-01-11" to "2025-01-11"
+    And a recurring reservation from "2025-01-04" to "2025-01-05" with dimensions:
+      | height | width | length |
+      | 4.0    | 1.0   | 1.0    |
+    And the reservation recurs:
+      | frequency | interval | by day   | by month day | count | until |
+      | weekly    | 1        | Saturday |              | 3     |       |
+    When I reserve the recurring reservation accepting fitting occurrences
+    Then the reserved occurrences should be:
+      | start      | end        | warehouse |
+      | 2025-01-04 | 2025-01-05 | 1         |
+      | 2025-01-18 | 2025-01-19 | 1         |
+    And the rejected occurrences should be:
+      | start      | end        |
+      | 2025-01-11 | 2025-01-12 |
+    And warehouse 1 should hold 3 items
+
+  #------------------------------------------
+  # Scenario 5: Unbounded recurrence
+  #------------------------------------------
+  Scenario: Recurrence without count or until
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And a recurring reservation from "2025-01-04" to "2025-01-05" with dimensions:
+      | height | width | length |
+      | 1.0    | 1.0   | 1.0    |
+    And the reservation recurs:
+      | frequency | interval | by day | by month day | count | until |
+      | daily     | 1        |        |              |       |       |
+    When I reserve the recurring reservation accepting all occurrences
+    Then an error should be returned with message "the recurrence must be bounded by a count or an until date"
@@ -0,0 +1,62 @@
+Feature: ReserveWithPreemption
+
+  #------------------------------------------
+  # Scenario 1: Room without preemption
+  #------------------------------------------
+  Scenario: Free space is used without bumping anyone
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 4.0 from "2025-01-10" to "2025-01-10"
+    When I reserve with priority 5 from "2025-01-10" to "2025-01-10" with dimensions:
+      | height | width | length |
+      | 6.0    | 1.0   | 1.0    |
+    Then the reservation should be placed in warehouse 1
+    And the displaced items should be:
+      | item | from | to |
+
+  #------------------------------------------
+  # Scenario 2: Bumped reservation moves
+  #------------------------------------------
+  Scenario: Lower-priority reservation is moved to another warehouse
+    Given today is "2025-01-01"
+    And I have 2 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 6.0 from "2025-01-10" to "2025-01-10"
+    And warehouse 2 is booked with volume 4.0 from "2025-01-10" to "2025-01-10"
+    And item 2 has priority 1
+    When I reserve with priority 5 from "2025-01-10" to "2025-01-10" with dimensions:
+      | height | width | length |
+      | 8.0    | 1.0   | 1.0    |
+    Then the reservation should be placed in warehouse 2
+    And the displaced items should be:
+      | item | from | to |
+      | 2    | 2    | 1  |
+
+  #------------------------------------------
+  # Scenario 3: Bumped reservation is waitlisted
+  #------------------------------------------
+  Scenario: Lower-priority reservation goes to the waitlist
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 6.0 from "2025-01-10" to "2025-01-12"
+    When I reserve with priority 5 from "2025-01-11" to "2025-01-11" with dimensions:
+      | height | width | length |
+      | 8.0    | 1.0   | 1.0    |
+    Then the reservation should be placed in warehouse 1
+    And the displaced items should be:
+      | item | from | to       |
+      | 1    | 1    | waitlist |
+    And the waitlist should hold 1 item
+
+  #------------------------------------------
+  # Scenario 4: Equal or higher priority stays
+  #------------------------------------------
+  Scenario: Reservations with the same priority are not bumped
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 6.0 from "2025-01-10" to "2025-01-12"
+    And item 1 has priority 5
+    When I reserve with priority 5 from "2025-01-11" to "2025-01-11" with dimensions:
+      | height | width | length |
+      | 8.0    | 1.0   | 1.0    |
+    Then an error should be returned with message "required volume cannot be accommodated even by preempting lower-priority reservations"
+    And the waitlist should hold 0 items
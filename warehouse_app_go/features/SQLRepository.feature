@@ -0,0 +1,104 @@
+Feature: SQLRepository
+
+  #------------------------------------------
+  # Scenario 1: Schema migrations
+  #------------------------------------------
+  Scenario: Opening a database brings its schema up to date once
+    Given the warehouses are stored in a database
+    Then the database schema should be at version 10
+    When I reopen the database
+    Then the database schema should be at version 10
+
+  Scenario: Warehouses stored as documents are moved into typed columns
+    Given a database at schema version 5 holds a warehouse with every field set
+    When I reopen the database
+    Then the database schema should be at version 10
+    And the warehouse should be read back unchanged
+
+  #------------------------------------------
+  # Scenario 2: Storing reservations
+  #------------------------------------------
+  Scenario: Reservations in the database survive reopening
+    Given today is "2025-01-01"
+    And the warehouses are stored in a database
+    And I have 2 warehouse with total volume 10.0
+    When I reserve volume 8.0 from "2025-01-10" to "2025-01-12"
+    And I reserve volume 4.0 from "2025-01-11" to "2025-01-11"
+    And I reopen the database
+    Then warehouse 1 should hold 1 items
+    And warehouse 2 should hold 1 items
+    When I call CalculateAvailableCapacity from "2025-01-10" to "2025-01-11"
+    Then the available capacities should be:
+      | date       | capacity |
+      | 2025-01-10 | 12.0     |
+      | 2025-01-11 | 8.0      |
+
+  Scenario: Items moved between warehouses are stored with their new warehouse
+    Given today is "2025-01-01"
+    And the warehouses are stored in a database
+    And I have 2 warehouse with total volume 10.0
+    When I reserve volume 4.0 from "2025-01-10" to "2025-01-12"
+    And I transfer item 1 to warehouse 2 on "2025-01-11"
+    And I reopen the database
+    Then the shipment of item 1 should be:
+      | warehouse | start      | end        |
+      | 1         | 2025-01-10 | 2025-01-10 |
+      | 2         | 2025-01-11 | 2025-01-12 |
+
+  Scenario: A batch that does not fit leaves the database untouched
+    Given today is "2025-01-01"
+    And the warehouses are stored in a database
+    And I have 1 warehouse with total volume 10.0
+    And a batch of items:
+      | id | volume | start      | end        |
+      | 11 | 6.0    | 2025-01-10 | 2025-01-12 |
+      | 12 | 6.0    | 2025-01-11 | 2025-01-13 |
+    When I reserve the batch
+    Then an error should be returned with message "the batch cannot be reserved"
+    When I reopen the database
+    Then warehouse 1 should hold 0 items
+
+  Scenario: Every field of a warehouse and its items is stored
+    Given the warehouses are stored in a database
+    When I save a warehouse with every field set
+    And I reopen the database
+    Then the warehouse should be read back unchanged
+
+  Scenario: The database refuses data that breaks its constraints
+    Given the warehouses are stored in a database
+    And I have 1 warehouse with total volume 10.0
+    When I store an item in warehouse 1 that ends before it starts
+    Then an error should be returned with message "CHECK constraint failed"
+    When I store an item in warehouse 2 that ends before it starts
+    Then an error should be returned with message "warehouse not found"
+
+  Scenario: Customers and the waitlist survive reopening
+    Given today is "2025-01-01"
+    And the warehouses are stored in a database
+    When I add warehouse 1 with total volume 10.0
+    And I register customer 7
+    And I reserve volume 6.0 from "2025-01-10" to "2025-01-10"
+    And I reserve with priority 5 from "2025-01-10" to "2025-01-10" with dimensions:
+      | height | width | length |
+      | 8.0    | 1.0   | 1.0    |
+    And I reopen the database
+    Then the service should know 1 customer
+    And the waitlist should hold 1 item
+    And warehouse 1 should hold 1 items
+
+  #------------------------------------------
+  # Scenario 3: Range queries
+  #------------------------------------------
+  Scenario: Only items whose buffered stay overlaps the range are read
+    Given the warehouses are stored in a database
+    And I have 2 warehouse with total volume 10.0
+    And warehouse 2 needs 0 days before and 2 days after each stay
+    And warehouse 1 is booked with volume 1.0 from "2025-01-01" to "2025-01-05"
+    And warehouse 1 is booked with volume 1.0 from "2025-01-08" to "2025-01-09"
+    And warehouse 2 is booked with volume 1.0 from "2025-01-04" to "2025-01-05"
+    And warehouse 2 is booked with volume 1.0 from "2025-01-20" to "2025-01-21"
+    Then the items overlapping "2025-01-06" to "2025-01-08" should be:
+      | warehouse | item |
+      | 1         | 2    |
+      | 2         | 3    |
+    And range queries should use the index "items_warehouse_period"
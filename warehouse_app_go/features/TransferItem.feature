@@ -0,0 +1,53 @@
+Feature: TransferItem
+
+  #------------------------------------------
+  # Scenario 1: Transfer mid-stay
+  #------------------------------------------
+  Scenario: Item continues its stay in the target warehouse
+    Given today is "2025-01-01"
+    And I have 2 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 4.0 from "2025-01-10" to "2025-01-20"
+    When I transfer item 1 to warehouse 2 on "2025-01-15"
+    Then the shipment of item 1 should be:
+      | warehouse | start      | end        |
+      | 1         | 2025-01-10 | 2025-01-14 |
+      | 2         | 2025-01-15 | 2025-01-20 |
+
+  #------------------------------------------
+  # Scenario 2: Chain of transfers
+  #------------------------------------------
+  Scenario: Shipment is queryable from any leg
+    Given today is "2025-01-01"
+    And I have 2 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 4.0 from "2025-01-10" to "2025-01-20"
+    When I transfer item 1 to warehouse 2 on "2025-01-15"
+    And I transfer item 2 to warehouse 1 on "2025-01-18"
+    Then the shipment of item 2 should be:
+      | warehouse | start      | end        |
+      | 1         | 2025-01-10 | 2025-01-14 |
+      | 2         | 2025-01-15 | 2025-01-17 |
+      | 1         | 2025-01-18 | 2025-01-20 |
+
+  #------------------------------------------
+  # Scenario 3: Target warehouse is full
+  #------------------------------------------
+  Scenario: Target warehouse cannot take the remaining days
+    Given today is "2025-01-01"
+    And I have 2 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 4.0 from "2025-01-10" to "2025-01-20"
+    And warehouse 2 is booked with volume 8.0 from "2025-01-19" to "2025-01-19"
+    When I transfer item 1 to warehouse 2 on "2025-01-15"
+    Then an error should be returned with message "the target warehouse cannot accommodate the item for the remaining days"
+    And the shipment of item 1 should be:
+      | warehouse | start      | end        |
+      | 1         | 2025-01-10 | 2025-01-20 |
+
+  #------------------------------------------
+  # Scenario 4: Transfer date outside the stay
+  #------------------------------------------
+  Scenario: Transfer date after the end of the stay
+    Given today is "2025-01-01"
+    And I have 2 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 4.0 from "2025-01-10" to "2025-01-20"
+    When I transfer item 1 to warehouse 2 on "2025-01-21"
+    Then an error should be returned with message "the transfer date must fall after the start and on or before the end of the stay"
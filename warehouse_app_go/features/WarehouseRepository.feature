@@ -0,0 +1,61 @@
+Feature: WarehouseRepository
+
+  #------------------------------------------
+  # Scenario 1: File-backed storage
+  #------------------------------------------
+  Scenario: Reservations in a file-backed repository survive reopening
+    Given today is "2025-01-01"
+    And the warehouses are stored in a file
+    And I have 2 warehouse with total volume 10.0
+    When I reserve volume 8.0 from "2025-01-10" to "2025-01-12"
+    And I reserve volume 4.0 from "2025-01-11" to "2025-01-11"
+    And I reopen the file
+    Then warehouse 1 should hold 1 items
+    And warehouse 2 should hold 1 items
+    When I call CalculateAvailableCapacity from "2025-01-10" to "2025-01-11"
+    Then the available capacities should be:
+      | date       | capacity |
+      | 2025-01-10 | 12.0     |
+      | 2025-01-11 | 8.0      |
+
+  Scenario: Writes keep the customers and waitlist of a saved document
+    Given today is "2025-01-01"
+    And I have 1 warehouse with total volume 10.0
+    And a customer with id 7
+    And warehouse 1 is booked with volume 6.0 from "2025-01-10" to "2025-01-10"
+    And item 1 has priority 1
+    When I reserve with priority 5 from "2025-01-10" to "2025-01-10" with dimensions:
+      | height | width | length |
+      | 8.0    | 1.0   | 1.0    |
+    And I save the service state to a file
+    And I reopen the file
+    And I reserve volume 1.0 from "2025-01-12" to "2025-01-12"
+    And I load the file into a new service
+    Then the service should know 1 customer
+    And the waitlist should hold 1 item
+    And warehouse 1 should hold 2 items
+
+  #------------------------------------------
+  # Scenario 2: Range queries
+  #------------------------------------------
+  Scenario: Only items whose buffered stay overlaps the range are returned
+    Given I have 2 warehouse with total volume 10.0
+    And warehouse 2 needs 0 days before and 2 days after each stay
+    And warehouse 1 is booked with volume 1.0 from "2025-01-01" to "2025-01-05"
+    And warehouse 1 is booked with volume 1.0 from "2025-01-08" to "2025-01-09"
+    And warehouse 2 is booked with volume 1.0 from "2025-01-04" to "2025-01-05"
+    And warehouse 2 is booked with volume 1.0 from "2025-01-20" to "2025-01-21"
+    Then the items overlapping "2025-01-06" to "2025-01-08" should be:
+      | warehouse | item |
+      | 1         | 2    |
+      | 2         | 3    |
+
+  Scenario: The same queries run against the file-backed repository
+    Given the warehouses are stored in a file
+    And I have 1 warehouse with total volume 10.0
+    And warehouse 1 is booked with volume 1.0 from "2025-01-01" to "2025-01-05"
+    And warehouse 1 is booked with volume 1.0 from "2025-01-08" to "2025-01-09"
+    When I reopen the file
+    Then the items overlapping "2025-01-06" to "2025-01-08" should be:
+      | warehouse | item |
+      | 1         | 2    |
@@ -1,4 +1,241 @@
 package main
 
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"warehouse_app_go/warehouse"
+)
+
 func main() {
+	dataPath := flag.String("data", "warehouse.json", "path of the JSON document holding the service state")
+	warehousesCSV := flag.String("import-warehouses", "", "path of a CSV file of warehouses to import, used with -import-items")
+	itemsCSV := flag.String("import-items", "", "path of a CSV file of items to import, used with -import-warehouses")
+	reportName := flag.String("report", "", "report to write to standard output: available, fully-utilized or usage")
+	reportFrom := flag.String("from", "", "first day of the report, as 2006-01-02")
+	reportTo := flag.String("to", "", "last day of the report, as 2006-01-02")
+	reportFormat := flag.String("format", "csv", "format of the report: csv or json")
+	perWarehouse := flag.Bool("per-warehouse", false, "add a column per warehouse to daily reports")
+	serve := flag.String("serve", "", "serve the HTTP API on this address, such as :8080, until interrupted")
+	check := flag.Bool("check", false, "check the stored data, after any import, and fail when it is inconsistent")
+	databasePath := flag.String("database", "", "path of a SQLite database holding the service state instead of the JSON document")
+	asOf := flag.String("as-of", "", "answer the report with what was known at this time, as 2006-01-02T15:04:05Z07:00; needs -database")
+	audit := flag.Bool("audit", false, "record who made every change and why; API changes then need an X-Actor header")
+	actor := flag.String("actor", os.Getenv("USER"), "who is recorded as making the changes of an import")
+	flag.Parse()
+
+	service, err := openService(*dataPath, *databasePath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// Data that was audited before stays audited without the flag.
+	if *audit && service.Audit == nil {
+		service.Audit = &warehouse.AuditLog{}
+	}
+
+	// Requests the repository cannot answer are refused before anything is
+	// changed.
+	var knownAt time.Time
+	if *asOf != "" {
+		if knownAt, err = time.Parse(warehouse.DocumentDateFormat, *asOf); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -as-of time %q\n", *asOf)
+			os.Exit(1)
+		}
+		if *reportName == "" {
+			fmt.Fprintln(os.Stderr, "-as-of can only be used with -report")
+			os.Exit(1)
+		}
+		if !service.KeepsHistory() {
+			fmt.Fprintln(os.Stderr, "-as-of needs -database: the JSON document keeps no history")
+			os.Exit(1)
+		}
+	}
+
+	if *warehousesCSV != "" || *itemsCSV != "" {
+		if err := importCSV(service.As(*actor, "CSV import"), *warehousesCSV, *itemsCSV); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	if *check {
+		if err := checkData(service); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	if *reportName != "" {
+		if !knownAt.IsZero() {
+			if service, err = service.AsOf(knownAt); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+		options := warehouse.ReportOptions{PerWarehouse: *perWarehouse}
+		if err := writeReport(service, *reportName, *reportFrom, *reportTo, *reportFormat, options); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *serve != "" {
+		if err := serveHTTP(service, *serve); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	warehouses, err := service.Repository.ListWarehouses()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	items := 0
+	for _, w := range warehouses {
+		items += len(w.Items)
+	}
+	fmt.Printf("%d warehouses, %d items, %d customers\n", len(warehouses), items, len(service.Customers))
+}
+
+// openService opens the JSON document at dataPath, or the database at
+// databasePath when it is given. Both keep every change as it is made.
+func openService(dataPath, databasePath string) (*warehouse.WarehouseStorageService, error) {
+	if databasePath == "" {
+		repository, err := warehouse.OpenFileRepository(dataPath)
+		if err != nil {
+			return nil, err
+		}
+		return warehouse.NewWarehouseStorageService(repository)
+	}
+
+	db, err := sql.Open("sqlite", databasePath+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	repository, err := warehouse.OpenSQLRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	return warehouse.NewWarehouseStorageService(repository)
+}
+
+func importCSV(service *warehouse.WarehouseStorageService, warehousesPath, itemsPath string) error {
+	if warehousesPath == "" || itemsPath == "" {
+		return errors.New("both -import-warehouses and -import-items are required")
+	}
+
+	warehousesFile, err := os.Open(warehousesPath)
+	if err != nil {
+		return err
+	}
+	defer warehousesFile.Close()
+
+	itemsFile, err := os.Open(itemsPath)
+	if err != nil {
+		return err
+	}
+	defer itemsFile.Close()
+
+	result, err := service.ImportCSV(warehousesFile, itemsFile)
+	for _, failure := range result.Failures {
+		fmt.Fprintf(os.Stderr, "%s line %d: %v\n", failure.File, failure.Line, failure.Err)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("imported %d warehouses, %d items\n", result.Warehouses, result.Items)
+	return nil
+}
+
+// serveHTTP serves the API until the process is interrupted.
+func serveHTTP(service *warehouse.WarehouseStorageService, addr string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{Addr: addr, Handler: warehouse.NewHTTPHandler(service)}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
+
+func checkData(service *warehouse.WarehouseStorageService) error {
+	violations, err := service.Check()
+	if err != nil {
+		return err
+	}
+
+	for _, violation := range violations {
+		fmt.Println(violation)
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("found %d violations", len(violations))
+	}
+	return nil
+}
+
+func writeReport(
+	service *warehouse.WarehouseStorageService,
+	name, from, to, format string,
+	options warehouse.ReportOptions,
+) error {
+
+	startDate, err := time.Parse(warehouse.ReportDateFormat, from)
+	if err != nil {
+		return fmt.Errorf("invalid -from date %q", from)
+	}
+	endDate, err := time.Parse(warehouse.ReportDateFormat, to)
+	if err != nil {
+		return fmt.Errorf("invalid -to date %q", to)
+	}
+
+	var report warehouse.Report
+	switch name {
+	case "available":
+		report, err = service.AvailableCapacityReport(startDate, endDate, options)
+	case "fully-utilized":
+		report, err = service.FullyUtilizedDatesReport(startDate, endDate, options)
+	case "usage":
+		report, err = service.WarehouseUsageReport(startDate, endDate)
+	default:
+		return fmt.Errorf("unknown report %q", name)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "csv":
+		return report.WriteCSV(os.Stdout)
+	case "json":
+		return report.WriteJSON(os.Stdout)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
 }
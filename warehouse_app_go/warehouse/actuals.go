@@ -0,0 +1,134 @@
+package warehouse
+
+import "time"
+
+type WarehouseVariance struct {
+	WarehouseId       int
+	PlannedVolumeDays float64
+	ActualVolumeDays  float64
+	Variance          float64
+}
+
+// -------------------------------------------------
+// CheckIn / CheckOut
+// -------------------------------------------------
+func (service *WarehouseStorageService) CheckIn(itemId int, at time.Time) error {
+	item, warehouseId, err := service.itemForActuals(itemId, at)
+	if err != nil {
+		return err
+	}
+
+	if !item.CheckedInAt.IsZero() {
+		return ErrItemCheckedIn
+	}
+
+	item.CheckedInAt = at
+	return service.write(ItemCheckedIn, func(repository WarehouseRepository) error {
+		return repository.UpdateItem(warehouseId, item)
+	})
+}
+
+func (service *WarehouseStorageService) CheckOut(itemId int, at time.Time) error {
+	item, warehouseId, err := service.itemForActuals(itemId, at)
+	if err != nil {
+		return err
+	}
+
+	if item.CheckedInAt.IsZero() {
+		return ErrNotCheckedIn
+	}
+
+	if !item.CheckedOutAt.IsZero() {
+		return ErrItemCheckedOut
+	}
+
+	if at.Before(item.CheckedInAt) {
+		return ErrCheckOutEarly
+	}
+
+	item.CheckedOutAt = at
+	return service.write(ItemCheckedOut, func(repository WarehouseRepository) error {
+		return repository.UpdateItem(warehouseId, item)
+	})
+}
+
+// itemForActuals returns the item whose actual times are recorded and the id
+// of the warehouse it is stored in.
+func (service *WarehouseStorageService) itemForActuals(itemId int, at time.Time) (Item, int, error) {
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return Item{}, -1, err
+	}
+
+	warehouseIndex, itemIndex, found := findItem(warehouses, itemId)
+	if !found {
+		return Item{}, -1, ErrItemNotFound
+	}
+
+	if at.After(service.now()) {
+		return Item{}, -1, ErrActualInFuture
+	}
+
+	item := warehouses[warehouseIndex].Items[itemIndex]
+	if !item.IsActive {
+		return Item{}, -1, ErrItemNotActive
+	}
+
+	return item, warehouses[warehouseIndex].Id, nil
+}
+
+// -------------------------------------------------
+// GetStorageVariance
+// -------------------------------------------------
+
+// GetStorageVariance compares the planned and actual volume-days of every
+// warehouse over the date range. Stays that have not been checked in or out
+// yet count with their planned dates on both sides.
+func (service *WarehouseStorageService) GetStorageVariance(
+	startDate, endDate time.Time,
+) ([]WarehouseVariance, error) {
+
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(warehouses) == 0 {
+		return nil, ErrNoWarehouses
+	}
+
+	if startDate.After(endDate) {
+		return nil, ErrInvalidPeriod
+	}
+
+	now := service.now()
+	variances := make([]WarehouseVariance, 0, len(warehouses))
+	for _, warehouse := range warehouses {
+		variance := WarehouseVariance{WarehouseId: warehouse.Id}
+		for _, item := range warehouse.Items {
+			if !item.IsActive {
+				continue
+			}
+			actualStart, actualEnd := item.GetOccupiedPeriod(now)
+			variance.PlannedVolumeDays += item.GetItemVolume() * float64(overlappingDays(item.StartDate, item.EndDate, startDate, endDate))
+			variance.ActualVolumeDays += item.GetItemVolume() * float64(overlappingDays(actualStart, actualEnd, startDate, endDate))
+		}
+		variance.Variance = variance.ActualVolumeDays - variance.PlannedVolumeDays
+		variances = append(variances, variance)
+	}
+
+	return variances, nil
+}
+
+func overlappingDays(start, end, rangeStart, rangeEnd time.Time) int {
+	if start.Before(rangeStart) {
+		start = rangeStart
+	}
+	if end.After(rangeEnd) {
+		end = rangeEnd
+	}
+	if start.After(end) {
+		return 0
+	}
+	return daysBetween(start, end) + 1
+}
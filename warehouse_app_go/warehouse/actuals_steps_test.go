@@ -0,0 +1,54 @@
+package warehouse
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func initPlannedVersusActualSteps(ctx *godog.ScenarioContext) {
+	// GIVEN
+	ctx.Given(`^item (\d+) was checked in on "([^"]*)"$`, itemWasCheckedInOn)
+	ctx.Given(`^item (\d+) was checked out on "([^"]*)"$`, itemWasCheckedOutOn)
+
+	// THEN
+	ctx.Then(`^the storage variance from "([^"]*)" to "([^"]*)" should be:$`, theStorageVarianceShouldBe)
+}
+
+// -------------------
+// GIVEN Steps (Arrange)
+// -------------------
+
+func itemWasCheckedInOn(ctx context.Context, itemId int, dateStr string) {
+	t := godog.T(ctx)
+	tc.actualsErr = tc.service.CheckIn(itemId, parseDate(t, dateStr))
+}
+
+func itemWasCheckedOutOn(ctx context.Context, itemId int, dateStr string) {
+	t := godog.T(ctx)
+	tc.actualsErr = tc.service.CheckOut(itemId, parseDate(t, dateStr))
+}
+
+// -------------------
+// THEN Steps (Assert)
+// -------------------
+
+func theStorageVarianceShouldBe(ctx context.Context, startStr, endStr string, table *godog.Table) {
+	t := godog.T(ctx)
+
+	variances, err := tc.service.GetStorageVariance(parseDate(t, startStr), parseDate(t, endStr))
+	assert.NoError(t, err, "unexpected error")
+	if !assert.Len(t, variances, len(table.Rows)-1, "variance row count mismatch") {
+		return
+	}
+
+	for i, row := range table.Rows[1:] {
+		warehouseId, _ := strconv.Atoi(row.Cells[0].Value)
+
+		assert.Equal(t, warehouseId, variances[i].WarehouseId, "warehouse mismatch at row %d", i)
+		assert.Equal(t, parseFloat(row.Cells[1].Value), variances[i].PlannedVolumeDays, "planned volume-days mismatch at row %d", i)
+		assert.Equal(t, parseFloat(row.Cells[2].Value), variances[i].ActualVolumeDays, "actual volume-days mismatch at row %d", i)
+	}
+}
@@ -0,0 +1,520 @@
+package warehouse
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// APIDateFormat is the format of the dates passed in query parameters.
+// Dates inside request and response bodies are written as in documents.
+const APIDateFormat = "2006-01-02"
+
+// errorStatuses maps the errors of the service to the status codes of the
+// responses that report them. A ConflictError is reported as 409 Conflict
+// and errors not listed here as 500 Internal Server Error.
+var errorStatuses = []struct {
+	err    error
+	status int
+}{
+	{ErrWarehouseNotFound, http.StatusNotFound},
+	{ErrItemNotFound, http.StatusNotFound},
+	{ErrNoWarehouses, http.StatusNotFound},
+	{ErrTargetNotFound, http.StatusNotFound},
+	{ErrBlockNotFound, http.StatusNotFound},
+
+	{ErrWarehouseExists, http.StatusConflict},
+	{ErrItemExists, http.StatusConflict},
+	{ErrItemNotActive, http.StatusConflict},
+	{ErrItemCheckedIn, http.StatusConflict},
+	{ErrItemCheckedOut, http.StatusConflict},
+	{ErrWarehouseInUse, http.StatusConflict},
+	{ErrNoCapacity, http.StatusConflict},
+	{ErrQuotaExceeded, http.StatusConflict},
+	{ErrNoOpenWarehouse, http.StatusConflict},
+	{ErrInTarget, http.StatusConflict},
+	{ErrTargetFull, http.StatusConflict},
+	{ErrStayStarted, http.StatusConflict},
+	{ErrBlockNoCapacity, http.StatusConflict},
+	{ErrNotCheckedIn, http.StatusConflict},
+	{ErrOccurrenceFull, http.StatusConflict},
+
+	{ErrInvalidDimensions, http.StatusBadRequest},
+	{ErrInvalidStay, http.StatusBadRequest},
+	{ErrInvalidPeriod, http.StatusBadRequest},
+	{ErrStartInPast, http.StatusBadRequest},
+	{ErrTransferDate, http.StatusBadRequest},
+	{ErrTransferInPast, http.StatusBadRequest},
+	{ErrBlockVolume, http.StatusBadRequest},
+	{ErrCheckOutEarly, http.StatusBadRequest},
+	{ErrActualInFuture, http.StatusBadRequest},
+	{ErrEndNotLater, http.StatusBadRequest},
+	{ErrUnboundedRecurrence, http.StatusBadRequest},
+	{ErrNegativeInterval, http.StatusBadRequest},
+	{ErrUnknownFrequency, http.StatusBadRequest},
+	{ErrInvalidMonthDay, http.StatusBadRequest},
+	{ErrNoOccurrences, http.StatusBadRequest},
+	{ErrCustomerNotFound, http.StatusBadRequest},
+	{ErrNoActor, http.StatusBadRequest},
+}
+
+// APIError is the body of every response that reports an error. Conflicts
+// also carry the stored warehouse and what differs from the request.
+type APIError struct {
+	Error   string
+	Current *Warehouse `json:",omitempty"`
+	Changes []string   `json:",omitempty"`
+}
+
+// APINewWarehouse is the body of POST /warehouses. A new warehouse starts
+// without items, which are reserved through POST /items.
+type APINewWarehouse struct {
+	Id               int
+	MaxCapacity      ThreeDRoom
+	Buffer           Buffer
+	Calendar         OperatingCalendar
+	CapacitySchedule []CapacityChange
+	Blocks           []CapacityBlock
+}
+
+// APIReservation is the body of POST /items. What happens to the item
+// after it is reserved, such as its check-in, is recorded by the service.
+type APIReservation struct {
+	ItemId     int
+	ItemName   string
+	ItemHeight float64
+	ItemWidth  float64
+	ItemLength float64
+	StartDate  time.Time
+	EndDate    time.Time
+	Buffer     *Buffer
+	Priority   int
+	CustomerId int
+}
+
+// APIItem is an item together with the warehouse that holds it.
+type APIItem struct {
+	Item
+	WarehouseId int
+}
+
+// APICapacity is the capacity left on one day.
+type APICapacity struct {
+	Date      string
+	Available float64
+}
+
+// APIWarehouseId answers the searches for a warehouse.
+type APIWarehouseId struct {
+	WarehouseId int
+}
+
+// -------------------------------------------------
+// NewHTTPHandler
+// -------------------------------------------------
+
+// NewHTTPHandler serves the service as a JSON API:
+//
+//	GET    /warehouses                  list the warehouses
+//	POST   /warehouses                  add a warehouse
+//	GET    /warehouses/{id}             get a warehouse
+//	PUT    /warehouses/{id}             update a warehouse, items are kept
+//	DELETE /warehouses/{id}             remove a warehouse without active items
+//	GET    /warehouses/available        FindAvailableWarehouse for start, end, height, width and length
+//	GET    /warehouses/least-used       GetLeastUsedWarehouse from start to end
+//	POST   /items                       reserve space for an item
+//	GET    /items/{id}                  get an item
+//	PUT    /items/{id}                  update the name, dimensions and dates of an item
+//	DELETE /items/{id}                  cancel the reservation of an item
+//	GET    /capacity                    CalculateAvailableCapacity from start to end
+//	GET    /fully-utilized-dates        GetFullyUtilizedDates from start to end
+//
+// Updates must carry the version they are based on. Changes are made on
+// behalf of the actor named in the X-Actor header for the reason in the
+// X-Reason header, which services keeping an audit log require.
+func NewHTTPHandler(service *WarehouseStorageService) http.Handler {
+	api := &httpAPI{service: service}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /warehouses", api.listWarehouses)
+	mux.HandleFunc("POST /warehouses", api.addWarehouse)
+	mux.HandleFunc("GET /warehouses/{id}", api.getWarehouse)
+	mux.HandleFunc("PUT /warehouses/{id}", api.updateWarehouse)
+	mux.HandleFunc("DELETE /warehouses/{id}", api.removeWarehouse)
+	mux.HandleFunc("GET /warehouses/available", api.findAvailableWarehouse)
+	mux.HandleFunc("GET /warehouses/least-used", api.getLeastUsedWarehouse)
+	mux.HandleFunc("POST /items", api.reserveItem)
+	mux.HandleFunc("GET /items/{id}", api.getItem)
+	mux.HandleFunc("PUT /items/{id}", api.updateItem)
+	mux.HandleFunc("DELETE /items/{id}", api.cancelItem)
+	mux.HandleFunc("GET /capacity", api.calculateAvailableCapacity)
+	mux.HandleFunc("GET /fully-utilized-dates", api.getFullyUtilizedDates)
+	return mux
+}
+
+type httpAPI struct {
+	service *WarehouseStorageService
+}
+
+// acting returns a view of the service acting for the caller of the request.
+func (api *httpAPI) acting(r *http.Request) *WarehouseStorageService {
+	return api.service.As(r.Header.Get("X-Actor"), r.Header.Get("X-Reason"))
+}
+
+// -------------------------------------------------
+// Warehouses
+// -------------------------------------------------
+
+func (api *httpAPI) listWarehouses(w http.ResponseWriter, r *http.Request) {
+	warehouses, err := api.service.repository().ListWarehouses()
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	if warehouses == nil {
+		warehouses = []Warehouse{}
+	}
+	writeJSON(w, http.StatusOK, warehouses)
+}
+
+func (api *httpAPI) addWarehouse(w http.ResponseWriter, r *http.Request) {
+	var request APINewWarehouse
+	if !readJSON(w, r, &request) {
+		return
+	}
+
+	id, err := api.acting(r).AddWarehouse(Warehouse{
+		Id:               request.Id,
+		MaxCapacity:      request.MaxCapacity,
+		Buffer:           request.Buffer,
+		Calendar:         request.Calendar,
+		CapacitySchedule: request.CapacitySchedule,
+		Blocks:           request.Blocks,
+	})
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	added, err := api.service.GetWarehouse(id)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/warehouses/%d", id))
+	writeJSON(w, http.StatusCreated, added)
+}
+
+func (api *httpAPI) getWarehouse(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	warehouse, err := api.service.GetWarehouse(id)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, warehouse)
+}
+
+func (api *httpAPI) updateWarehouse(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	var warehouse Warehouse
+	if !readJSON(w, r, &warehouse) || !matchId(w, id, &warehouse.Id) {
+		return
+	}
+
+	// Items are changed through their own resources.
+	current, err := api.service.GetWarehouse(id)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	warehouse.Items = current.Items
+
+	updated, err := api.acting(r).UpdateWarehouse(warehouse)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func (api *httpAPI) removeWarehouse(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	if err := api.acting(r).RemoveWarehouse(id); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (api *httpAPI) findAvailableWarehouse(w http.ResponseWriter, r *http.Request) {
+	query := apiQuery{r: r}
+	startDate, endDate := query.date("start"), query.date("end")
+	height, width, length := query.float("height"), query.float("width"), query.float("length")
+	if !query.valid(w) {
+		return
+	}
+
+	id, err := api.service.FindAvailableWarehouse(startDate, endDate, height, width, length)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, APIWarehouseId{WarehouseId: id})
+}
+
+// getLeastUsedWarehouse answers -1 when no warehouse is used in the range,
+// as GetLeastUsedWarehouse does.
+func (api *httpAPI) getLeastUsedWarehouse(w http.ResponseWriter, r *http.Request) {
+	query := apiQuery{r: r}
+	startDate, endDate := query.date("start"), query.date("end")
+	if !query.valid(w) {
+		return
+	}
+
+	id, err := api.service.GetLeastUsedWarehouse(startDate, endDate)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, APIWarehouseId{WarehouseId: id})
+}
+
+// -------------------------------------------------
+// Items
+// -------------------------------------------------
+
+func (api *httpAPI) reserveItem(w http.ResponseWriter, r *http.Request) {
+	var request APIReservation
+	if !readJSON(w, r, &request) {
+		return
+	}
+
+	warehouseId, reserved, err := api.acting(r).reserve(Item{
+		ItemId:     request.ItemId,
+		ItemName:   request.ItemName,
+		ItemHeight: request.ItemHeight,
+		ItemWidth:  request.ItemWidth,
+		ItemLength: request.ItemLength,
+		StartDate:  request.StartDate,
+		EndDate:    request.EndDate,
+		Buffer:     request.Buffer,
+		Priority:   request.Priority,
+		CustomerId: request.CustomerId,
+	})
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/items/%d", reserved.ItemId))
+	writeJSON(w, http.StatusCreated, APIItem{Item: reserved, WarehouseId: warehouseId})
+}
+
+func (api *httpAPI) getItem(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	item, warehouseId, err := api.service.GetItem(id)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, APIItem{Item: item, WarehouseId: warehouseId})
+}
+
+func (api *httpAPI) updateItem(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	var item Item
+	if !readJSON(w, r, &item) || !matchId(w, id, &item.ItemId) {
+		return
+	}
+
+	updated, err := api.acting(r).UpdateItem(item)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	_, warehouseId, err := api.service.GetItem(id)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, APIItem{Item: updated, WarehouseId: warehouseId})
+}
+
+func (api *httpAPI) cancelItem(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	if err := api.acting(r).CancelReservation(id); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// -------------------------------------------------
+// Capacity
+// -------------------------------------------------
+
+func (api *httpAPI) calculateAvailableCapacity(w http.ResponseWriter, r *http.Request) {
+	query := apiQuery{r: r}
+	startDate, endDate := query.date("start"), query.date("end")
+	if !query.valid(w) {
+		return
+	}
+
+	capacities, err := api.service.CalculateAvailableCapacity(startDate, endDate)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	days := []APICapacity{}
+	for _, day := range sortedDays(capacities) {
+		days = append(days, APICapacity{Date: day.Format(APIDateFormat), Available: capacities[day]})
+	}
+	writeJSON(w, http.StatusOK, days)
+}
+
+func (api *httpAPI) getFullyUtilizedDates(w http.ResponseWriter, r *http.Request) {
+	query := apiQuery{r: r}
+	startDate, endDate := query.date("start"), query.date("end")
+	if !query.valid(w) {
+		return
+	}
+
+	dates, err := api.service.GetFullyUtilizedDates(startDate, endDate)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	days := []string{}
+	for _, date := range dates {
+		days = append(days, date.Format(APIDateFormat))
+	}
+	writeJSON(w, http.StatusOK, days)
+}
+
+// -------------------------------------------------
+// Requests and responses
+// -------------------------------------------------
+
+// apiQuery reads query parameters and keeps the first one that is invalid.
+type apiQuery struct {
+	r   *http.Request
+	err error
+}
+
+func (q *apiQuery) date(name string) time.Time {
+	value := q.r.URL.Query().Get(name)
+	date, err := time.Parse(APIDateFormat, value)
+	if err != nil && q.err == nil {
+		q.err = fmt.Errorf("the %s date %q is not a valid date", name, value)
+	}
+	return date
+}
+
+func (q *apiQuery) float(name string) float64 {
+	value := q.r.URL.Query().Get(name)
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil && q.err == nil {
+		q.err = fmt.Errorf("the %s %q is not a number", name, value)
+	}
+	return number
+}
+
+func (q *apiQuery) valid(w http.ResponseWriter) bool {
+	if q.err != nil {
+		writeJSON(w, http.StatusBadRequest, APIError{Error: q.err.Error()})
+		return false
+	}
+	return true
+}
+
+func pathId(w http.ResponseWriter, r *http.Request) (int, bool) {
+	value := r.PathValue("id")
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, APIError{Error: fmt.Sprintf("the id %q is not a whole number", value)})
+		return 0, false
+	}
+	return id, true
+}
+
+// matchId takes over the id of the path unless the body names another one.
+func matchId(w http.ResponseWriter, pathId int, bodyId *int) bool {
+	if *bodyId != 0 && *bodyId != pathId {
+		writeJSON(w, http.StatusBadRequest, APIError{Error: "the id of the body does not match the path"})
+		return false
+	}
+	*bodyId = pathId
+	return true
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, value any) bool {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(value); err != nil {
+		writeJSON(w, http.StatusBadRequest, APIError{Error: "the request body is not valid: " + err.Error()})
+		return false
+	}
+	return true
+}
+
+func writeAPIError(w http.ResponseWriter, err error) {
+	var conflict *ConflictError
+	if errors.As(err, &conflict) {
+		writeJSON(w, http.StatusConflict, APIError{
+			Error:   err.Error(),
+			Current: &conflict.Current,
+			Changes: conflict.Changes,
+		})
+		return
+	}
+
+	for _, known := range errorStatuses {
+		if errors.Is(err, known.err) {
+			writeJSON(w, known.status, APIError{Error: err.Error()})
+			return
+		}
+	}
+
+	// Other errors come from the storage and are not the caller's to see.
+	log.Printf("warehouse API: %v", err)
+	writeJSON(w, http.StatusInternalServerError, APIError{Error: "internal server error"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
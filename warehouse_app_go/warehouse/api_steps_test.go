@@ -0,0 +1,122 @@
+package warehouse
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initAPISteps(ctx *godog.ScenarioContext) {
+	// WHEN
+	ctx.When(`^I send a (GET|DELETE) request to "([^"]*)"$`, iSendARequestTo)
+	ctx.When(`^I send a (POST|PUT) request to "([^"]*)" with:$`, iSendARequestToWith)
+	ctx.When(`^I send a (POST|PUT) request to "([^"]*)" as "([^"]*)" because "([^"]*)" with:$`, iSendARequestToAsBecauseWith)
+	ctx.When(`^the API reports the error$`, theAPIReportsTheError)
+
+	// THEN
+	ctx.Then(`^the response status should be (\d+)$`, theResponseStatusShouldBe)
+	ctx.Then(`^the response header "([^"]*)" should be "([^"]*)"$`, theResponseHeaderShouldBe)
+	ctx.Then(`^the response body should be:$`, theResponseBodyShouldBe)
+	ctx.Then(`^the response body should include:$`, theResponseBodyShouldInclude)
+}
+
+// -------------------
+// WHEN Steps (Act)
+// -------------------
+
+func iSendARequestTo(_ context.Context, method, target string) {
+	sendAPIRequest(httptest.NewRequest(method, target, nil))
+}
+
+func iSendARequestToWith(_ context.Context, method, target string, body *godog.DocString) {
+	sendAPIRequest(httptest.NewRequest(method, target, strings.NewReader(body.Content)))
+}
+
+func iSendARequestToAsBecauseWith(_ context.Context, method, target, actor, reason string, body *godog.DocString) {
+	request := httptest.NewRequest(method, target, strings.NewReader(body.Content))
+	request.Header.Set("X-Actor", actor)
+	request.Header.Set("X-Reason", reason)
+	sendAPIRequest(request)
+}
+
+func sendAPIRequest(request *http.Request) {
+	tc.apiResponse = httptest.NewRecorder()
+	NewHTTPHandler(&tc.service).ServeHTTP(tc.apiResponse, request)
+}
+
+// theAPIReportsTheError answers with the error an earlier step of the
+// scenario returned, as a handler would.
+func theAPIReportsTheError(ctx context.Context) {
+	t := godog.T(ctx)
+
+	for _, err := range scenarioErrors() {
+		if err != nil {
+			tc.apiResponse = httptest.NewRecorder()
+			writeAPIError(tc.apiResponse, err)
+			return
+		}
+	}
+	require.Fail(t, "no error was returned")
+}
+
+// -------------------
+// THEN Steps (Assert)
+// -------------------
+
+func theResponseStatusShouldBe(ctx context.Context, status int) {
+	t := godog.T(ctx)
+	assert.Equal(t, status, tc.apiResponse.Code, "status mismatch, body %s", tc.apiResponse.Body.String())
+}
+
+func theResponseHeaderShouldBe(ctx context.Context, name, value string) {
+	assert.Equal(godog.T(ctx), value, tc.apiResponse.Header().Get(name), "header %s mismatch", name)
+}
+
+func theResponseBodyShouldBe(ctx context.Context, body *godog.DocString) {
+	assert.JSONEq(godog.T(ctx), body.Content, tc.apiResponse.Body.String(), "body mismatch")
+}
+
+// theResponseBodyShouldInclude compares only the fields named in the
+// expected body, at any depth.
+func theResponseBodyShouldInclude(ctx context.Context, body *godog.DocString) {
+	t := godog.T(ctx)
+
+	var expected, actual any
+	require.NoError(t, json.Unmarshal([]byte(body.Content), &expected), "invalid expected body")
+	require.NoError(t, json.Unmarshal(tc.apiResponse.Body.Bytes(), &actual), "invalid response body")
+	assert.Equal(t, expected, selectJSONFields(expected, actual), "body mismatch")
+}
+
+func selectJSONFields(expected, actual any) any {
+	switch expectedValue := expected.(type) {
+	case map[string]any:
+		actualObject, ok := actual.(map[string]any)
+		if !ok {
+			return actual
+		}
+		selected := make(map[string]any)
+		for key, value := range expectedValue {
+			if actualValue, found := actualObject[key]; found {
+				selected[key] = selectJSONFields(value, actualValue)
+			}
+		}
+		return selected
+	case []any:
+		actualArray, ok := actual.([]any)
+		if !ok || len(actualArray) != len(expectedValue) {
+			return actual
+		}
+		selected := make([]any, len(actualArray))
+		for i := range actualArray {
+			selected[i] = selectJSONFields(expectedValue[i], actualArray[i])
+		}
+		return selected
+	}
+	return actual
+}
@@ -0,0 +1,104 @@
+package warehouse
+
+import (
+	"errors"
+	"time"
+)
+
+// HistoryRepository is implemented by repositories that keep when every
+// change was recorded, such as the event log and the SQL repository.
+type HistoryRepository interface {
+	// ListWarehousesAsOf returns the warehouses as they were stored at
+	// knownAt, with every change recorded later left out.
+	ListWarehousesAsOf(knownAt time.Time) ([]Warehouse, error)
+
+	// LoadStateAsOf returns the customers and the waitlist as they were
+	// stored at knownAt.
+	LoadStateAsOf(knownAt time.Time) (ServiceState, error)
+}
+
+// AsOf returns a read-only copy of the service that sees the warehouses,
+// customers and waitlist as the service knew them at knownAt and takes
+// knownAt as the current time. Changes made through the copy fail with
+// ErrReadOnly. Only repositories that keep a history can answer;
+// KeepsHistory tells them apart up front.
+func (service *WarehouseStorageService) AsOf(knownAt time.Time) (*WarehouseStorageService, error) {
+	history, ok := service.repository().(HistoryRepository)
+	if !ok {
+		return nil, errors.New("the repository does not keep a history")
+	}
+
+	warehouses, err := history.ListWarehousesAsOf(knownAt)
+	if err != nil {
+		return nil, err
+	}
+	state, err := history.LoadStateAsOf(knownAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WarehouseStorageService{
+		Repository: readOnlyRepository{NewInMemoryRepository(warehouses)},
+		Customers:  state.Customers,
+		Waitlist:   state.Waitlist,
+		Now:        func() time.Time { return knownAt },
+	}, nil
+}
+
+// readOnlyRepository refuses every change to the repository it wraps.
+type readOnlyRepository struct {
+	WarehouseRepository
+}
+
+func (readOnlyRepository) Record(EventType, func(repository WarehouseRepository) error) error {
+	return ErrReadOnly
+}
+
+func (readOnlyRepository) SaveWarehouse(Warehouse) error { return ErrReadOnly }
+func (readOnlyRepository) RemoveWarehouse(int) error     { return ErrReadOnly }
+func (readOnlyRepository) AddItem(int, Item) error       { return ErrReadOnly }
+func (readOnlyRepository) UpdateItem(int, Item) error    { return ErrReadOnly }
+func (readOnlyRepository) RemoveItem(int, int) error     { return ErrReadOnly }
+
+// KeepsHistory reports whether the repository of the service keeps the
+// history AsOf needs.
+func (service *WarehouseStorageService) KeepsHistory() bool {
+	_, ok := service.repository().(HistoryRepository)
+	return ok
+}
+
+// -------------------------------------------------
+// CalculateAvailableCapacityAsOf
+// -------------------------------------------------
+
+// CalculateAvailableCapacityAsOf answers CalculateAvailableCapacity with what
+// the service knew at knownAt.
+func (service *WarehouseStorageService) CalculateAvailableCapacityAsOf(
+	knownAt, startDate, endDate time.Time,
+) (map[time.Time]float64, error) {
+
+	past, err := service.AsOf(knownAt)
+	if err != nil {
+		return nil, err
+	}
+	return past.CalculateAvailableCapacity(startDate, endDate)
+}
+
+// -------------------------------------------------
+// FindAvailableWarehouseAsOf
+// -------------------------------------------------
+
+// FindAvailableWarehouseAsOf answers FindAvailableWarehouse with what the
+// service knew at knownAt. Start dates are checked against knownAt rather
+// than the current time.
+func (service *WarehouseStorageService) FindAvailableWarehouseAsOf(
+	knownAt, startDate, endDate time.Time,
+	requiredHeight, requiredWidth, requiredLength float64,
+) (int, error) {
+
+	past, err := service.AsOf(knownAt)
+	if err != nil {
+		return -1, err
+	}
+	return past.FindAvailableWarehouse(startDate, endDate, requiredHeight, requiredWidth, requiredLength)
+}
@@ -0,0 +1,78 @@
+package warehouse
+
+import (
+	"context"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initAsOfSteps(ctx *godog.ScenarioContext) {
+	// WHEN
+	ctx.When(`^I call CalculateAvailableCapacity from "([^"]*)" to "([^"]*)" as known on "([^"]*)"$`,
+		iCallCalculateAvailableCapacityAsKnownOn)
+	ctx.When(`^I call FindAvailableWarehouse from "([^"]*)" to "([^"]*)" as known on "([^"]*)" with dimensions:$`,
+		iCallFindAvailableWarehouseAsKnownOn)
+	ctx.When(`^I reserve volume (\d+\.?\d*) from "([^"]*)" to "([^"]*)" as known on "([^"]*)"$`,
+		iReserveVolumeAsKnownOn)
+
+	// THEN
+	ctx.Then(`^the service should have known (\d+) customers? and (\d+) waitlisted items? on "([^"]*)"$`,
+		theServiceShouldHaveKnownCustomersAndWaitlistedItemsOn)
+}
+
+// -------------------
+// WHEN Steps (Act)
+// -------------------
+
+func iCallCalculateAvailableCapacityAsKnownOn(ctx context.Context, startStr, endStr, knownStr string) {
+	t := godog.T(ctx)
+
+	tc.capacityMap, tc.calculateCapacityErr = tc.service.CalculateAvailableCapacityAsOf(
+		parseDate(t, knownStr),
+		parseDate(t, startStr),
+		parseDate(t, endStr),
+	)
+}
+
+func iCallFindAvailableWarehouseAsKnownOn(ctx context.Context, startStr, endStr, knownStr string, table *godog.Table) {
+	t := godog.T(ctx)
+
+	dims := parseDimensionsTable(table)
+	tc.searchResult, tc.searchError = tc.service.FindAvailableWarehouseAsOf(
+		parseDate(t, knownStr),
+		parseDate(t, startStr),
+		parseDate(t, endStr),
+		dims.Height,
+		dims.Width,
+		dims.Length,
+	)
+}
+
+func iReserveVolumeAsKnownOn(ctx context.Context, volume float64, startStr, endStr, knownStr string) {
+	t := godog.T(ctx)
+
+	past, err := tc.service.AsOf(parseDate(t, knownStr))
+	require.NoError(t, err, "unexpected error")
+	tc.searchResult, tc.searchError = past.Reserve(Item{
+		ItemHeight: volume,
+		ItemWidth:  1,
+		ItemLength: 1,
+		StartDate:  parseDate(t, startStr),
+		EndDate:    parseDate(t, endStr),
+	})
+}
+
+// -------------------
+// THEN Steps (Assert)
+// -------------------
+
+func theServiceShouldHaveKnownCustomersAndWaitlistedItemsOn(ctx context.Context, customers, waitlisted int, knownStr string) {
+	t := godog.T(ctx)
+
+	past, err := tc.service.AsOf(parseDate(t, knownStr))
+	require.NoError(t, err, "unexpected error")
+	assert.Len(t, past.Customers, customers, "customer count mismatch")
+	assert.Len(t, past.Waitlist, waitlisted, "waitlist size mismatch")
+}
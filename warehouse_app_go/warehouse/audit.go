@@ -0,0 +1,349 @@
+package warehouse
+
+import (
+	"sync"
+	"time"
+)
+
+// AuditEntry records how one warehouse or item was changed, by whom and why.
+// Entries about the warehouse itself have no ItemId and hold the warehouse
+// without its items, whose changes have entries of their own. Before is nil
+// for what was created and After for what was removed; Changes names the
+// fields that differ otherwise.
+type AuditEntry struct {
+	Sequence    int
+	RecordedAt  time.Time
+	Actor       string
+	Reason      string
+	Operation   EventType
+	WarehouseId int
+	ItemId      int
+	Changes     []string
+
+	WarehouseBefore *Warehouse
+	WarehouseAfter  *Warehouse
+	ItemBefore      *Item
+	ItemAfter       *Item
+}
+
+// AuditQuery selects audit entries. Zero fields match every entry; From and
+// To bound the time the entries were recorded, both inclusive.
+type AuditQuery struct {
+	WarehouseId int
+	ItemId      int
+	Actor       string
+	From        time.Time
+	To          time.Time
+}
+
+// AuditLog keeps the audit entries of a service in the order they were
+// recorded. Entries cannot be changed once added; queries hand out copies.
+// The zero value is an empty log, safe for concurrent use.
+type AuditLog struct {
+	mu      sync.RWMutex
+	entries []AuditEntry
+}
+
+// AuditRepository is implemented by repositories that store the audit
+// entries together with the warehouses, so that the entries of an operation
+// are written in the same operation as its changes.
+type AuditRepository interface {
+	LoadAudit() ([]AuditEntry, error)
+	AppendAudit(entries []AuditEntry) error
+}
+
+// loadAudit returns the audit entries the repository keeps, or none when it
+// does not keep any.
+func loadAudit(repository WarehouseRepository) ([]AuditEntry, error) {
+	if audits, ok := repository.(AuditRepository); ok {
+		return audits.LoadAudit()
+	}
+	return nil, nil
+}
+
+// appendAudit stores the entries in the repository when it keeps an audit.
+func appendAudit(repository WarehouseRepository, entries []AuditEntry) error {
+	if audits, ok := repository.(AuditRepository); ok {
+		return audits.AppendAudit(entries)
+	}
+	return nil
+}
+
+// -------------------------------------------------
+// As
+// -------------------------------------------------
+
+// As returns a view of the service that makes its changes on behalf of actor
+// for the given reason. The view works on the repository, the customers, the
+// waitlist and the audit log of the service, so changes made through either
+// are seen by both.
+func (service *WarehouseStorageService) As(actor, reason string) *WarehouseStorageService {
+	shared := service.shared()
+	shared.repository()
+	return &WarehouseStorageService{parent: shared, actor: actor, reason: reason}
+}
+
+// writeAudited runs write and adds an entry to the audit log for every
+// warehouse and item the operation changed. The entries are stored in the
+// same operation as the changes. The log stays locked from numbering the
+// entries until they are added, so that concurrent writes never number two
+// entries alike.
+func (service *WarehouseStorageService) writeAudited(
+	eventType EventType,
+	apply func(repository WarehouseRepository) error,
+) error {
+
+	if service.actor == "" {
+		return ErrNoActor
+	}
+
+	log := service.shared().Audit
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	var entries []AuditEntry
+	err := service.store(eventType, func(repository WarehouseRepository) error {
+		auditor := &auditingRepository{WarehouseRepository: repository}
+		err := apply(auditor)
+		entries = log.stamp(auditor.entries, service.now(), service.actor, service.reason, eventType)
+		if len(entries) > 0 {
+			if auditErr := appendAudit(repository, entries); err == nil {
+				err = auditErr
+			}
+		}
+		return err
+	})
+
+	// Without a recorder the writes that succeeded before the failure stay
+	// in place and are audited like any other.
+	if _, records := service.repository().(ChangeRecorder); err != nil && records {
+		return err
+	}
+	log.entries = append(log.entries, entries...)
+	return err
+}
+
+// -------------------------------------------------
+// Query
+// -------------------------------------------------
+
+// Query returns the entries matching the query, oldest first.
+func (l *AuditLog) Query(query AuditQuery) []AuditEntry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var entries []AuditEntry
+	for _, entry := range l.entries {
+		if query.matches(entry) {
+			entries = append(entries, cloneAuditEntry(entry))
+		}
+	}
+	return entries
+}
+
+func (q AuditQuery) matches(entry AuditEntry) bool {
+	switch {
+	case q.WarehouseId != 0 && entry.WarehouseId != q.WarehouseId:
+		return false
+	case q.ItemId != 0 && entry.ItemId != q.ItemId:
+		return false
+	case q.Actor != "" && entry.Actor != q.Actor:
+		return false
+	case !q.From.IsZero() && entry.RecordedAt.Before(q.From):
+		return false
+	case !q.To.IsZero() && entry.RecordedAt.After(q.To):
+		return false
+	}
+	return true
+}
+
+// stamp returns the entries numbered after the ones in the log and marked
+// with when, by whom, why and by which operation they were recorded. The
+// caller holds the lock of the log.
+func (l *AuditLog) stamp(entries []AuditEntry, recordedAt time.Time, actor, reason string, operation EventType) []AuditEntry {
+	stamped := make([]AuditEntry, len(entries))
+	for i, entry := range entries {
+		entry.Sequence = len(l.entries) + i + 1
+		entry.RecordedAt = recordedAt
+		entry.Actor = actor
+		entry.Reason = reason
+		entry.Operation = operation
+		stamped[i] = entry
+	}
+	return stamped
+}
+
+func cloneAuditEntries(entries []AuditEntry) []AuditEntry {
+	if entries == nil {
+		return nil
+	}
+	clones := make([]AuditEntry, len(entries))
+	for i, entry := range entries {
+		clones[i] = cloneAuditEntry(entry)
+	}
+	return clones
+}
+
+func cloneAuditEntry(entry AuditEntry) AuditEntry {
+	entry.Changes = cloneSlice(entry.Changes)
+	if entry.WarehouseBefore != nil {
+		before := cloneWarehouse(*entry.WarehouseBefore)
+		entry.WarehouseBefore = &before
+	}
+	if entry.WarehouseAfter != nil {
+		after := cloneWarehouse(*entry.WarehouseAfter)
+		entry.WarehouseAfter = &after
+	}
+	if entry.ItemBefore != nil {
+		before := cloneItem(*entry.ItemBefore)
+		entry.ItemBefore = &before
+	}
+	if entry.ItemAfter != nil {
+		after := cloneItem(*entry.ItemAfter)
+		entry.ItemAfter = &after
+	}
+	return entry
+}
+
+// -------------------------------------------------
+// auditingRepository
+// -------------------------------------------------
+
+// auditingRepository passes every call on to the target repository and
+// compares the warehouse written to before and after each write that
+// succeeded.
+type auditingRepository struct {
+	WarehouseRepository
+	entries []AuditEntry
+}
+
+func (r *auditingRepository) audit(warehouseId int, write func() error) error {
+	before := r.lookup(warehouseId)
+	if err := write(); err != nil {
+		return err
+	}
+	r.entries = append(r.entries, auditChanges(warehouseId, before, r.lookup(warehouseId))...)
+	return nil
+}
+
+func (r *auditingRepository) lookup(warehouseId int) *Warehouse {
+	warehouse, err := r.WarehouseRepository.GetWarehouse(warehouseId)
+	if err != nil {
+		return nil
+	}
+	return &warehouse
+}
+
+func (r *auditingRepository) SaveWarehouse(warehouse Warehouse) error {
+	return r.audit(warehouse.Id, func() error {
+		return r.WarehouseRepository.SaveWarehouse(warehouse)
+	})
+}
+
+func (r *auditingRepository) RemoveWarehouse(warehouseId int) error {
+	return r.audit(warehouseId, func() error {
+		return r.WarehouseRepository.RemoveWarehouse(warehouseId)
+	})
+}
+
+func (r *auditingRepository) AddItem(warehouseId int, item Item) error {
+	return r.audit(warehouseId, func() error {
+		return r.WarehouseRepository.AddItem(warehouseId, item)
+	})
+}
+
+func (r *auditingRepository) UpdateItem(warehouseId int, item Item) error {
+	return r.audit(warehouseId, func() error {
+		return r.WarehouseRepository.UpdateItem(warehouseId, item)
+	})
+}
+
+func (r *auditingRepository) RemoveItem(warehouseId, itemId int) error {
+	return r.audit(warehouseId, func() error {
+		return r.WarehouseRepository.RemoveItem(warehouseId, itemId)
+	})
+}
+
+// The state of the service is passed on without entries of its own.
+func (r *auditingRepository) LoadState() (ServiceState, error) {
+	return loadState(r.WarehouseRepository)
+}
+
+func (r *auditingRepository) SaveState(state ServiceState) error {
+	return saveState(r.WarehouseRepository, state)
+}
+
+func (r *auditingRepository) LoadAudit() ([]AuditEntry, error) {
+	return loadAudit(r.WarehouseRepository)
+}
+
+func (r *auditingRepository) AppendAudit(entries []AuditEntry) error {
+	return appendAudit(r.WarehouseRepository, entries)
+}
+
+// auditChanges returns the entries for the warehouse and for each of its
+// items that differ between the two states. Versions are not compared.
+func auditChanges(warehouseId int, before, after *Warehouse) []AuditEntry {
+	var entries []AuditEntry
+
+	warehouseBefore, warehouseAfter := withoutItems(before), withoutItems(after)
+	entry := AuditEntry{WarehouseId: warehouseId, WarehouseBefore: warehouseBefore, WarehouseAfter: warehouseAfter}
+	if warehouseBefore != nil && warehouseAfter != nil {
+		entry.Changes = describeFieldChanges(*warehouseBefore, *warehouseAfter)
+	}
+	if warehouseBefore == nil || warehouseAfter == nil || len(entry.Changes) > 0 {
+		entries = append(entries, entry)
+	}
+
+	var itemIds []int
+	seen := make(map[int]bool)
+	for _, warehouse := range []*Warehouse{before, after} {
+		if warehouse == nil {
+			continue
+		}
+		for _, item := range warehouse.Items {
+			if !seen[item.ItemId] {
+				seen[item.ItemId] = true
+				itemIds = append(itemIds, item.ItemId)
+			}
+		}
+	}
+
+	for _, itemId := range itemIds {
+		entry := AuditEntry{
+			WarehouseId: warehouseId,
+			ItemId:      itemId,
+			ItemBefore:  itemOf(before, itemId),
+			ItemAfter:   itemOf(after, itemId),
+		}
+		if entry.ItemBefore != nil && entry.ItemAfter != nil {
+			entry.Changes = describeFieldChanges(*entry.ItemBefore, *entry.ItemAfter)
+			if len(entry.Changes) == 0 {
+				continue
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func withoutItems(warehouse *Warehouse) *Warehouse {
+	if warehouse == nil {
+		return nil
+	}
+	stripped := cloneWarehouse(*warehouse)
+	stripped.Items = nil
+	return &stripped
+}
+
+func itemOf(warehouse *Warehouse, itemId int) *Item {
+	if warehouse == nil {
+		return nil
+	}
+	_, itemIndex, found := findItem([]Warehouse{*warehouse}, itemId)
+	if !found {
+		return nil
+	}
+	item := cloneItem(warehouse.Items[itemIndex])
+	return &item
+}
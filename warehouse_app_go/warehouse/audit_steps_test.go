@@ -0,0 +1,200 @@
+package warehouse
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func initAuditSteps(ctx *godog.ScenarioContext) {
+	// GIVEN
+	ctx.Given(`^the service keeps an audit log$`, theServiceKeepsAnAuditLog)
+	ctx.Given(`^"([^"]*)" is acting because "([^"]*)"$`, isActingBecause)
+	ctx.Given(`^nobody is acting$`, nobodyIsActing)
+
+	// WHEN
+	ctx.When(`^"([^"]*)" adds warehouse (\d+) with total volume (\d+\.?\d*) because "([^"]*)"$`, addsWarehouseBecause)
+	ctx.When(`^"([^"]*)" reserves volume (\d+\.?\d*) with priority (\d+) from "([^"]*)" to "([^"]*)" because "([^"]*)"$`,
+		reservesVolumeWithPriorityBecause)
+
+	// THEN
+	ctx.Then(`^the audit entries for item (\d+) should be:$`, theAuditEntriesForItemShouldBe)
+	ctx.Then(`^the audit entries for warehouse (\d+) should be:$`, theAuditEntriesForWarehouseShouldBe)
+	ctx.Then(`^the audit entries of "([^"]*)" should be:$`, theAuditEntriesOfShouldBe)
+	ctx.Then(`^the audit entries recorded from "([^"]*)" to "([^"]*)" should be:$`, theAuditEntriesRecordedFromToShouldBe)
+	ctx.Then(`^audit entry (\d+) should change the end date from "([^"]*)" to "([^"]*)"$`, auditEntryShouldChangeTheEndDate)
+	ctx.Then(`^the audit entries for item (\d+) should not change when the returned entries are modified$`,
+		theAuditEntriesForItemShouldNotChangeWhenModified)
+	ctx.Then(`^the warehouse and every reservation made should have an audit entry, numbered one after another$`,
+		theWarehouseAndEveryReservationMadeShouldHaveAnAuditEntry)
+}
+
+// -------------------
+// GIVEN Steps (Arrange)
+// -------------------
+
+func theServiceKeepsAnAuditLog(_ context.Context) {
+	tc.service.shared().Audit = &AuditLog{}
+}
+
+func isActingBecause(_ context.Context, actor, reason string) {
+	tc.service = *actingBase().As(actor, reason)
+}
+
+func nobodyIsActing(_ context.Context) {
+	tc.service = *actingBase().As("", "")
+}
+
+// actingBase returns the service views are made from. The first view moves
+// the service out of tc.service, which holds the view from then on.
+func actingBase() *WarehouseStorageService {
+	if tc.service.parent != nil {
+		return tc.service.parent
+	}
+	base := tc.service
+	return &base
+}
+
+// -------------------
+// WHEN Steps (Act)
+// -------------------
+
+// The steps below act through a view of tc.service and leave tc.service
+// itself in place.
+
+func addsWarehouseBecause(_ context.Context, actor string, warehouseId int, volume float64, reason string) {
+	_, tc.eventLogErr = tc.service.As(actor, reason).AddWarehouse(Warehouse{
+		Id:          warehouseId,
+		MaxCapacity: ThreeDRoom{Height: volume, Width: 1, Length: 1},
+	})
+}
+
+func reservesVolumeWithPriorityBecause(
+	ctx context.Context,
+	actor string, volume float64, priority int, startStr, endStr, reason string,
+) {
+	t := godog.T(ctx)
+
+	tc.preemptionReport, tc.preemptionErr = tc.service.As(actor, reason).ReserveWithPreemption(Item{
+		ItemHeight: volume,
+		ItemWidth:  1,
+		ItemLength: 1,
+		StartDate:  parseDate(t, startStr),
+		EndDate:    parseDate(t, endStr),
+		Priority:   priority,
+	})
+}
+
+// -------------------
+// THEN Steps (Assert)
+// -------------------
+
+func theAuditEntriesForItemShouldBe(ctx context.Context, itemId int, table *godog.Table) {
+	assertAuditEntries(ctx, AuditQuery{ItemId: itemId}, table)
+}
+
+func theAuditEntriesForWarehouseShouldBe(ctx context.Context, warehouseId int, table *godog.Table) {
+	assertAuditEntries(ctx, AuditQuery{WarehouseId: warehouseId}, table)
+}
+
+func theAuditEntriesOfShouldBe(ctx context.Context, actor string, table *godog.Table) {
+	assertAuditEntries(ctx, AuditQuery{Actor: actor}, table)
+}
+
+func theAuditEntriesRecordedFromToShouldBe(ctx context.Context, fromStr, toStr string, table *godog.Table) {
+	t := godog.T(ctx)
+	assertAuditEntries(ctx, AuditQuery{From: parseDate(t, fromStr), To: parseDate(t, toStr)}, table)
+}
+
+func theWarehouseAndEveryReservationMadeShouldHaveAnAuditEntry(ctx context.Context) {
+	t := godog.T(ctx)
+
+	count := 1
+	for _, err := range tc.reservationErrs {
+		if err == nil {
+			count++
+		}
+	}
+	entries := tc.service.shared().Audit.Query(AuditQuery{})
+	assert.Len(t, entries, count, "audit entry count mismatch")
+	for i, entry := range entries {
+		assert.Equal(t, i+1, entry.Sequence, "sequence mismatch")
+	}
+}
+
+func auditEntryShouldChangeTheEndDate(ctx context.Context, sequence int, beforeStr, afterStr string) {
+	t := godog.T(ctx)
+
+	for _, entry := range tc.service.shared().Audit.Query(AuditQuery{}) {
+		if entry.Sequence != sequence {
+			continue
+		}
+		if assert.NotNil(t, entry.ItemBefore, "entry %d has no item before", sequence) &&
+			assert.NotNil(t, entry.ItemAfter, "entry %d has no item after", sequence) {
+			assert.Equal(t, parseDate(t, beforeStr), entry.ItemBefore.EndDate, "end date before")
+			assert.Equal(t, parseDate(t, afterStr), entry.ItemAfter.EndDate, "end date after")
+		}
+		return
+	}
+	assert.Fail(t, "audit entry not found", "sequence %d", sequence)
+}
+
+func theAuditEntriesForItemShouldNotChangeWhenModified(ctx context.Context, itemId int) {
+	t := godog.T(ctx)
+
+	query := AuditQuery{ItemId: itemId}
+	expected := tc.service.shared().Audit.Query(query)
+	modified := tc.service.shared().Audit.Query(query)
+	for i := range modified {
+		modified[i].Actor = "mallory"
+		modified[i].Changes = append(modified[i].Changes[:0], "nothing")
+		if modified[i].ItemAfter != nil {
+			modified[i].ItemAfter.EndDate = modified[i].ItemAfter.EndDate.AddDate(1, 0, 0)
+		}
+	}
+
+	assert.NotEmpty(t, expected, "no audit entries")
+	assert.Equal(t, expected, tc.service.shared().Audit.Query(query), "audit entries changed")
+}
+
+func assertAuditEntries(ctx context.Context, query AuditQuery, table *godog.Table) {
+	t := godog.T(ctx)
+
+	var expected, actual [][]string
+	for _, row := range table.Rows[1:] {
+		var cells []string
+		for _, cell := range row.Cells {
+			cells = append(cells, cell.Value)
+		}
+		expected = append(expected, cells)
+	}
+
+	for _, entry := range tc.service.shared().Audit.Query(query) {
+		item := ""
+		if entry.ItemId != 0 {
+			item = strconv.Itoa(entry.ItemId)
+		}
+		changes := strings.Join(entry.Changes, ", ")
+		switch {
+		case entry.WarehouseBefore == nil && entry.ItemBefore == nil:
+			changes = "created"
+		case entry.WarehouseAfter == nil && entry.ItemAfter == nil:
+			changes = "removed"
+		}
+		actual = append(actual, []string{
+			strconv.Itoa(entry.Sequence),
+			entry.RecordedAt.Format("2006-01-02"),
+			entry.Actor,
+			entry.Reason,
+			string(entry.Operation),
+			strconv.Itoa(entry.WarehouseId),
+			item,
+			changes,
+		})
+	}
+
+	assert.Equal(t, expected, actual, "audit entries mismatch")
+}
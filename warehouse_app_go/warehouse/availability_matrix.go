@@ -0,0 +1,72 @@
+package warehouse
+
+import (
+	"errors"
+	"time"
+)
+
+// AvailabilityCell tells whether a warehouse can take the item for a start
+// date. FreeMargin is the smallest free volume left during the stay once the
+// item is stored and is negative when the item does not fit.
+type AvailabilityCell struct {
+	Available  bool
+	FreeMargin float64
+}
+
+// AvailabilityMatrix holds one row per start date and one column per
+// warehouse, in the order of StartDates and WarehouseIds.
+type AvailabilityMatrix struct {
+	StartDates   []time.Time
+	WarehouseIds []int
+	Cells        [][]AvailabilityCell
+}
+
+// -------------------------------------------------
+// GetAvailabilityMatrix
+// -------------------------------------------------
+func (service *WarehouseStorageService) GetAvailabilityMatrix(
+	dimensions ThreeDRoom,
+	duration int,
+	windowStart, windowEnd time.Time,
+) (AvailabilityMatrix, error) {
+
+	if duration < 1 {
+		return AvailabilityMatrix{}, errors.New("the duration must be at least one day")
+	}
+
+	if windowStart.After(windowEnd) {
+		return AvailabilityMatrix{}, ErrInvalidPeriod
+	}
+
+	lastEnd := windowEnd.AddDate(0, 0, duration-1)
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return AvailabilityMatrix{}, err
+	}
+
+	if err := service.validateStay(warehouses, windowStart, lastEnd, dimensions.Height, dimensions.Width, dimensions.Length); err != nil {
+		return AvailabilityMatrix{}, err
+	}
+
+	now := service.now()
+	matrix := AvailabilityMatrix{}
+	for day := windowStart; !day.After(windowEnd); day = day.AddDate(0, 0, 1) {
+		matrix.StartDates = append(matrix.StartDates, day)
+		matrix.Cells = append(matrix.Cells, make([]AvailabilityCell, len(warehouses)))
+	}
+
+	for column, warehouse := range warehouses {
+		matrix.WarehouseIds = append(matrix.WarehouseIds, warehouse.Id)
+
+		margins := warehouse.getStayMargins(dimensions.GetVolume(), duration, windowStart, windowEnd, now)
+		for row, start := range matrix.StartDates {
+			stay := Item{StartDate: start, EndDate: start.AddDate(0, 0, duration-1)}
+			matrix.Cells[row][column] = AvailabilityCell{
+				Available:  margins[row] >= 0 && warehouse.canOperate(stay, now),
+				FreeMargin: margins[row],
+			}
+		}
+	}
+
+	return matrix, nil
+}
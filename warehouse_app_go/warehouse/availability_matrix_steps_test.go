@@ -0,0 +1,62 @@
+package warehouse
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func initAvailabilityMatrixSteps(ctx *godog.ScenarioContext) {
+	// WHEN
+	ctx.When(`^I request the availability matrix of (\d+) days? between "([^"]*)" and "([^"]*)" with dimensions:$`,
+		iRequestTheAvailabilityMatrix)
+
+	// THEN
+	ctx.Then(`^the availability matrix should be:$`, theAvailabilityMatrixShouldBe)
+}
+
+// -------------------
+// WHEN Step (Act)
+// -------------------
+
+func iRequestTheAvailabilityMatrix(ctx context.Context, duration int, startStr, endStr string, table *godog.Table) {
+	t := godog.T(ctx)
+
+	tc.matrixResult, tc.matrixErr = tc.service.GetAvailabilityMatrix(
+		*parseDimensionsTable(table),
+		duration,
+		parseDate(t, startStr),
+		parseDate(t, endStr),
+	)
+}
+
+// -------------------
+// THEN Steps (Assert)
+// -------------------
+
+func theAvailabilityMatrixShouldBe(ctx context.Context, table *godog.Table) {
+	t := godog.T(ctx)
+
+	assert.NoError(t, tc.matrixErr, "unexpected error")
+
+	actual := make(map[string]AvailabilityCell)
+	for row, start := range tc.matrixResult.StartDates {
+		for column, warehouseId := range tc.matrixResult.WarehouseIds {
+			key := start.Format("2006-01-02") + "/" + strconv.Itoa(warehouseId)
+			actual[key] = tc.matrixResult.Cells[row][column]
+		}
+	}
+
+	expected := make(map[string]AvailabilityCell)
+	for _, row := range table.Rows[1:] {
+		key := row.Cells[0].Value + "/" + row.Cells[1].Value
+		expected[key] = AvailabilityCell{
+			Available:  row.Cells[2].Value == "yes",
+			FreeMargin: parseFloat(row.Cells[3].Value),
+		}
+	}
+
+	assert.Equal(t, expected, actual, "availability matrix mismatch")
+}
@@ -0,0 +1,76 @@
+package warehouse
+
+import "errors"
+
+type ItemPlacement struct {
+	ItemId      int
+	WarehouseId int
+}
+
+// ItemFailure explains why the item at Index of a batch could not be reserved.
+type ItemFailure struct {
+	Index  int
+	ItemId int
+	Err    error
+}
+
+type BatchReservationResult struct {
+	Placements []ItemPlacement
+	Failures   []ItemFailure
+}
+
+// -------------------------------------------------
+// ReserveBatch
+// -------------------------------------------------
+
+// ReserveBatch reserves all items or none of them. Items are checked against
+// the existing occupancy and against each other in the order given; when one
+// of them does not fit the reasons for every failing item are returned and
+// the service is left unchanged.
+func (service *WarehouseStorageService) ReserveBatch(items []Item) (BatchReservationResult, error) {
+	var result BatchReservationResult
+
+	if len(items) == 0 {
+		return result, errors.New("the batch does not contain any items")
+	}
+
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return result, err
+	}
+
+	candidate := service.workingCopy(warehouses)
+	// Ids given in the batch are set aside before any are assigned, so that
+	// an item without an id never takes one a later item asks for.
+	explicitIds := make(map[int]bool)
+	for _, item := range items {
+		if item.ItemId != 0 {
+			explicitIds[item.ItemId] = true
+		}
+	}
+
+	nextId := nextItemId(warehouses)
+	for index, item := range items {
+		if item.ItemId == 0 {
+			for explicitIds[nextId] {
+				nextId++
+			}
+			item.ItemId = nextId
+			nextId++
+		}
+
+		warehouseId, err := candidate.Reserve(item)
+		if err != nil {
+			result.Failures = append(result.Failures, ItemFailure{Index: index, ItemId: item.ItemId, Err: err})
+			continue
+		}
+		result.Placements = append(result.Placements, ItemPlacement{ItemId: item.ItemId, WarehouseId: warehouseId})
+	}
+
+	if len(result.Failures) > 0 {
+		result.Placements = nil
+		return result, errors.New("the batch cannot be reserved")
+	}
+
+	return result, service.commit(BatchReserved, warehouses, candidate)
+}
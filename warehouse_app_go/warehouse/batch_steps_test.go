@@ -0,0 +1,85 @@
+package warehouse
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func initReserveBatchSteps(ctx *godog.ScenarioContext) {
+	// GIVEN
+	ctx.Given(`^a batch of items:$`, aBatchOfItems)
+
+	// WHEN
+	ctx.When(`^I reserve the batch$`, iReserveTheBatch)
+
+	// THEN
+	ctx.Then(`^the batch should be placed as:$`, theBatchShouldBePlacedAs)
+	ctx.Then(`^the batch failures should be:$`, theBatchFailuresShouldBe)
+}
+
+// -------------------
+// GIVEN Steps (Arrange)
+// -------------------
+
+func aBatchOfItems(ctx context.Context, table *godog.Table) {
+	t := godog.T(ctx)
+
+	for _, row := range table.Rows[1:] {
+		id, _ := strconv.Atoi(row.Cells[0].Value)
+		tc.batchItems = append(tc.batchItems, Item{
+			ItemId:     id,
+			ItemName:   "Batch",
+			ItemHeight: parseFloat(row.Cells[1].Value),
+			ItemWidth:  1,
+			ItemLength: 1,
+			StartDate:  parseDate(t, row.Cells[2].Value),
+			EndDate:    parseDate(t, row.Cells[3].Value),
+		})
+	}
+}
+
+// -------------------
+// WHEN Step (Act)
+// -------------------
+
+func iReserveTheBatch(_ context.Context) {
+	tc.batchResult, tc.batchErr = tc.service.ReserveBatch(tc.batchItems)
+}
+
+// -------------------
+// THEN Steps (Assert)
+// -------------------
+
+func theBatchShouldBePlacedAs(ctx context.Context, table *godog.Table) {
+	t := godog.T(ctx)
+
+	assert.NoError(t, tc.batchErr, "unexpected error")
+
+	var expected []ItemPlacement
+	for _, row := range table.Rows[1:] {
+		itemId, _ := strconv.Atoi(row.Cells[0].Value)
+		warehouseId, _ := strconv.Atoi(row.Cells[1].Value)
+		expected = append(expected, ItemPlacement{ItemId: itemId, WarehouseId: warehouseId})
+	}
+
+	assert.Equal(t, expected, tc.batchResult.Placements, "placements mismatch")
+}
+
+func theBatchFailuresShouldBe(ctx context.Context, table *godog.Table) {
+	t := godog.T(ctx)
+
+	if !assert.Len(t, tc.batchResult.Failures, len(table.Rows)-1, "failure count mismatch") {
+		return
+	}
+
+	for i, row := range table.Rows[1:] {
+		itemId, _ := strconv.Atoi(row.Cells[0].Value)
+		failure := tc.batchResult.Failures[i]
+
+		assert.Equal(t, itemId, failure.ItemId, "item mismatch at row %d", i)
+		assert.EqualError(t, failure.Err, row.Cells[1].Value, "reason mismatch at row %d", i)
+	}
+}
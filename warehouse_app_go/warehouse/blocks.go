@@ -0,0 +1,232 @@
+package warehouse
+
+import (
+	"math"
+	"time"
+)
+
+type BlockUtilization struct {
+	BlockId            int
+	WarehouseId        int
+	CustomerId         int
+	Daily              map[time.Time]float64
+	ReservedVolumeDays float64
+	UsedVolumeDays     float64
+	Utilization        float64
+}
+
+func (b CapacityBlock) isEffective(day time.Time) bool {
+	return withinDays(day, b.StartDate, b.EndDate)
+}
+
+// GetVolumeBlockedOnDay returns the volume held by capacity blocks on the day
+// that their customers do not fill with their own items.
+func (w Warehouse) GetVolumeBlockedOnDay(day, now time.Time) float64 {
+	volume := 0.0
+	seen := make(map[int]bool)
+	for _, block := range w.Blocks {
+		if seen[block.CustomerId] || !block.isEffective(day) {
+			continue
+		}
+		seen[block.CustomerId] = true
+		volume += w.getUnusedBlockVolume(block.CustomerId, day, now)
+	}
+	return volume
+}
+
+func (w Warehouse) getUnusedBlockVolume(customerId int, day, now time.Time) float64 {
+	if customerId == 0 {
+		return 0
+	}
+	reserved := 0.0
+	for _, block := range w.Blocks {
+		if block.CustomerId == customerId && block.isEffective(day) {
+			reserved += block.Volume
+		}
+	}
+	if reserved == 0 {
+		return 0
+	}
+	return math.Max(0, reserved-w.getCustomerOccupiedOnDay(customerId, day, now))
+}
+
+// getCustomerOccupiedOnDay returns the volume the items of the customer take
+// up in the warehouse on the day, buffer days included.
+func (w Warehouse) getCustomerOccupiedOnDay(customerId int, day, now time.Time) float64 {
+	volume := 0.0
+	for _, item := range w.Items {
+		if item.CustomerId != customerId || !item.IsActive {
+			continue
+		}
+		start, end := w.GetBufferedPeriod(item, now)
+		if withinDays(day, start, end) {
+			volume += item.GetItemVolume()
+		}
+	}
+	return volume
+}
+
+// getBlockUsageOnDay returns the part of the block filled by items of its
+// customer. Blocks of the same customer are filled in the order they were
+// added.
+func (w Warehouse) getBlockUsageOnDay(blockId int, day, now time.Time) float64 {
+	var target CapacityBlock
+	for _, block := range w.Blocks {
+		if block.Id == blockId {
+			target = block
+		}
+	}
+	if !target.isEffective(day) {
+		return 0
+	}
+
+	remaining := w.getCustomerOccupiedOnDay(target.CustomerId, day, now)
+	for _, block := range w.Blocks {
+		if block.CustomerId != target.CustomerId || !block.isEffective(day) {
+			continue
+		}
+		used := math.Min(remaining, block.Volume)
+		if block.Id == blockId {
+			return used
+		}
+		remaining -= used
+	}
+	return 0
+}
+
+func nextBlockId(warehouses []Warehouse) int {
+	maxId := 0
+	for _, warehouse := range warehouses {
+		for _, block := range warehouse.Blocks {
+			maxId = max(maxId, block.Id)
+		}
+	}
+	return maxId + 1
+}
+
+func findBlock(warehouses []Warehouse, blockId int) (int, int, bool) {
+	for i, warehouse := range warehouses {
+		for j, block := range warehouse.Blocks {
+			if block.Id == blockId {
+				return i, j, true
+			}
+		}
+	}
+	return -1, -1, false
+}
+
+// -------------------------------------------------
+// AddCapacityBlock
+// -------------------------------------------------
+
+// AddCapacityBlock sets volume of the warehouse aside for the customer of the
+// block over its contract period. Items the customer already stores there
+// count against the block, everything else has to keep fitting.
+func (service *WarehouseStorageService) AddCapacityBlock(
+	warehouseId int,
+	block CapacityBlock,
+) (CapacityBlock, error) {
+
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return CapacityBlock{}, err
+	}
+
+	warehouseIndex, found := findWarehouse(warehouses, warehouseId)
+	if !found {
+		return CapacityBlock{}, ErrWarehouseNotFound
+	}
+
+	if customer, err := service.findCustomer(block.CustomerId); err != nil || customer == nil {
+		return CapacityBlock{}, ErrCustomerNotFound
+	}
+
+	if block.Volume <= 0 {
+		return CapacityBlock{}, ErrBlockVolume
+	}
+
+	if block.StartDate.After(block.EndDate) {
+		return CapacityBlock{}, ErrInvalidPeriod
+	}
+
+	block.Id = nextBlockId(warehouses)
+	candidate := warehouses[warehouseIndex]
+	candidate.Blocks = append(candidate.Blocks, block)
+	now := service.now()
+	for day := block.StartDate; !day.After(block.EndDate); day = day.AddDate(0, 0, 1) {
+		if candidate.GetVolumeOccupiedOnDay(day, now) > candidate.GetCapacityOnDay(day) {
+			return CapacityBlock{}, ErrBlockNoCapacity
+		}
+	}
+
+	err = service.write(CapacityBlockAdded, func(repository WarehouseRepository) error {
+		return repository.SaveWarehouse(candidate)
+	})
+	if err != nil {
+		return CapacityBlock{}, err
+	}
+	return block, nil
+}
+
+// -------------------------------------------------
+// RemoveCapacityBlock
+// -------------------------------------------------
+func (service *WarehouseStorageService) RemoveCapacityBlock(blockId int) error {
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return err
+	}
+
+	warehouseIndex, blockIndex, found := findBlock(warehouses, blockId)
+	if !found {
+		return ErrBlockNotFound
+	}
+
+	warehouse := warehouses[warehouseIndex]
+	warehouse.Blocks = append(warehouse.Blocks[:blockIndex:blockIndex], warehouse.Blocks[blockIndex+1:]...)
+	return service.write(CapacityBlockRemoved, func(repository WarehouseRepository) error {
+		return repository.SaveWarehouse(warehouse)
+	})
+}
+
+// -------------------------------------------------
+// GetBlockUtilization
+// -------------------------------------------------
+
+// GetBlockUtilization reports how much of the block its customer fills on
+// every day of the contract period, and the share of the reserved volume-days
+// that were used.
+func (service *WarehouseStorageService) GetBlockUtilization(blockId int) (BlockUtilization, error) {
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return BlockUtilization{}, err
+	}
+
+	warehouseIndex, blockIndex, found := findBlock(warehouses, blockId)
+	if !found {
+		return BlockUtilization{}, ErrBlockNotFound
+	}
+
+	warehouse := warehouses[warehouseIndex]
+	block := warehouse.Blocks[blockIndex]
+	utilization := BlockUtilization{
+		BlockId:     block.Id,
+		WarehouseId: warehouse.Id,
+		CustomerId:  block.CustomerId,
+		Daily:       make(map[time.Time]float64),
+	}
+
+	now := service.now()
+	for day := block.StartDate; !day.After(block.EndDate); day = day.AddDate(0, 0, 1) {
+		used := warehouse.getBlockUsageOnDay(block.Id, day, now)
+		utilization.Daily[day] = used
+		utilization.ReservedVolumeDays += block.Volume
+		utilization.UsedVolumeDays += used
+	}
+
+	if utilization.ReservedVolumeDays > 0 {
+		utilization.Utilization = utilization.UsedVolumeDays / utilization.ReservedVolumeDays
+	}
+
+	return utilization, nil
+}
@@ -0,0 +1,68 @@
+package warehouse
+
+import (
+	"context"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func initCapacityBlockSteps(ctx *godog.ScenarioContext) {
+	// GIVEN
+	ctx.Given(`^a customer with id (\d+)$`, aCustomerWithId)
+	ctx.Given(`^customer (\d+) has a block of (\d+\.?\d*) in warehouse (\d+) from "([^"]*)" to "([^"]*)"$`, customerHasABlock)
+
+	// WHEN
+	ctx.When(`^I remove capacity block (\d+)$`, iRemoveCapacityBlock)
+
+	// THEN
+	ctx.Then(`^capacity block (\d+) should be used:$`, capacityBlockShouldBeUsed)
+	ctx.Then(`^capacity block (\d+) should be (\d+\.?\d*) percent utilized$`, capacityBlockShouldBePercentUtilized)
+}
+
+// -------------------
+// GIVEN Steps (Arrange)
+// -------------------
+
+func aCustomerWithId(_ context.Context, customerId int) {
+	tc.AddCustomer(Customer{Id: customerId})
+}
+
+func customerHasABlock(ctx context.Context, customerId int, volume float64, warehouseId int, startStr, endStr string) {
+	t := godog.T(ctx)
+
+	_, tc.blockErr = tc.service.AddCapacityBlock(warehouseId, CapacityBlock{
+		CustomerId: customerId,
+		Volume:     volume,
+		StartDate:  parseDate(t, startStr),
+		EndDate:    parseDate(t, endStr),
+	})
+}
+
+// -------------------
+// WHEN Steps (Act)
+// -------------------
+
+func iRemoveCapacityBlock(_ context.Context, blockId int) {
+	tc.blockErr = tc.service.RemoveCapacityBlock(blockId)
+}
+
+// -------------------
+// THEN Steps (Assert)
+// -------------------
+
+func capacityBlockShouldBeUsed(ctx context.Context, blockId int, table *godog.Table) {
+	t := godog.T(ctx)
+
+	utilization, err := tc.service.GetBlockUtilization(blockId)
+	assert.NoError(t, err, "unexpected error")
+	assert.Equal(t, tableToTimeMap(t, table, 0, 1), utilization.Daily, "block usage mismatch")
+}
+
+func capacityBlockShouldBePercentUtilized(ctx context.Context, blockId int, percent float64) {
+	t := godog.T(ctx)
+
+	utilization, err := tc.service.GetBlockUtilization(blockId)
+	assert.NoError(t, err, "unexpected error")
+	assert.InDelta(t, percent/100, utilization.Utilization, 1e-9, "block utilization mismatch")
+}
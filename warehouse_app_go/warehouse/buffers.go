@@ -0,0 +1,42 @@
+package warehouse
+
+import "time"
+
+type OccupancyBreakdown struct {
+	Stored   float64
+	Buffered float64
+}
+
+// -------------------------------------------------
+// GetOccupancyBreakdown
+// -------------------------------------------------
+func (service *WarehouseStorageService) GetOccupancyBreakdown(
+	startDate, endDate time.Time,
+) (map[time.Time]OccupancyBreakdown, error) {
+
+	warehouses, err := service.repository().ListWarehousesOverlapping(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(warehouses) == 0 {
+		return nil, ErrNoWarehouses
+	}
+
+	if startDate.After(endDate) {
+		return nil, ErrInvalidPeriod
+	}
+
+	now := service.now()
+	breakdownMap := make(map[time.Time]OccupancyBreakdown)
+	for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		breakdown := OccupancyBreakdown{}
+		for _, warehouse := range warehouses {
+			breakdown.Stored += warehouse.GetVolumeStoredOnDay(day, now)
+			breakdown.Buffered += warehouse.GetVolumeBufferedOnDay(day, now)
+		}
+		breakdownMap[day] = breakdown
+	}
+
+	return breakdownMap, nil
+}
@@ -0,0 +1,55 @@
+package warehouse
+
+import (
+	"context"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func initBufferDaysSteps(ctx *godog.ScenarioContext) {
+	// GIVEN
+	ctx.Given(`^warehouse (\d+) needs (\d+) days? before and (\d+) days? after each stay$`, warehouseNeedsBufferDays)
+	ctx.Given(`^item (\d+) needs (\d+) days? before and (\d+) days? after its stay$`, itemNeedsBufferDays)
+
+	// THEN
+	ctx.Then(`^the occupancy breakdown from "([^"]*)" to "([^"]*)" should be:$`, theOccupancyBreakdownShouldBe)
+}
+
+// -------------------
+// GIVEN Steps (Arrange)
+// -------------------
+
+func warehouseNeedsBufferDays(_ context.Context, warehouseId, before, after int) {
+	tc.SetWarehouseBuffer(warehouseId, Buffer{Before: before, After: after})
+}
+
+func itemNeedsBufferDays(_ context.Context, itemId, before, after int) {
+	tc.SetItemBuffer(itemId, Buffer{Before: before, After: after})
+}
+
+// -------------------
+// THEN Steps (Assert)
+// -------------------
+
+func theOccupancyBreakdownShouldBe(ctx context.Context, startStr, endStr string, table *godog.Table) {
+	t := godog.T(ctx)
+
+	breakdown, err := tc.service.GetOccupancyBreakdown(parseDate(t, startStr), parseDate(t, endStr))
+	assert.NoError(t, err, "unexpected error")
+
+	expected := make(map[string]OccupancyBreakdown)
+	for _, row := range table.Rows[1:] {
+		expected[row.Cells[0].Value] = OccupancyBreakdown{
+			Stored:   parseFloat(row.Cells[1].Value),
+			Buffered: parseFloat(row.Cells[2].Value),
+		}
+	}
+
+	actual := make(map[string]OccupancyBreakdown)
+	for day, value := range breakdown {
+		actual[day.Format("2006-01-02")] = value
+	}
+
+	assert.Equal(t, expected, actual, "occupancy breakdown mismatch")
+}
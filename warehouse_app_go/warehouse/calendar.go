@@ -0,0 +1,39 @@
+package warehouse
+
+import "time"
+
+func (r DateRange) Contains(day time.Time) bool {
+	return withinDays(day, r.Start, r.End)
+}
+
+func (c OperatingCalendar) IsInService(day time.Time) bool {
+	if !c.CommissionedOn.IsZero() && day.Before(c.CommissionedOn) {
+		return false
+	}
+	if !c.DecommissionedOn.IsZero() && !day.Before(c.DecommissionedOn) {
+		return false
+	}
+	return true
+}
+
+func (c OperatingCalendar) IsOpen(day time.Time) bool {
+	if !c.IsInService(day) || containsWeekday(c.ClosedWeekdays, day.Weekday()) {
+		return false
+	}
+	for _, closure := range c.Closures {
+		if closure.Contains(day) {
+			return false
+		}
+	}
+	return true
+}
+
+// canOperate reports whether the warehouse is in service for the whole
+// buffered stay of the item and open on its check-in and check-out days.
+func (w Warehouse) canOperate(item Item, now time.Time) bool {
+	start, end := w.GetBufferedPeriod(item, now)
+	if !w.Calendar.IsInService(start) || !w.Calendar.IsInService(end) {
+		return false
+	}
+	return w.Calendar.IsOpen(item.StartDate) && w.Calendar.IsOpen(item.EndDate)
+}
@@ -0,0 +1,57 @@
+package warehouse
+
+import (
+	"context"
+	"time"
+
+	"github.com/cucumber/godog"
+)
+
+func initOperatingCalendarSteps(ctx *godog.ScenarioContext) {
+	// GIVEN
+	ctx.Given(`^warehouse (\d+) is closed from "([^"]*)" to "([^"]*)"$`, warehouseIsClosedFromTo)
+	ctx.Given(`^warehouse (\d+) is closed every (\w+)$`, warehouseIsClosedEvery)
+	ctx.Given(`^warehouse (\d+) is commissioned on "([^"]*)"$`, warehouseIsCommissionedOn)
+	ctx.Given(`^warehouse (\d+) is decommissioned on "([^"]*)"$`, warehouseIsDecommissionedOn)
+}
+
+// -------------------
+// GIVEN Steps (Arrange)
+// -------------------
+
+func warehouseIsClosedFromTo(ctx context.Context, warehouseId int, startStr, endStr string) {
+	t := godog.T(ctx)
+	closure := DateRange{Start: parseDate(t, startStr), End: parseDate(t, endStr)}
+
+	tc.UpdateWarehouseCalendar(warehouseId, func(calendar *OperatingCalendar) {
+		calendar.Closures = append(calendar.Closures, closure)
+	})
+}
+
+func warehouseIsClosedEvery(_ context.Context, warehouseId int, weekday string) {
+	tc.UpdateWarehouseCalendar(warehouseId, func(calendar *OperatingCalendar) {
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if day.String() == weekday {
+				calendar.ClosedWeekdays = append(calendar.ClosedWeekdays, day)
+			}
+		}
+	})
+}
+
+func warehouseIsCommissionedOn(ctx context.Context, warehouseId int, dateStr string) {
+	t := godog.T(ctx)
+	date := parseDate(t, dateStr)
+
+	tc.UpdateWarehouseCalendar(warehouseId, func(calendar *OperatingCalendar) {
+		calendar.CommissionedOn = date
+	})
+}
+
+func warehouseIsDecommissionedOn(ctx context.Context, warehouseId int, dateStr string) {
+	t := godog.T(ctx)
+	date := parseDate(t, dateStr)
+
+	tc.UpdateWarehouseCalendar(warehouseId, func(calendar *OperatingCalendar) {
+		calendar.DecommissionedOn = date
+	})
+}
@@ -0,0 +1,36 @@
+package warehouse
+
+import (
+	"context"
+
+	"github.com/cucumber/godog"
+)
+
+func initCapacityScheduleSteps(ctx *godog.ScenarioContext) {
+	// GIVEN
+	ctx.Given(`^warehouse (\d+) is resized from "([^"]*)" to:$`, warehouseIsResizedFrom)
+	ctx.Given(`^warehouse (\d+) loses (\d+\.?\d*) volume from "([^"]*)" to "([^"]*)"$`, warehouseLosesVolumeFromTo)
+}
+
+// -------------------
+// GIVEN Steps (Arrange)
+// -------------------
+
+func warehouseIsResizedFrom(ctx context.Context, warehouseId int, dateStr string, table *godog.Table) {
+	t := godog.T(ctx)
+
+	tc.AddCapacityChange(warehouseId, CapacityChange{
+		EffectiveFrom: parseDate(t, dateStr),
+		Room:          parseDimensionsTable(table),
+	})
+}
+
+func warehouseLosesVolumeFromTo(ctx context.Context, warehouseId int, volume float64, startStr, endStr string) {
+	t := godog.T(ctx)
+
+	tc.AddCapacityChange(warehouseId, CapacityChange{
+		EffectiveFrom:    parseDate(t, startStr),
+		EffectiveTo:      parseDate(t, endStr),
+		VolumeAdjustment: -volume,
+	})
+}
@@ -66,24 +66,45 @@ func iCallCalculateAvailableCapacity(startStr, endStr string) error {
 // THEN Steps (Assert)
 // ------------------------------------------------------------------
 
-func theAvailableCapacitiesShouldBe(_ context.Context, table *godog.Table) error {
-	assert.NoError(tc.t, tc.calculateCapacityErr, "unexpected calculation error")
-	assert.NotNil(tc.t, tc.capacityMap, "capacity map should not be nil")
+func theAvailableCapacitiesShouldBe(ctx context.Context, table *godog.Table) error {
+	t := godog.T(ctx)
 
-	expectedMap := tableToTimeMap(tc.t, table, 0, 1)
-	assert.Equal(tc.t, expectedMap, tc.capacityMap, "capacity map mismatch")
+	assert.NoError(t, tc.calculateCapacityErr, "unexpected calculation error")
+	assert.NotNil(t, tc.capacityMap, "capacity map should not be nil")
+
+	expectedMap := tableToTimeMap(t, table, 0, 1)
+	assert.Equal(t, expectedMap, tc.capacityMap, "capacity map mismatch")
+	return nil
+}
+
+func anErrorShouldBeReturnedWithMessage(ctx context.Context, msg string) error {
+	assert.True(godog.T(ctx), findMatchingError(scenarioErrors(), msg),
+		"expected error containing %q", msg)
 	return nil
 }
 
-func anErrorShouldBeReturnedWithMessage(msg string) error {
-	allErrors := []error{
+// scenarioErrors returns the errors the steps of the scenario recorded.
+func scenarioErrors() []error {
+	return []error{
 		tc.calculateCapacityErr,
 		tc.searchError,
 		tc.fullyUtilizedDatesErr,
 		tc.leastUsedWarehouseErr,
+		tc.recurringErr,
+		tc.transferErr,
+		tc.actualsErr,
+		tc.slotErr,
+		tc.matrixErr,
+		tc.batchErr,
+		tc.rebalanceErr,
+		tc.preemptionErr,
+		tc.blockErr,
+		tc.persistenceErr,
+		tc.repositoryErr,
+		tc.eventLogErr,
+		tc.databaseErr,
+		tc.concurrencyErr,
+		tc.importErr,
+		tc.reportErr,
 	}
-
-	assert.True(tc.t, findMatchingError(allErrors, msg),
-		"expected error containing %q", msg)
-	return nil
 }
@@ -0,0 +1,218 @@
+package warehouse
+
+import (
+	"fmt"
+	"time"
+)
+
+// Violation is a broken rule found in the stored data. Location names the
+// warehouse, item, capacity change or block it was found in, such as
+// "warehouse 1, item 3" or "waitlist, item 5".
+type Violation struct {
+	Location string
+	Problem  string
+}
+
+func (v Violation) String() string {
+	return v.Location + ": " + v.Problem
+}
+
+// -------------------------------------------------
+// Check
+// -------------------------------------------------
+
+// Check scans the warehouses, their items and the waitlist and returns every
+// violation it finds: ids that are not positive or used more than once,
+// zero or negative dimensions, periods that end before they start, items of
+// unknown customers and days on which a warehouse holds more than its
+// capacity. The error is only set when the data cannot be read.
+func (service *WarehouseStorageService) Check() ([]Violation, error) {
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return nil, err
+	}
+
+	shared := service.shared()
+	checker := consistencyChecker{
+		customers:      make(map[int]bool),
+		warehouseSeen:  make(map[int]bool),
+		itemLocations:  make(map[int]string),
+		checkCustomers: len(shared.Customers) > 0,
+		now:            service.now(),
+	}
+	for _, customer := range shared.Customers {
+		checker.customers[customer.Id] = true
+	}
+
+	for _, warehouse := range warehouses {
+		checker.checkWarehouse(warehouse)
+	}
+	for _, item := range shared.Waitlist {
+		checker.checkItem("waitlist", item)
+	}
+	return checker.violations, nil
+}
+
+type consistencyChecker struct {
+	customers      map[int]bool
+	checkCustomers bool
+	warehouseSeen  map[int]bool
+	itemLocations  map[int]string
+	violations     []Violation
+	now            time.Time
+}
+
+func (c *consistencyChecker) report(location, format string, args ...any) {
+	c.violations = append(c.violations, Violation{Location: location, Problem: fmt.Sprintf(format, args...)})
+}
+
+func (c *consistencyChecker) checkWarehouse(warehouse Warehouse) {
+	location := fmt.Sprintf("warehouse %d", warehouse.Id)
+
+	if warehouse.Id <= 0 {
+		c.report(location, "the id must be positive")
+	} else if c.warehouseSeen[warehouse.Id] {
+		c.report(location, "the warehouse id is used more than once")
+	}
+	c.warehouseSeen[warehouse.Id] = true
+
+	if !validRoom(warehouse.MaxCapacity) {
+		c.report(location, "the 3D model has invalid dimensions (zero or negative)")
+	}
+
+	for i, change := range warehouse.CapacitySchedule {
+		changeLocation := fmt.Sprintf("%s, capacity change %d", location, i+1)
+		if change.Room != nil && !validRoom(*change.Room) {
+			c.report(changeLocation, "the 3D model has invalid dimensions (zero or negative)")
+		}
+		if !change.EffectiveTo.IsZero() && change.EffectiveTo.Before(change.EffectiveFrom) {
+			c.report(changeLocation, "the end date %s is earlier than the start date %s",
+				formatCheckDate(change.EffectiveTo), formatCheckDate(change.EffectiveFrom))
+		}
+	}
+
+	for _, block := range warehouse.Blocks {
+		blockLocation := fmt.Sprintf("%s, block %d", location, block.Id)
+		if block.Volume <= 0 {
+			c.report(blockLocation, "the volume must be positive")
+		}
+		if block.EndDate.Before(block.StartDate) {
+			c.report(blockLocation, "the end date %s is earlier than the start date %s",
+				formatCheckDate(block.EndDate), formatCheckDate(block.StartDate))
+		}
+		if c.checkCustomers && !c.customers[block.CustomerId] {
+			c.report(blockLocation, "unknown customer %d", block.CustomerId)
+		}
+	}
+
+	valid := true
+	for _, item := range warehouse.Items {
+		if !c.checkItem(location, item) {
+			valid = false
+		}
+	}
+
+	// Occupancy is only meaningful once every item has a period.
+	if valid {
+		c.checkOccupancy(location, warehouse)
+	}
+}
+
+// checkItem reports the violations of an item kept in container and tells
+// whether the period of the item can be used to compute occupancy.
+func (c *consistencyChecker) checkItem(container string, item Item) bool {
+	location := fmt.Sprintf("%s, item %d", container, item.ItemId)
+
+	if item.ItemId <= 0 {
+		c.report(location, "the id must be positive")
+	} else if first, found := c.itemLocations[item.ItemId]; found {
+		c.report(location, "the item id is already used in %s", first)
+	} else {
+		c.itemLocations[item.ItemId] = container
+	}
+
+	if item.ItemHeight <= 0 || item.ItemWidth <= 0 || item.ItemLength <= 0 {
+		c.report(location, "the 3D model has invalid dimensions (zero or negative)")
+	}
+
+	valid := true
+	if item.StartDate.IsZero() {
+		c.report(location, "the start date is missing")
+		valid = false
+	}
+	if item.EndDate.IsZero() {
+		c.report(location, "the end date is missing")
+		valid = false
+	}
+	if valid && item.EndDate.Before(item.StartDate) {
+		c.report(location, "the end date %s is earlier than the start date %s",
+			formatCheckDate(item.EndDate), formatCheckDate(item.StartDate))
+		valid = false
+	}
+	if !item.CheckedInAt.IsZero() && !item.CheckedOutAt.IsZero() && item.CheckedOutAt.Before(item.CheckedInAt) {
+		c.report(location, "the item was checked out on %s before it was checked in on %s",
+			formatCheckDate(item.CheckedOutAt), formatCheckDate(item.CheckedInAt))
+		valid = false
+	}
+	if item.Buffer != nil && (item.Buffer.Before < 0 || item.Buffer.After < 0) {
+		c.report(location, "the buffer days cannot be negative")
+		valid = false
+	}
+
+	if c.checkCustomers && item.CustomerId != 0 && !c.customers[item.CustomerId] {
+		c.report(location, "unknown customer %d", item.CustomerId)
+	}
+	return valid
+}
+
+// checkOccupancy reports every run of days on which the warehouse holds
+// more than its capacity, as reservations would count it.
+func (c *consistencyChecker) checkOccupancy(location string, warehouse Warehouse) {
+	var startDate, endDate time.Time
+	extend := func(start, end time.Time) {
+		if startDate.IsZero() || start.Before(startDate) {
+			startDate = start
+		}
+		if endDate.IsZero() || end.After(endDate) {
+			endDate = end
+		}
+	}
+	for _, item := range warehouse.Items {
+		if item.IsActive {
+			extend(warehouse.GetBufferedPeriod(item, c.now))
+		}
+	}
+	for _, block := range warehouse.Blocks {
+		extend(block.StartDate, block.EndDate)
+	}
+	if startDate.IsZero() {
+		return
+	}
+
+	profile := warehouse.getFreeVolumeProfile(startDate, endDate, c.now)
+	for i := 0; i < len(profile); i++ {
+		if profile[i] >= 0 {
+			continue
+		}
+		first, excess := i, -profile[i]
+		for i+1 < len(profile) && profile[i+1] < 0 {
+			i++
+			excess = max(excess, -profile[i])
+		}
+
+		from, to := startDate.AddDate(0, 0, first), startDate.AddDate(0, 0, i)
+		if first == i {
+			c.report(location, "overbooked by %g on %s", excess, formatCheckDate(from))
+		} else {
+			c.report(location, "overbooked by up to %g from %s to %s", excess, formatCheckDate(from), formatCheckDate(to))
+		}
+	}
+}
+
+func validRoom(room ThreeDRoom) bool {
+	return room.Height > 0 && room.Width > 0 && room.Length > 0
+}
+
+func formatCheckDate(date time.Time) string {
+	return date.Format(ReportDateFormat)
+}
@@ -0,0 +1,48 @@
+package warehouse
+
+import (
+	"context"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initCheckSteps(ctx *godog.ScenarioContext) {
+	// WHEN
+	ctx.When(`^I check the stored data$`, iCheckTheStoredData)
+
+	// THEN
+	ctx.Then(`^no violations should be found$`, noViolationsShouldBeFound)
+	ctx.Then(`^the violations should be:$`, theViolationsShouldBe)
+}
+
+// -------------------
+// WHEN Steps (Act)
+// -------------------
+
+func iCheckTheStoredData(ctx context.Context) {
+	t := godog.T(ctx)
+
+	var err error
+	tc.violations, err = tc.service.Check()
+	require.NoError(t, err, "unexpected error")
+}
+
+// -------------------
+// THEN Steps (Assert)
+// -------------------
+
+func noViolationsShouldBeFound(ctx context.Context) {
+	assert.Empty(godog.T(ctx), tc.violations, "unexpected violations")
+}
+
+func theViolationsShouldBe(ctx context.Context, table *godog.Table) {
+	t := godog.T(ctx)
+
+	var expected []Violation
+	for _, row := range table.Rows[1:] {
+		expected = append(expected, Violation{Location: row.Cells[0].Value, Problem: row.Cells[1].Value})
+	}
+	assert.Equal(t, expected, tc.violations, "violations mismatch")
+}
@@ -0,0 +1,198 @@
+package warehouse
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ConflictError is returned when a warehouse or item is written based on a
+// version that is no longer the stored one. Current holds the warehouse as
+// it is stored now and Changes names the parts of it that differ from what
+// the caller wrote, so that the caller can merge and retry.
+type ConflictError struct {
+	WarehouseId     int
+	ItemId          int
+	ExpectedVersion int
+	ActualVersion   int
+	Current         Warehouse
+	Changes         []string
+}
+
+func (e *ConflictError) Error() string {
+	subject := fmt.Sprintf("warehouse %d", e.WarehouseId)
+	if e.ItemId != 0 {
+		subject = fmt.Sprintf("item %d in warehouse %d", e.ItemId, e.WarehouseId)
+	}
+	message := fmt.Sprintf("%s was changed concurrently (expected version %d, found version %d)",
+		subject, e.ExpectedVersion, e.ActualVersion)
+	if len(e.Changes) > 0 {
+		message += ": " + strings.Join(e.Changes, ", ")
+	}
+	return message
+}
+
+// storeWarehouse returns warehouse as it replaces current in storage. The
+// version of the warehouse must match the stored one; it is advanced, as are
+// the versions of the items that changed. A new warehouse keeps the versions
+// it brings along, starting at 1.
+func storeWarehouse(current *Warehouse, warehouse Warehouse) (Warehouse, error) {
+	stored := cloneWarehouse(warehouse)
+	if current == nil {
+		stored.Version = max(stored.Version, 1)
+		for i := range stored.Items {
+			stored.Items[i].Version = max(stored.Items[i].Version, 1)
+		}
+		return stored, nil
+	}
+
+	if current.Version != warehouse.Version {
+		return Warehouse{}, &ConflictError{
+			WarehouseId:     warehouse.Id,
+			ExpectedVersion: warehouse.Version,
+			ActualVersion:   current.Version,
+			Current:         cloneWarehouse(*current),
+			Changes:         describeWarehouseChanges(warehouse, *current),
+		}
+	}
+
+	stored.Version = current.Version + 1
+	for i, item := range stored.Items {
+		_, previousIndex, found := findItem([]Warehouse{*current}, item.ItemId)
+		if !found {
+			stored.Items[i].Version = 1
+			continue
+		}
+		previous := current.Items[previousIndex]
+		item.Version = previous.Version
+		stored.Items[i].Version = previous.Version
+		if !reflect.DeepEqual(item, previous) {
+			stored.Items[i].Version++
+		}
+	}
+	return stored, nil
+}
+
+// storeItem returns the warehouse with item replacing the stored item of the
+// same id. The version of the item must match the stored one.
+func storeItem(current Warehouse, item Item) (Warehouse, error) {
+	_, itemIndex, found := findItem([]Warehouse{current}, item.ItemId)
+	if !found {
+		return Warehouse{}, ErrItemNotFound
+	}
+
+	previous := current.Items[itemIndex]
+	if previous.Version != item.Version {
+		return Warehouse{}, &ConflictError{
+			WarehouseId:     current.Id,
+			ItemId:          item.ItemId,
+			ExpectedVersion: item.Version,
+			ActualVersion:   previous.Version,
+			Current:         cloneWarehouse(current),
+			Changes:         describeFieldChanges(item, previous),
+		}
+	}
+
+	stored := cloneWarehouse(current)
+	item.Version++
+	stored.Items[itemIndex] = cloneItem(item)
+	stored.Version++
+	return stored, nil
+}
+
+// expectVersion fails with a ConflictError when the warehouse was changed
+// since it was read. Operations that decided on the space the warehouse had
+// left check it before they write.
+func expectVersion(repository WarehouseRepository, warehouse Warehouse) error {
+	current, err := repository.GetWarehouse(warehouse.Id)
+	if err != nil {
+		return err
+	}
+	if current.Version != warehouse.Version {
+		return &ConflictError{
+			WarehouseId:     warehouse.Id,
+			ExpectedVersion: warehouse.Version,
+			ActualVersion:   current.Version,
+			Current:         current,
+			Changes:         describeWarehouseChanges(warehouse, current),
+		}
+	}
+	return nil
+}
+
+// expectNewItems fails with a ConflictError when one of the items is already
+// stored. Ids allocated from the warehouses read before the write can have
+// been taken by another writer since; the caller reads again and retries.
+func expectNewItems(repository WarehouseRepository, items ...Item) error {
+	if len(items) == 0 {
+		return nil
+	}
+	current, err := repository.ListWarehouses()
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		warehouseIndex, itemIndex, found := findItem(current, item.ItemId)
+		if !found {
+			continue
+		}
+		warehouse := current[warehouseIndex]
+		return &ConflictError{
+			WarehouseId:   warehouse.Id,
+			ItemId:        item.ItemId,
+			ActualVersion: warehouse.Items[itemIndex].Version,
+			Current:       warehouse,
+			Changes:       []string{fmt.Sprintf("item %d added", item.ItemId)},
+		}
+	}
+	return nil
+}
+
+// describeWarehouseChanges names the fields of the warehouse and the items
+// that differ between the two versions.
+func describeWarehouseChanges(before, after Warehouse) []string {
+	before.Version, after.Version = 0, 0
+	items := [2][]Item{before.Items, after.Items}
+	before.Items, after.Items = nil, nil
+
+	changes := describeFieldChanges(before, after)
+
+	seen := make(map[int]bool)
+	for _, list := range items {
+		for _, item := range list {
+			if seen[item.ItemId] {
+				continue
+			}
+			seen[item.ItemId] = true
+
+			_, i, inBefore := findItem([]Warehouse{{Items: items[0]}}, item.ItemId)
+			_, j, inAfter := findItem([]Warehouse{{Items: items[1]}}, item.ItemId)
+			switch {
+			case !inBefore:
+				changes = append(changes, fmt.Sprintf("item %d added", item.ItemId))
+			case !inAfter:
+				changes = append(changes, fmt.Sprintf("item %d removed", item.ItemId))
+			case len(describeFieldChanges(items[0][i], items[1][j])) > 0:
+				changes = append(changes, fmt.Sprintf("item %d changed", item.ItemId))
+			}
+		}
+	}
+	return changes
+}
+
+// describeFieldChanges names the exported fields, other than Version, whose
+// values differ between the two structs of the same type.
+func describeFieldChanges(before, after any) []string {
+	var changes []string
+	a, b := reflect.ValueOf(before), reflect.ValueOf(after)
+	for i := 0; i < a.NumField(); i++ {
+		field := a.Type().Field(i)
+		if !field.IsExported() || field.Name == "Version" {
+			continue
+		}
+		if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			changes = append(changes, field.Name)
+		}
+	}
+	return changes
+}
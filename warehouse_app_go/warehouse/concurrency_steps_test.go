@@ -0,0 +1,212 @@
+package warehouse
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initConcurrencySteps(ctx *godog.ScenarioContext) {
+	// GIVEN
+	ctx.Given(`^planner "([^"]*)" opens warehouse (\d+)$`, plannerOpensWarehouse)
+
+	// WHEN
+	ctx.When(`^planner "([^"]*)" sets the volume of warehouse (\d+) to (\d+\.?\d*)$`, plannerSetsTheVolumeOfWarehouse)
+	ctx.When(`^planner "([^"]*)" closes warehouse (\d+) every (\w+)$`, plannerClosesWarehouseEvery)
+	ctx.When(`^planner "([^"]*)" moves the end of item (\d+) to "([^"]*)"$`, plannerMovesTheEndOfItem)
+	ctx.When(`^I reserve volume (\d+\.?\d*) from "([^"]*)" to "([^"]*)" while a reservation of volume (\d+\.?\d*) is made$`, iReserveVolumeWhileAReservationIsMade)
+	ctx.When(`^(\d+) reservations of volume (\d+\.?\d*) from "([^"]*)" to "([^"]*)" are made at the same time$`, reservationsAreMadeAtTheSameTime)
+
+	// THEN
+	ctx.Then(`^warehouse (\d+) should be at version (\d+)$`, warehouseShouldBeAtVersion)
+	ctx.Then(`^item (\d+) should be at version (\d+)$`, itemShouldBeAtVersion)
+	ctx.Then(`^warehouse (\d+) should have total volume (\d+\.?\d*)$`, warehouseShouldHaveTotalVolume)
+	ctx.Then(`^warehouse (\d+) should be closed on (\d+) weekdays?$`, warehouseShouldBeClosedOnWeekdays)
+	ctx.Then(`^no day from "([^"]*)" to "([^"]*)" should be overbooked$`, noDayShouldBeOverbooked)
+	ctx.Then(`^every rejected reservation should report a conflict or missing space$`, everyRejectedReservationShouldReportAConflict)
+}
+
+// -------------------
+// GIVEN Steps (Arrange)
+// -------------------
+
+func plannerOpensWarehouse(ctx context.Context, planner string, warehouseId int) {
+	t := godog.T(ctx)
+
+	warehouse, err := tc.service.GetWarehouse(warehouseId)
+	require.NoError(t, err, "unexpected error")
+	if tc.planners == nil {
+		tc.planners = make(map[string]Warehouse)
+	}
+	tc.planners[planner] = warehouse
+}
+
+// -------------------
+// WHEN Steps (Act)
+// -------------------
+
+func plannerSetsTheVolumeOfWarehouse(_ context.Context, planner string, _ int, volume float64) {
+	warehouse := tc.planners[planner]
+	warehouse.MaxCapacity = ThreeDRoom{Height: volume, Width: 1, Length: 1}
+	tc.planners[planner], tc.concurrencyErr = tc.service.UpdateWarehouse(warehouse)
+}
+
+func plannerClosesWarehouseEvery(_ context.Context, planner string, _ int, weekday string) {
+	warehouse := tc.planners[planner]
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if day.String() == weekday {
+			warehouse.Calendar.ClosedWeekdays = append(warehouse.Calendar.ClosedWeekdays, day)
+		}
+	}
+	tc.planners[planner], tc.concurrencyErr = tc.service.UpdateWarehouse(warehouse)
+}
+
+func plannerMovesTheEndOfItem(ctx context.Context, planner string, itemId int, dateStr string) {
+	t := godog.T(ctx)
+
+	warehouse := tc.planners[planner]
+	_, itemIndex, found := findItem([]Warehouse{warehouse}, itemId)
+	require.True(t, found, "item %d not found", itemId)
+
+	item := warehouse.Items[itemIndex]
+	item.EndDate = parseDate(t, dateStr)
+	tc.concurrencyErr = tc.service.repository().UpdateItem(warehouse.Id, item)
+}
+
+func reservationsAreMadeAtTheSameTime(ctx context.Context, count int, volume float64, startStr, endStr string) {
+	t := godog.T(ctx)
+
+	item := Item{
+		ItemHeight: volume,
+		ItemWidth:  1,
+		ItemLength: 1,
+		StartDate:  parseDate(t, startStr),
+		EndDate:    parseDate(t, endStr),
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, count)
+	for i := range count {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = tc.service.Reserve(item)
+		}()
+	}
+	wg.Wait()
+	tc.reservationErrs = errs
+}
+
+// iReserveVolumeWhileAReservationIsMade makes the second reservation for the
+// same stay right after the first one read the warehouses.
+func iReserveVolumeWhileAReservationIsMade(ctx context.Context, volume float64, startStr, endStr string, otherVolume float64) {
+	t := godog.T(ctx)
+
+	repository := tc.service.repository()
+	other := WarehouseStorageService{Repository: repository, Now: tc.service.Now}
+	interleaving := &interleavingRepository{
+		WarehouseRepository: repository,
+		interleave: func() {
+			_, err := other.Reserve(Item{
+				ItemHeight: otherVolume,
+				ItemWidth:  1,
+				ItemLength: 1,
+				StartDate:  parseDate(t, startStr),
+				EndDate:    parseDate(t, endStr),
+			})
+			require.NoError(t, err, "unexpected error")
+		},
+	}
+
+	service := WarehouseStorageService{Repository: interleaving, Now: tc.service.Now}
+	_, tc.concurrencyErr = service.Reserve(Item{
+		ItemHeight: volume,
+		ItemWidth:  1,
+		ItemLength: 1,
+		StartDate:  parseDate(t, startStr),
+		EndDate:    parseDate(t, endStr),
+	})
+}
+
+// interleavingRepository runs interleave once, right after the first list of
+// the warehouses was read, as a writer working at the same time would.
+type interleavingRepository struct {
+	WarehouseRepository
+	interleave func()
+}
+
+func (r *interleavingRepository) ListWarehouses() ([]Warehouse, error) {
+	warehouses, err := r.WarehouseRepository.ListWarehouses()
+	if interleave := r.interleave; interleave != nil {
+		r.interleave = nil
+		interleave()
+	}
+	return warehouses, err
+}
+
+// -------------------
+// THEN Steps (Assert)
+// -------------------
+
+func warehouseShouldBeAtVersion(ctx context.Context, warehouseId, version int) {
+	t := godog.T(ctx)
+
+	warehouse, err := tc.service.GetWarehouse(warehouseId)
+	require.NoError(t, err, "unexpected error")
+	assert.Equal(t, version, warehouse.Version, "warehouse version mismatch")
+}
+
+func itemShouldBeAtVersion(ctx context.Context, itemId, version int) {
+	t := godog.T(ctx)
+
+	warehouses := tc.warehouses()
+	warehouseIndex, itemIndex, found := findItem(warehouses, itemId)
+	require.True(t, found, "item %d not found", itemId)
+	assert.Equal(t, version, warehouses[warehouseIndex].Items[itemIndex].Version, "item version mismatch")
+}
+
+func warehouseShouldHaveTotalVolume(ctx context.Context, warehouseId int, volume float64) {
+	t := godog.T(ctx)
+
+	warehouse, err := tc.service.GetWarehouse(warehouseId)
+	require.NoError(t, err, "unexpected error")
+	assert.Equal(t, volume, warehouse.MaxCapacity.GetVolume(), "total volume mismatch")
+}
+
+func warehouseShouldBeClosedOnWeekdays(ctx context.Context, warehouseId, count int) {
+	t := godog.T(ctx)
+
+	warehouse, err := tc.service.GetWarehouse(warehouseId)
+	require.NoError(t, err, "unexpected error")
+	assert.Len(t, warehouse.Calendar.ClosedWeekdays, count, "closed weekday count mismatch")
+}
+
+func noDayShouldBeOverbooked(ctx context.Context, startStr, endStr string) {
+	t := godog.T(ctx)
+
+	capacities, err := tc.service.CalculateAvailableCapacity(parseDate(t, startStr), parseDate(t, endStr))
+	require.NoError(t, err, "unexpected error")
+	for day, capacity := range capacities {
+		assert.GreaterOrEqual(t, capacity, 0.0, "overbooked on %s", day.Format("2006-01-02"))
+	}
+}
+
+func everyRejectedReservationShouldReportAConflict(ctx context.Context) {
+	t := godog.T(ctx)
+
+	for _, err := range tc.reservationErrs {
+		if err == nil {
+			continue
+		}
+		var conflict *ConflictError
+		assert.True(t,
+			errors.As(err, &conflict) || strings.Contains(err.Error(), "required volume cannot be accommodated"),
+			"unexpected error %q", err)
+	}
+}
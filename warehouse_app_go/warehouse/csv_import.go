@@ -0,0 +1,301 @@
+package warehouse
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const CSVDateFormat = "2006-01-02"
+
+var (
+	warehouseCSVColumns = []string{"id", "height", "width", "length"}
+	itemCSVColumns      = []string{"id", "name", "height", "width", "length", "start", "end", "active", "warehouse_id"}
+)
+
+// RowFailure explains why a line of an imported CSV file was rejected. Line 1
+// is the header.
+type RowFailure struct {
+	File string
+	Line int
+	Err  error
+}
+
+type ImportResult struct {
+	Warehouses int
+	Items      int
+	Failures   []RowFailure
+}
+
+// -------------------------------------------------
+// ImportCSV
+// -------------------------------------------------
+
+// ImportCSV creates the warehouses and items listed in the two CSV files. Both
+// start with a header naming the columns, in any order: id, height, width and
+// length for warehouses; id, name, height, width, length, start, end, active
+// and warehouse_id for items. Dates are written as 2006-01-02 and an empty
+// active column means active. Items are taken over as booked, without checking
+// the space left. Every invalid row is reported and nothing is imported unless
+// all rows are valid.
+func (service *WarehouseStorageService) ImportCSV(warehousesCSV, itemsCSV io.Reader) (ImportResult, error) {
+	var result ImportResult
+
+	existing, err := service.repository().ListWarehouses()
+	if err != nil {
+		return result, err
+	}
+
+	warehouses := parseWarehouseCSV(warehousesCSV, existing, &result.Failures)
+	items := parseItemCSV(itemsCSV, existing, warehouses, &result.Failures)
+
+	if len(result.Failures) > 0 {
+		return result, errors.New("the import contains invalid rows")
+	}
+
+	err = service.write(CSVImported, func(repository WarehouseRepository) error {
+		for _, warehouse := range warehouses {
+			if err := repository.SaveWarehouse(warehouse); err != nil {
+				return err
+			}
+		}
+		for _, item := range items {
+			if err := repository.AddItem(item.warehouseId, item.Item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.Warehouses = len(warehouses)
+	result.Items = len(items)
+	return result, nil
+}
+
+type importedItem struct {
+	Item
+	warehouseId int
+}
+
+func parseWarehouseCSV(r io.Reader, existing []Warehouse, failures *[]RowFailure) []Warehouse {
+	var warehouses []Warehouse
+	for _, row := range readCSV("warehouses", r, warehouseCSVColumns, failures) {
+		id := row.int("id")
+		room := ThreeDRoom{
+			Height: row.float("height"),
+			Width:  row.float("width"),
+			Length: row.float("length"),
+		}
+
+		if row.valid("id") {
+			if id <= 0 {
+				row.fail(errors.New("the id must be positive"))
+			} else if _, found := findWarehouse(existing, id); found {
+				row.fail(ErrWarehouseExists)
+			} else if _, found := findWarehouse(warehouses, id); found {
+				row.fail(errors.New("the warehouse id is listed more than once"))
+			}
+		}
+		if row.valid("height", "width", "length") && (room.Height <= 0 || room.Width <= 0 || room.Length <= 0) {
+			row.fail(ErrInvalidDimensions)
+		}
+
+		if !row.failed {
+			warehouses = append(warehouses, Warehouse{Id: id, MaxCapacity: room})
+		}
+	}
+	return warehouses
+}
+
+func parseItemCSV(r io.Reader, existing, imported []Warehouse, failures *[]RowFailure) []importedItem {
+	var items []importedItem
+	seen := make(map[int]bool)
+	for _, row := range readCSV("items", r, itemCSVColumns, failures) {
+		item := Item{
+			ItemId:     row.int("id"),
+			ItemName:   row.get("name"),
+			ItemHeight: row.float("height"),
+			ItemWidth:  row.float("width"),
+			ItemLength: row.float("length"),
+			StartDate:  row.date("start"),
+			EndDate:    row.date("end"),
+			IsActive:   row.bool("active", true),
+		}
+		warehouseId := row.int("warehouse_id")
+
+		if row.valid("id") {
+			if item.ItemId <= 0 {
+				row.fail(errors.New("the id must be positive"))
+			} else if _, _, found := findItem(existing, item.ItemId); found {
+				row.fail(ErrItemExists)
+			} else if seen[item.ItemId] {
+				row.fail(errors.New("the item id is listed more than once"))
+			}
+			seen[item.ItemId] = true
+		}
+		if row.valid("height", "width", "length") && (item.ItemHeight <= 0 || item.ItemWidth <= 0 || item.ItemLength <= 0) {
+			row.fail(ErrInvalidDimensions)
+		}
+		if row.valid("start", "end") && item.StartDate.After(item.EndDate) {
+			row.fail(ErrInvalidPeriod)
+		}
+		if row.valid("warehouse_id") {
+			_, knownExisting := findWarehouse(existing, warehouseId)
+			_, knownImported := findWarehouse(imported, warehouseId)
+			if !knownExisting && !knownImported {
+				row.fail(fmt.Errorf("unknown warehouse %d", warehouseId))
+			}
+		}
+
+		if !row.failed {
+			items = append(items, importedItem{Item: item, warehouseId: warehouseId})
+		}
+	}
+	return items
+}
+
+// csvRow is a data line of an imported file with its values by column.
+// Failures of the row are added to the failures of the import.
+type csvRow struct {
+	file     string
+	line     int
+	values   map[string]string
+	invalid  map[string]bool
+	failed   bool
+	failures *[]RowFailure
+}
+
+// readCSV reads the header and the data rows of the file. Missing columns
+// and lines that cannot be read are reported as failures.
+func readCSV(file string, r io.Reader, columns []string, failures *[]RowFailure) []*csvRow {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		err = errors.New("the file is empty")
+	}
+	if err != nil {
+		*failures = append(*failures, RowFailure{File: file, Line: 1, Err: err})
+		return nil
+	}
+
+	index := make(map[string]int)
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	missing := false
+	for _, column := range columns {
+		if _, found := index[column]; !found {
+			*failures = append(*failures, RowFailure{File: file, Line: 1, Err: fmt.Errorf("the column %q is missing", column)})
+			missing = true
+		}
+	}
+	if missing {
+		return nil
+	}
+
+	var rows []*csvRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			line := 0
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				line = parseErr.Line
+			}
+			*failures = append(*failures, RowFailure{File: file, Line: line, Err: err})
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := &csvRow{
+			file:     file,
+			line:     line,
+			values:   make(map[string]string),
+			invalid:  make(map[string]bool),
+			failures: failures,
+		}
+		for _, column := range columns {
+			if i := index[column]; i < len(record) {
+				row.values[column] = strings.TrimSpace(record[i])
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func (row *csvRow) fail(err error) {
+	row.failed = true
+	*row.failures = append(*row.failures, RowFailure{File: row.file, Line: row.line, Err: err})
+}
+
+// valid reports whether all columns could be parsed.
+func (row *csvRow) valid(columns ...string) bool {
+	for _, column := range columns {
+		if row.invalid[column] {
+			return false
+		}
+	}
+	return true
+}
+
+func (row *csvRow) get(column string) string {
+	return row.values[column]
+}
+
+func (row *csvRow) int(column string) int {
+	value, err := strconv.Atoi(row.get(column))
+	if err != nil {
+		row.invalid[column] = true
+		row.fail(fmt.Errorf("the %s %q is not a whole number", column, row.get(column)))
+	}
+	return value
+}
+
+func (row *csvRow) float(column string) float64 {
+	value, err := strconv.ParseFloat(row.get(column), 64)
+	if err != nil {
+		row.invalid[column] = true
+		row.fail(fmt.Errorf("the %s %q is not a number", column, row.get(column)))
+	} else if math.IsNaN(value) || math.IsInf(value, 0) {
+		row.invalid[column] = true
+		row.fail(fmt.Errorf("the %s %q is not a finite number", column, row.get(column)))
+	}
+	return value
+}
+
+func (row *csvRow) date(column string) time.Time {
+	value, err := time.Parse(CSVDateFormat, row.get(column))
+	if err != nil {
+		row.invalid[column] = true
+		row.fail(fmt.Errorf("the %s date %q is not a valid date", column, row.get(column)))
+	}
+	return value
+}
+
+func (row *csvRow) bool(column string, fallback bool) bool {
+	if row.get(column) == "" {
+		return fallback
+	}
+	value, err := strconv.ParseBool(row.get(column))
+	if err != nil {
+		row.invalid[column] = true
+		row.fail(fmt.Errorf("the %s value %q is not true or false", column, row.get(column)))
+	}
+	return value
+}
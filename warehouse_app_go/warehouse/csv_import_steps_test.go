@@ -0,0 +1,78 @@
+package warehouse
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func initCSVImportSteps(ctx *godog.ScenarioContext) {
+	// GIVEN
+	ctx.Given(`^the warehouse CSV:$`, theWarehouseCSV)
+	ctx.Given(`^the item CSV:$`, theItemCSV)
+
+	// WHEN
+	ctx.When(`^I import the CSV files$`, iImportTheCSVFiles)
+
+	// THEN
+	ctx.Then(`^(\d+) warehouses and (\d+) items should be imported$`, warehousesAndItemsShouldBeImported)
+	ctx.Then(`^the import failures should be:$`, theImportFailuresShouldBe)
+}
+
+// -------------------
+// GIVEN Steps (Arrange)
+// -------------------
+
+func theWarehouseCSV(_ context.Context, doc *godog.DocString) {
+	tc.warehouseCSV = doc.Content
+}
+
+func theItemCSV(_ context.Context, doc *godog.DocString) {
+	tc.itemCSV = doc.Content
+}
+
+// -------------------
+// WHEN Steps (Act)
+// -------------------
+
+func iImportTheCSVFiles(_ context.Context) {
+	tc.importResult, tc.importErr = tc.service.ImportCSV(
+		strings.NewReader(tc.warehouseCSV),
+		strings.NewReader(tc.itemCSV),
+	)
+}
+
+// -------------------
+// THEN Steps (Assert)
+// -------------------
+
+func warehousesAndItemsShouldBeImported(ctx context.Context, warehouses, items int) {
+	t := godog.T(ctx)
+
+	assert.NoError(t, tc.importErr, "unexpected error")
+	assert.Equal(t, warehouses, tc.importResult.Warehouses, "imported warehouse count mismatch")
+	assert.Equal(t, items, tc.importResult.Items, "imported item count mismatch")
+}
+
+func theImportFailuresShouldBe(ctx context.Context, table *godog.Table) {
+	t := godog.T(ctx)
+
+	if !assert.Len(t, tc.importResult.Failures, len(table.Rows)-1, "failure count mismatch") {
+		for _, failure := range tc.importResult.Failures {
+			t.Logf("%s line %d: %v", failure.File, failure.Line, failure.Err)
+		}
+		return
+	}
+
+	for i, row := range table.Rows[1:] {
+		line, _ := strconv.Atoi(row.Cells[1].Value)
+		failure := tc.importResult.Failures[i]
+
+		assert.Equal(t, row.Cells[0].Value, failure.File, "file mismatch at row %d", i)
+		assert.Equal(t, line, failure.Line, "line mismatch at row %d", i)
+		assert.EqualError(t, failure.Err, row.Cells[2].Value, "reason mismatch at row %d", i)
+	}
+}
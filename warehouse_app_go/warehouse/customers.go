@@ -0,0 +1,192 @@
+package warehouse
+
+import (
+	"math"
+	"time"
+)
+
+func (service WarehouseStorageService) findCustomer(customerId int) (*Customer, error) {
+	if customerId == 0 {
+		return nil, nil
+	}
+	customers := service.shared().Customers
+	for i := range customers {
+		if customers[i].Id == customerId {
+			return &customers[i], nil
+		}
+	}
+	return nil, ErrCustomerNotFound
+}
+
+// canPlace reports whether the item fits into the warehouse both in space and
+// within the quotas of its customer. The item with id replacing, if any, is
+// left out of the customer usage because the item takes over its space.
+func (service WarehouseStorageService) canPlace(warehouses []Warehouse, warehouse Warehouse, item Item, replacing int) bool {
+	return warehouse.canAccommodate(item, service.now()) && service.withinQuota(warehouses, warehouse, item, replacing)
+}
+
+func (service WarehouseStorageService) withinQuota(warehouses []Warehouse, warehouse Warehouse, item Item, replacing int) bool {
+	customer, err := service.findCustomer(item.CustomerId)
+	if customer == nil || err != nil {
+		return err == nil
+	}
+
+	warehouseQuota, hasWarehouseQuota := customer.WarehouseQuotas[warehouse.Id]
+	if customer.VolumeQuota <= 0 && !hasWarehouseQuota {
+		return true
+	}
+
+	now := service.now()
+	start, end := item.GetOccupiedPeriod(now)
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		total, inWarehouse := getCustomerVolumeOnDay(warehouses, customer.Id, warehouse.Id, day, now, replacing)
+		if customer.VolumeQuota > 0 && total+item.GetItemVolume() > customer.VolumeQuota {
+			return false
+		}
+		if hasWarehouseQuota && inWarehouse+item.GetItemVolume() > warehouseQuota {
+			return false
+		}
+	}
+	return true
+}
+
+// getCustomerVolumeOnDay returns the volume the customer stores on the day in
+// all warehouses and in the given warehouse.
+func getCustomerVolumeOnDay(
+	warehouses []Warehouse,
+	customerId, warehouseId int,
+	day, now time.Time,
+	excluded int,
+) (float64, float64) {
+
+	total, inWarehouse := 0.0, 0.0
+	for _, warehouse := range warehouses {
+		for _, item := range warehouse.Items {
+			start, end := item.GetOccupiedPeriod(now)
+			if item.CustomerId != customerId || item.ItemId == excluded || !item.IsActive || !withinDays(day, start, end) {
+				continue
+			}
+			total += item.GetItemVolume()
+			if warehouse.Id == warehouseId {
+				inWarehouse += item.GetItemVolume()
+			}
+		}
+	}
+	return total, inWarehouse
+}
+
+// -------------------------------------------------
+// FindAvailableWarehouseForCustomer
+// -------------------------------------------------
+func (service *WarehouseStorageService) FindAvailableWarehouseForCustomer(
+	customerId int,
+	startDate, endDate time.Time,
+	requiredHeight, requiredWidth, requiredLength float64,
+) (int, error) {
+
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return -1, err
+	}
+
+	if err := service.validateStay(warehouses, startDate, endDate, requiredHeight, requiredWidth, requiredLength); err != nil {
+		return -1, err
+	}
+
+	if _, err := service.findCustomer(customerId); err != nil {
+		return -1, err
+	}
+
+	return service.findAvailable(warehouses, Item{
+		ItemHeight: requiredHeight,
+		ItemWidth:  requiredWidth,
+		ItemLength: requiredLength,
+		StartDate:  startDate,
+		EndDate:    endDate,
+		IsActive:   true,
+		CustomerId: customerId,
+	})
+}
+
+// -------------------------------------------------
+// GetCustomerUsage
+// -------------------------------------------------
+func (service *WarehouseStorageService) GetCustomerUsage(
+	customerId int,
+	startDate, endDate time.Time,
+) (map[time.Time]float64, error) {
+
+	if _, err := service.findCustomer(customerId); err != nil || customerId == 0 {
+		return nil, ErrCustomerNotFound
+	}
+
+	if startDate.After(endDate) {
+		return nil, ErrInvalidPeriod
+	}
+
+	warehouses, err := service.repository().ListWarehousesOverlapping(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	usageMap := make(map[time.Time]float64)
+	for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		usageMap[day], _ = getCustomerVolumeOnDay(warehouses, customerId, 0, day, service.now(), 0)
+	}
+
+	return usageMap, nil
+}
+
+// -------------------------------------------------
+// CalculateAvailableCapacityForCustomer
+// -------------------------------------------------
+
+// CalculateAvailableCapacityForCustomer returns the volume the customer could
+// still store on each day: the free capacity of every warehouse, including the
+// unused part of the customer's capacity blocks, capped by the remaining
+// warehouse quota, and the total capped by the remaining global quota.
+func (service *WarehouseStorageService) CalculateAvailableCapacityForCustomer(
+	customerId int,
+	startDate, endDate time.Time,
+) (map[time.Time]float64, error) {
+
+	warehouses, err := service.repository().ListWarehousesOverlapping(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(warehouses) == 0 {
+		return nil, ErrNoWarehouses
+	}
+
+	customer, err := service.findCustomer(customerId)
+	if err != nil || customer == nil {
+		return nil, ErrCustomerNotFound
+	}
+
+	if startDate.After(endDate) {
+		return nil, ErrInvalidPeriod
+	}
+
+	now := service.now()
+	capacityMap := make(map[time.Time]float64)
+	for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		available := 0.0
+		for _, warehouse := range warehouses {
+			free := warehouse.GetCapacityOnDay(day) - warehouse.GetVolumeOccupiedOnDay(day, now)
+			free = math.Max(0, free+warehouse.getUnusedBlockVolume(customer.Id, day, now))
+			if quota, ok := customer.WarehouseQuotas[warehouse.Id]; ok {
+				_, inWarehouse := getCustomerVolumeOnDay(warehouses, customer.Id, warehouse.Id, day, now, 0)
+				free = math.Min(free, math.Max(0, quota-inWarehouse))
+			}
+			available += free
+		}
+		total, _ := getCustomerVolumeOnDay(warehouses, customer.Id, 0, day, now, 0)
+		if customer.VolumeQuota > 0 {
+			available = math.Min(available, math.Max(0, customer.VolumeQuota-total))
+		}
+		capacityMap[day] = available
+	}
+
+	return capacityMap, nil
+}
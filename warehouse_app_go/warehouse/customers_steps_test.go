@@ -0,0 +1,81 @@
+package warehouse
+
+import (
+	"context"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func initCustomerQuotaSteps(ctx *godog.ScenarioContext) {
+	// GIVEN
+	ctx.Given(`^customer (\d+) may store (\d+\.?\d*) in total$`, customerMayStoreInTotal)
+	ctx.Given(`^customer (\d+) may store (\d+\.?\d*) in warehouse (\d+)$`, customerMayStoreInWarehouse)
+	ctx.Given(`^item (\d+) belongs to customer (\d+)$`, itemBelongsToCustomer)
+
+	// WHEN
+	ctx.When(`^customer (\d+) looks for a warehouse from "([^"]*)" to "([^"]*)" with dimensions:$`, customerLooksForAWarehouse)
+	ctx.When(`^I calculate the available capacity for customer (\d+) from "([^"]*)" to "([^"]*)"$`, iCalculateTheAvailableCapacityForCustomer)
+
+	// THEN
+	ctx.Then(`^the usage of customer (\d+) from "([^"]*)" to "([^"]*)" should be:$`, theUsageOfCustomerShouldBe)
+}
+
+// -------------------
+// GIVEN Steps (Arrange)
+// -------------------
+
+func customerMayStoreInTotal(_ context.Context, customerId int, quota float64) {
+	tc.AddCustomer(Customer{Id: customerId, VolumeQuota: quota})
+}
+
+func customerMayStoreInWarehouse(_ context.Context, customerId int, quota float64, warehouseId int) {
+	if _, err := tc.service.findCustomer(customerId); err != nil {
+		tc.AddCustomer(Customer{Id: customerId})
+	}
+	tc.SetWarehouseQuota(customerId, warehouseId, quota)
+}
+
+func itemBelongsToCustomer(_ context.Context, itemId, customerId int) {
+	tc.SetItemCustomer(itemId, customerId)
+}
+
+// -------------------
+// WHEN Steps (Act)
+// -------------------
+
+func customerLooksForAWarehouse(ctx context.Context, customerId int, startStr, endStr string, table *godog.Table) {
+	t := godog.T(ctx)
+	dims := parseDimensionsTable(table)
+
+	tc.searchResult, tc.searchError = tc.service.FindAvailableWarehouseForCustomer(
+		customerId,
+		parseDate(t, startStr),
+		parseDate(t, endStr),
+		dims.Height,
+		dims.Width,
+		dims.Length,
+	)
+}
+
+func iCalculateTheAvailableCapacityForCustomer(ctx context.Context, customerId int, startStr, endStr string) {
+	t := godog.T(ctx)
+
+	tc.capacityMap, tc.calculateCapacityErr = tc.service.CalculateAvailableCapacityForCustomer(
+		customerId,
+		parseDate(t, startStr),
+		parseDate(t, endStr),
+	)
+}
+
+// -------------------
+// THEN Steps (Assert)
+// -------------------
+
+func theUsageOfCustomerShouldBe(ctx context.Context, customerId int, startStr, endStr string, table *godog.Table) {
+	t := godog.T(ctx)
+
+	usage, err := tc.service.GetCustomerUsage(customerId, parseDate(t, startStr), parseDate(t, endStr))
+	assert.NoError(t, err, "unexpected error")
+	assert.Equal(t, tableToTimeMap(t, table, 0, 1), usage, "customer usage mismatch")
+}
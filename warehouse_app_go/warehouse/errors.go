@@ -0,0 +1,50 @@
+package warehouse
+
+import "errors"
+
+// The errors below are returned by the service and its repositories for the
+// failures callers tell apart, such as the HTTP API choosing a status code.
+// Match them with errors.Is.
+var (
+	ErrWarehouseNotFound = errors.New("warehouse not found")
+	ErrItemNotFound      = errors.New("item not found")
+	ErrCustomerNotFound  = errors.New("customer not found")
+	ErrNoWarehouses      = errors.New("no warehouses available")
+	ErrTargetNotFound    = errors.New("target warehouse not found")
+	ErrBlockNotFound     = errors.New("capacity block not found")
+
+	ErrWarehouseExists = errors.New("a warehouse with this id already exists")
+	ErrItemExists      = errors.New("an item with this id already exists")
+	ErrItemNotActive   = errors.New("the item is not active")
+	ErrItemCheckedIn   = errors.New("the item has already been checked in")
+	ErrItemCheckedOut  = errors.New("the item has already been checked out")
+	ErrWarehouseInUse  = errors.New("the warehouse still holds active items")
+	ErrNoCapacity      = errors.New("required volume cannot be accommodated within the specified dates")
+	ErrQuotaExceeded   = errors.New("the customer quota would be exceeded")
+	ErrNoOpenWarehouse = errors.New("no warehouse is open for check-in and check-out on the specified dates")
+	ErrInTarget        = errors.New("the item is already stored in the target warehouse")
+	ErrTargetFull      = errors.New("the target warehouse cannot accommodate the item")
+	ErrStayStarted     = errors.New("the stay has already started")
+	ErrBlockNoCapacity = errors.New("the capacity block cannot be accommodated within the specified dates")
+	ErrNotCheckedIn    = errors.New("the item has not been checked in")
+	ErrOccurrenceFull  = errors.New("not all occurrences can be accommodated")
+
+	ErrInvalidDimensions   = errors.New("the 3D model has invalid dimensions (zero or negative)")
+	ErrInvalidStay         = errors.New("start date cannot be later than end date")
+	ErrInvalidPeriod       = errors.New("the start date cannot be later than the end date")
+	ErrStartInPast         = errors.New("start date cannot be in the past")
+	ErrTransferDate        = errors.New("the transfer date must fall after the start and on or before the end of the stay")
+	ErrTransferInPast      = errors.New("the transfer date cannot be in the past")
+	ErrBlockVolume         = errors.New("the block volume must be positive")
+	ErrCheckOutEarly       = errors.New("the check-out time cannot be earlier than the check-in time")
+	ErrActualInFuture      = errors.New("actual timestamps cannot be in the future")
+	ErrEndNotLater         = errors.New("the new end date must be later than the current end date")
+	ErrUnboundedRecurrence = errors.New("the recurrence must be bounded by a count or an until date")
+	ErrNegativeInterval    = errors.New("the recurrence interval cannot be negative")
+	ErrUnknownFrequency    = errors.New("unknown recurrence frequency")
+	ErrInvalidMonthDay     = errors.New("month days must be between 1 and 31")
+	ErrNoOccurrences       = errors.New("the recurrence does not produce the requested occurrences")
+
+	ErrNoActor  = errors.New("changes to audited data must name an actor")
+	ErrReadOnly = errors.New("the service only answers what was known in the past and cannot be changed")
+)
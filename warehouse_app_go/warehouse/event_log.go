@@ -0,0 +1,672 @@
+package warehouse
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+type EventType string
+
+const (
+	WarehouseCreated     EventType = "WarehouseCreated"
+	WarehouseSaved       EventType = "WarehouseSaved"
+	WarehouseRemoved     EventType = "WarehouseRemoved"
+	CapacityChanged      EventType = "CapacityChanged"
+	CapacityBlockAdded   EventType = "CapacityBlockAdded"
+	CapacityBlockRemoved EventType = "CapacityBlockRemoved"
+	ItemAdded            EventType = "ItemAdded"
+	ItemUpdated          EventType = "ItemUpdated"
+	ItemRemoved          EventType = "ItemRemoved"
+	ItemReserved         EventType = "ItemReserved"
+	ItemCancelled        EventType = "ItemCancelled"
+	ItemExtended         EventType = "ItemExtended"
+	ItemTransferred      EventType = "ItemTransferred"
+	ItemRelocated        EventType = "ItemRelocated"
+	ItemShifted          EventType = "ItemShifted"
+	ItemCheckedIn        EventType = "ItemCheckedIn"
+	ItemCheckedOut       EventType = "ItemCheckedOut"
+	BatchReserved        EventType = "BatchReserved"
+	RecurringReserved    EventType = "RecurringReserved"
+	ReservationPreempted EventType = "ReservationPreempted"
+	MovePlanApplied      EventType = "MovePlanApplied"
+	StateLoaded          EventType = "StateLoaded"
+	StateSaved           EventType = "StateSaved"
+	CustomersChanged     EventType = "CustomersChanged"
+	CSVImported          EventType = "CSVImported"
+	AuditRecorded        EventType = "AuditRecorded"
+)
+
+type ChangeKind string
+
+const (
+	SaveWarehouseChange   ChangeKind = "SaveWarehouse"
+	RemoveWarehouseChange ChangeKind = "RemoveWarehouse"
+	AddItemChange         ChangeKind = "AddItem"
+	UpdateItemChange      ChangeKind = "UpdateItem"
+	RemoveItemChange      ChangeKind = "RemoveItem"
+	SaveStateChange       ChangeKind = "SaveState"
+	AppendAuditChange     ChangeKind = "AppendAudit"
+)
+
+// Change is one write to the repository. Replaying the changes of all events
+// in order rebuilds the warehouses and the state of the service.
+type Change struct {
+	Kind        ChangeKind
+	WarehouseId int
+	ItemId      int
+	Warehouse   *Warehouse
+	Item        *Item
+	State       *ServiceState
+	Audit       []AuditEntry `json:",omitempty"`
+}
+
+// Event groups the changes one operation of the service made.
+type Event struct {
+	Sequence   int
+	Type       EventType
+	RecordedAt time.Time
+	Changes    []Change
+}
+
+// ChangeRecorder is implemented by repositories that keep the changes of one
+// operation together, such as the event log. Record runs apply against the
+// repository and keeps either all of its changes or none of them.
+type ChangeRecorder interface {
+	Record(eventType EventType, apply func(repository WarehouseRepository) error) error
+}
+
+func (c Change) applyTo(repository WarehouseRepository) error {
+	switch c.Kind {
+	case SaveWarehouseChange:
+		return repository.SaveWarehouse(*c.Warehouse)
+	case RemoveWarehouseChange:
+		return repository.RemoveWarehouse(c.WarehouseId)
+	case AddItemChange:
+		return repository.AddItem(c.WarehouseId, *c.Item)
+	case UpdateItemChange:
+		return repository.UpdateItem(c.WarehouseId, *c.Item)
+	case RemoveItemChange:
+		return repository.RemoveItem(c.WarehouseId, c.ItemId)
+	case SaveStateChange:
+		return saveState(repository, *c.State)
+	case AppendAuditChange:
+		return appendAudit(repository, c.Audit)
+	}
+	return errors.New("unknown change kind")
+}
+
+// recordingRepository passes every call on to the target repository and
+// remembers the writes that succeeded.
+type recordingRepository struct {
+	WarehouseRepository
+	changes []Change
+}
+
+func (r *recordingRepository) record(change Change) error {
+	if err := change.applyTo(r.WarehouseRepository); err != nil {
+		return err
+	}
+	r.changes = append(r.changes, change)
+	return nil
+}
+
+func (r *recordingRepository) SaveWarehouse(warehouse Warehouse) error {
+	saved := cloneWarehouse(warehouse)
+	return r.record(Change{Kind: SaveWarehouseChange, WarehouseId: warehouse.Id, Warehouse: &saved})
+}
+
+func (r *recordingRepository) RemoveWarehouse(warehouseId int) error {
+	return r.record(Change{Kind: RemoveWarehouseChange, WarehouseId: warehouseId})
+}
+
+func (r *recordingRepository) AddItem(warehouseId int, item Item) error {
+	added := cloneItem(item)
+	return r.record(Change{Kind: AddItemChange, WarehouseId: warehouseId, ItemId: item.ItemId, Item: &added})
+}
+
+func (r *recordingRepository) UpdateItem(warehouseId int, item Item) error {
+	updated := cloneItem(item)
+	return r.record(Change{Kind: UpdateItemChange, WarehouseId: warehouseId, ItemId: item.ItemId, Item: &updated})
+}
+
+func (r *recordingRepository) RemoveItem(warehouseId, itemId int) error {
+	return r.record(Change{Kind: RemoveItemChange, WarehouseId: warehouseId, ItemId: itemId})
+}
+
+func (r *recordingRepository) LoadState() (ServiceState, error) {
+	return loadState(r.WarehouseRepository)
+}
+
+func (r *recordingRepository) SaveState(state ServiceState) error {
+	saved := cloneState(state)
+	return r.record(Change{Kind: SaveStateChange, State: &saved})
+}
+
+func (r *recordingRepository) LoadAudit() ([]AuditEntry, error) {
+	return loadAudit(r.WarehouseRepository)
+}
+
+func (r *recordingRepository) AppendAudit(entries []AuditEntry) error {
+	return r.record(Change{Kind: AppendAuditChange, Audit: cloneAuditEntries(entries)})
+}
+
+// -------------------------------------------------
+// EventLog
+// -------------------------------------------------
+
+const (
+	eventSegmentPattern = "events-*.log"
+	eventSnapshotFile   = "snapshot.json"
+)
+
+// eventSnapshot is the state of the warehouses, customers, waitlist and
+// audit entries after the event with the given sequence number.
+type eventSnapshot struct {
+	Version    int
+	DateFormat string
+	Sequence   int
+	Warehouses []Warehouse
+	Customers  []Customer
+	Waitlist   []Item
+	Audit      []AuditEntry `json:",omitempty"`
+}
+
+// eventFile is the part of *os.File the event log writes through.
+type eventFile interface {
+	io.Writer
+	Sync() error
+	Truncate(size int64) error
+	Seek(offset int64, whence int) (int64, error)
+	Close() error
+}
+
+// EventLog is a repository that appends every change as an event to a log in
+// its directory and keeps the resulting warehouses, customers and waitlist in
+// memory. Events are synced to disk before they become visible. A snapshot of
+// the state is written every SnapshotEvery events and a new log segment is
+// started with it, so that opening the log only reads the events recorded
+// since. Older segments are kept as the history of the warehouses.
+type EventLog struct {
+	SnapshotEvery int
+	Now           func() time.Time
+
+	mu            sync.RWMutex
+	dir           string
+	file          eventFile
+	segmentStart  int
+	offset        int64
+	failed        error
+	memory        *InMemoryRepository
+	sequence      int
+	sinceSnapshot int
+}
+
+// OpenEventLog rebuilds the state from the snapshot and the log segments
+// written after it in dir, creating both when they do not exist yet. An event
+// that was only partially written when the process stopped is discarded.
+func OpenEventLog(dir string) (*EventLog, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	log := &EventLog{dir: dir, memory: NewInMemoryRepository(nil)}
+	if err := log.loadSnapshot(); err != nil {
+		return nil, err
+	}
+
+	segments, err := log.segments()
+	if err != nil {
+		return nil, err
+	}
+
+	// Replay from the segment holding the first event after the snapshot.
+	// It is usually the last one; rotating may have failed after a snapshot.
+	first := 0
+	for i, start := range segments {
+		if start <= log.sequence+1 {
+			first = i
+		}
+	}
+	if len(segments) == 0 {
+		segments = []int{log.sequence + 1}
+	}
+
+	for i := first; i < len(segments); i++ {
+		last := i == len(segments)-1
+		file, err := os.OpenFile(log.segmentPath(segments[i]), os.O_RDWR|os.O_CREATE, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		offset, err := log.replay(file, last)
+		if err != nil || !last {
+			file.Close()
+		}
+		if err != nil {
+			return nil, err
+		}
+		if last {
+			log.file, log.segmentStart, log.offset = file, segments[i], offset
+		}
+	}
+
+	if err := syncDir(dir); err != nil {
+		log.file.Close()
+		return nil, err
+	}
+	return log, nil
+}
+
+func (l *EventLog) loadSnapshot() error {
+	data, err := os.ReadFile(filepath.Join(l.dir, eventSnapshotFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var snapshot eventSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return errors.New("the snapshot is not valid: " + err.Error())
+	}
+	if !supportedDocumentVersion(snapshot.Version) || snapshot.DateFormat != DocumentDateFormat {
+		return errors.New("unsupported snapshot version")
+	}
+
+	l.memory = NewInMemoryRepository(snapshot.Warehouses)
+	l.memory.state = ServiceState{Customers: snapshot.Customers, Waitlist: snapshot.Waitlist}
+	l.memory.audit = snapshot.Audit
+	l.sequence = snapshot.Sequence
+	return nil
+}
+
+// segments returns the sequence number each log segment starts with, in
+// order.
+func (l *EventLog) segments() ([]int, error) {
+	paths, err := filepath.Glob(filepath.Join(l.dir, eventSegmentPattern))
+	if err != nil {
+		return nil, err
+	}
+
+	var starts []int
+	for _, path := range paths {
+		var start int
+		if _, err := fmt.Sscanf(filepath.Base(path), "events-%d.log", &start); err == nil {
+			starts = append(starts, start)
+		}
+	}
+	sort.Ints(starts)
+	return starts, nil
+}
+
+func (l *EventLog) segmentPath(start int) string {
+	return filepath.Join(l.dir, fmt.Sprintf("events-%010d.log", start))
+}
+
+// replay applies the events of the segment that come after the current
+// sequence and returns the offset after the last complete event. Events
+// already covered by the snapshot are skipped without being decoded. Only
+// the last segment may end with an event that was cut off.
+func (l *EventLog) replay(file *os.File, last bool) (int64, error) {
+	reader := bufio.NewReader(file)
+	offset := int64(0)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(bytes.TrimSpace(line)) > 0 {
+				if !last {
+					return 0, errors.New("the event log is corrupted: a segment ends with an incomplete event")
+				}
+				// The last event was cut off while it was written.
+				if err := file.Truncate(offset); err != nil {
+					return 0, err
+				}
+			}
+			_, err = file.Seek(offset, io.SeekStart)
+			return offset, err
+		}
+		if err != nil {
+			return 0, err
+		}
+		offset += int64(len(line))
+
+		var header struct{ Sequence int }
+		if err := json.Unmarshal(line, &header); err != nil {
+			return 0, errors.New("the event log is corrupted: " + err.Error())
+		}
+		if header.Sequence <= l.sequence {
+			continue
+		}
+
+		var event Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			return 0, errors.New("the event log is corrupted: " + err.Error())
+		}
+		for _, change := range event.Changes {
+			if err := change.applyTo(l.memory); err != nil {
+				return 0, err
+			}
+		}
+		l.sequence = event.Sequence
+		l.sinceSnapshot++
+	}
+}
+
+func (l *EventLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// Events returns every event recorded in the log, oldest first.
+func (l *EventLog) Events() ([]Event, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	segments, err := l.segments()
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	for _, start := range segments {
+		data, err := os.ReadFile(l.segmentPath(start))
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			var event Event
+			if err := json.Unmarshal(line, &event); err != nil {
+				return nil, errors.New("the event log is corrupted: " + err.Error())
+			}
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// ListWarehousesAsOf replays the events recorded up to knownAt. Snapshots
+// only cover the latest state, so the log is read from its start.
+func (l *EventLog) ListWarehousesAsOf(knownAt time.Time) ([]Warehouse, error) {
+	past, err := l.replayUntil(knownAt)
+	if err != nil {
+		return nil, err
+	}
+	return past.ListWarehouses()
+}
+
+// LoadStateAsOf replays the events recorded up to knownAt like
+// ListWarehousesAsOf and returns the customers and the waitlist.
+func (l *EventLog) LoadStateAsOf(knownAt time.Time) (ServiceState, error) {
+	past, err := l.replayUntil(knownAt)
+	if err != nil {
+		return ServiceState{}, err
+	}
+	return past.LoadState()
+}
+
+func (l *EventLog) replayUntil(knownAt time.Time) (*InMemoryRepository, error) {
+	events, err := l.Events()
+	if err != nil {
+		return nil, err
+	}
+
+	past := NewInMemoryRepository(nil)
+	for _, event := range events {
+		if event.RecordedAt.After(knownAt) {
+			break
+		}
+		for _, change := range event.Changes {
+			if err := change.applyTo(past); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return past, nil
+}
+
+// Record runs apply against a copy of the state and appends its changes as
+// one event. Nothing is recorded when apply fails or changes nothing. Once
+// the event is synced the change is kept even if the snapshot that may follow
+// cannot be written; the snapshot is tried again after the next event.
+func (l *EventLog) Record(eventType EventType, apply func(repository WarehouseRepository) error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.failed != nil {
+		return l.failed
+	}
+
+	candidate := l.memory.copy()
+	recorder := &recordingRepository{WarehouseRepository: candidate}
+	if err := apply(recorder); err != nil {
+		return err
+	}
+	if len(recorder.changes) == 0 {
+		return nil
+	}
+
+	event := Event{
+		Sequence:   l.sequence + 1,
+		Type:       eventType,
+		RecordedAt: l.now(),
+		Changes:    recorder.changes,
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if err := l.append(append(line, '\n')); err != nil {
+		return err
+	}
+
+	l.memory = candidate
+	l.sequence = event.Sequence
+	l.sinceSnapshot++
+	if l.SnapshotEvery > 0 && l.sinceSnapshot >= l.SnapshotEvery {
+		_ = l.snapshot()
+	}
+	return nil
+}
+
+// append writes the line at the end of the current segment and syncs it. A
+// line that could not be written or synced completely is cut off again, so
+// that the next event starts on a line of its own and the log never holds an
+// event the caller was told failed. When even that fails the log refuses
+// further events until it is reopened.
+func (l *EventLog) append(line []byte) error {
+	_, err := l.file.Write(line)
+	if err == nil {
+		err = l.file.Sync()
+	}
+	if err == nil {
+		l.offset += int64(len(line))
+		return nil
+	}
+
+	if rewindErr := l.rewind(); rewindErr != nil {
+		l.failed = errors.New("the event log must be reopened after a failed write: " + rewindErr.Error())
+	}
+	return err
+}
+
+func (l *EventLog) rewind() error {
+	if err := l.file.Truncate(l.offset); err != nil {
+		return err
+	}
+	if _, err := l.file.Seek(l.offset, io.SeekStart); err != nil {
+		return err
+	}
+	return l.file.Sync()
+}
+
+// Snapshot writes the current state to the snapshot file and starts a new
+// log segment.
+func (l *EventLog) Snapshot() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.snapshot()
+}
+
+func (l *EventLog) snapshot() error {
+	path := filepath.Join(l.dir, eventSnapshotFile)
+	file, err := os.CreateTemp(l.dir, eventSnapshotFile+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	err = json.NewEncoder(file).Encode(eventSnapshot{
+		Version:    DocumentVersion,
+		DateFormat: DocumentDateFormat,
+		Sequence:   l.sequence,
+		Warehouses: l.memory.warehouses,
+		Customers:  l.memory.state.Customers,
+		Waitlist:   l.memory.state.Waitlist,
+		Audit:      l.memory.audit,
+	})
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return err
+	}
+
+	l.sinceSnapshot = 0
+	if err := syncDir(l.dir); err != nil {
+		return err
+	}
+	return l.rotate()
+}
+
+// rotate starts a new segment for the events after the current sequence.
+// The current segment is kept when it does not hold any event yet.
+func (l *EventLog) rotate() error {
+	start := l.sequence + 1
+	if l.segmentStart == start {
+		return nil
+	}
+
+	file, err := os.OpenFile(l.segmentPath(start), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := syncDir(l.dir); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+
+	l.file.Close()
+	l.file, l.segmentStart, l.offset = file, start, 0
+	return nil
+}
+
+func (l *EventLog) now() time.Time {
+	if l.Now != nil {
+		return l.Now()
+	}
+	return time.Now().UTC()
+}
+
+func (l *EventLog) GetWarehouse(warehouseId int) (Warehouse, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.memory.GetWarehouse(warehouseId)
+}
+
+func (l *EventLog) ListWarehouses() ([]Warehouse, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.memory.ListWarehouses()
+}
+
+func (l *EventLog) ListWarehousesOverlapping(startDate, endDate time.Time) ([]Warehouse, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.memory.ListWarehousesOverlapping(startDate, endDate)
+}
+
+func (l *EventLog) LoadState() (ServiceState, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.memory.LoadState()
+}
+
+func (l *EventLog) SaveState(state ServiceState) error {
+	return l.Record(StateSaved, func(repository WarehouseRepository) error {
+		return saveState(repository, state)
+	})
+}
+
+func (l *EventLog) LoadAudit() ([]AuditEntry, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.memory.LoadAudit()
+}
+
+func (l *EventLog) AppendAudit(entries []AuditEntry) error {
+	return l.Record(AuditRecorded, func(repository WarehouseRepository) error {
+		return appendAudit(repository, entries)
+	})
+}
+
+func (l *EventLog) SaveWarehouse(warehouse Warehouse) error {
+	return l.Record(WarehouseSaved, func(repository WarehouseRepository) error {
+		return repository.SaveWarehouse(warehouse)
+	})
+}
+
+func (l *EventLog) RemoveWarehouse(warehouseId int) error {
+	return l.Record(WarehouseRemoved, func(repository WarehouseRepository) error {
+		return repository.RemoveWarehouse(warehouseId)
+	})
+}
+
+func (l *EventLog) AddItem(warehouseId int, item Item) error {
+	return l.Record(ItemAdded, func(repository WarehouseRepository) error {
+		return repository.AddItem(warehouseId, item)
+	})
+}
+
+func (l *EventLog) UpdateItem(warehouseId int, item Item) error {
+	return l.Record(ItemUpdated, func(repository WarehouseRepository) error {
+		return repository.UpdateItem(warehouseId, item)
+	})
+}
+
+func (l *EventLog) RemoveItem(warehouseId, itemId int) error {
+	return l.Record(ItemRemoved, func(repository WarehouseRepository) error {
+		return repository.RemoveItem(warehouseId, itemId)
+	})
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
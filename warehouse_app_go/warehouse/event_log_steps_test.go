@@ -0,0 +1,212 @@
+package warehouse
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initEventLogSteps(ctx *godog.ScenarioContext) {
+	ctx.After(func(ctx context.Context, sc *godog.Scenario, err error) (context.Context, error) {
+		if tc.eventLog != nil {
+			tc.eventLog.Close()
+		}
+		if tc.eventLogDir != "" {
+			os.RemoveAll(tc.eventLogDir)
+		}
+		return ctx, nil
+	})
+
+	// GIVEN
+	ctx.Given(`^the warehouses are stored in an event log$`, theWarehousesAreStoredInAnEventLog)
+	ctx.Given(`^the event log takes a snapshot every (\d+) events$`, theEventLogTakesASnapshotEvery)
+
+	// WHEN
+	ctx.When(`^I add warehouse (\d+) with total volume (\d+\.?\d*)$`, iAddWarehouseWithTotalVolume)
+	ctx.When(`^I cancel item (\d+)$`, iCancelItem)
+	ctx.When(`^I extend item (\d+) to "([^"]*)"$`, iExtendItemTo)
+	ctx.When(`^I reduce the capacity of warehouse (\d+) by (\d+\.?\d*) from "([^"]*)" to "([^"]*)"$`, iReduceTheCapacityOfWarehouse)
+	ctx.When(`^the last event is only partially written$`, theLastEventIsOnlyPartiallyWritten)
+	ctx.When(`^the disk fails while the next event is (written|synced)$`, theDiskFailsWhileTheNextEventIs)
+	ctx.When(`^the first log segment is damaged$`, theFirstLogSegmentIsDamaged)
+	ctx.When(`^I register customer (\d+)$`, iRegisterCustomer)
+	ctx.When(`^I reopen the event log$`, iReopenTheEventLog)
+
+	// THEN
+	ctx.Then(`^the recorded events should be:$`, theRecordedEventsShouldBe)
+	ctx.Then(`^the snapshot should cover (\d+) events$`, theSnapshotShouldCoverEvents)
+	ctx.Then(`^the event log should have (\d+) segments?$`, theEventLogShouldHaveSegments)
+}
+
+// -------------------
+// GIVEN Steps (Arrange)
+// -------------------
+
+func theWarehousesAreStoredInAnEventLog(ctx context.Context) {
+	t := godog.T(ctx)
+
+	dir, err := os.MkdirTemp("", "events")
+	require.NoError(t, err, "unexpected error")
+	tc.eventLogDir = dir
+
+	tc.eventLog, err = OpenEventLog(dir)
+	require.NoError(t, err, "unexpected error")
+	tc.eventLog.Now = scenarioClock
+	tc.service.Repository = tc.eventLog
+}
+
+// scenarioClock records changes at the current date of the scenario.
+func scenarioClock() time.Time {
+	return tc.service.now()
+}
+
+func theEventLogTakesASnapshotEvery(_ context.Context, count int) {
+	tc.eventLog.SnapshotEvery = count
+}
+
+// -------------------
+// WHEN Steps (Act)
+// -------------------
+
+func iAddWarehouseWithTotalVolume(_ context.Context, warehouseId int, volume float64) {
+	_, tc.eventLogErr = tc.service.AddWarehouse(Warehouse{
+		Id:          warehouseId,
+		MaxCapacity: ThreeDRoom{Height: volume, Width: 1, Length: 1},
+	})
+}
+
+func iCancelItem(_ context.Context, itemId int) {
+	tc.eventLogErr = tc.service.CancelReservation(itemId)
+}
+
+func iExtendItemTo(ctx context.Context, itemId int, dateStr string) {
+	t := godog.T(ctx)
+	tc.eventLogErr = tc.service.ExtendReservation(itemId, parseDate(t, dateStr))
+}
+
+func iReduceTheCapacityOfWarehouse(ctx context.Context, warehouseId int, volume float64, startStr, endStr string) {
+	t := godog.T(ctx)
+	tc.eventLogErr = tc.service.ChangeCapacity(warehouseId, CapacityChange{
+		EffectiveFrom:    parseDate(t, startStr),
+		EffectiveTo:      parseDate(t, endStr),
+		VolumeAdjustment: -volume,
+	})
+}
+
+func theLastEventIsOnlyPartiallyWritten(ctx context.Context) {
+	t := godog.T(ctx)
+
+	path := tc.eventLog.segmentPath(tc.eventLog.segmentStart)
+	info, err := os.Stat(path)
+	require.NoError(t, err, "unexpected error")
+	require.NoError(t, os.Truncate(path, info.Size()-10), "unexpected error")
+}
+
+func theDiskFailsWhileTheNextEventIs(_ context.Context, stage string) {
+	tc.eventLog.file = &failingEventFile{
+		eventFile: tc.eventLog.file,
+		failWrite: stage == "written",
+		failSync:  stage == "synced",
+	}
+}
+
+func theFirstLogSegmentIsDamaged(ctx context.Context) {
+	t := godog.T(ctx)
+
+	segments, err := tc.eventLog.segments()
+	require.NoError(t, err, "unexpected error")
+	path := tc.eventLog.segmentPath(segments[0])
+	require.NoError(t, os.WriteFile(path, []byte("not an event\n"), 0o644), "unexpected error")
+}
+
+func iRegisterCustomer(_ context.Context, customerId int) {
+	customers := append(cloneSlice(tc.service.Customers), Customer{Id: customerId})
+	tc.eventLogErr = tc.service.SetCustomers(customers)
+}
+
+func iReopenTheEventLog(ctx context.Context) {
+	t := godog.T(ctx)
+
+	require.NoError(t, tc.eventLog.Close(), "unexpected error")
+	tc.eventLog, tc.eventLogErr = OpenEventLog(tc.eventLogDir)
+	require.NoError(t, tc.eventLogErr, "unexpected error")
+	tc.eventLog.Now = scenarioClock
+
+	service, err := NewWarehouseStorageService(tc.eventLog)
+	require.NoError(t, err, "unexpected error")
+	service.Now = tc.service.shared().Now
+	tc.service = *service
+}
+
+// failingEventFile stops the next write halfway or fails the next sync after
+// the write went through, as a full or failing disk would.
+type failingEventFile struct {
+	eventFile
+	failWrite bool
+	failSync  bool
+}
+
+func (f *failingEventFile) Write(p []byte) (int, error) {
+	if f.failWrite {
+		f.failWrite = false
+		n, _ := f.eventFile.Write(p[:len(p)/2])
+		return n, errors.New("no space left on device")
+	}
+	return f.eventFile.Write(p)
+}
+
+func (f *failingEventFile) Sync() error {
+	if f.failSync {
+		f.failSync = false
+		return errors.New("input/output error")
+	}
+	return f.eventFile.Sync()
+}
+
+// -------------------
+// THEN Steps (Assert)
+// -------------------
+
+func theRecordedEventsShouldBe(ctx context.Context, table *godog.Table) {
+	t := godog.T(ctx)
+
+	events, err := tc.eventLog.Events()
+	require.NoError(t, err, "unexpected error")
+	if !assert.Len(t, events, len(table.Rows)-1, "event count mismatch") {
+		return
+	}
+
+	for i, row := range table.Rows[1:] {
+		sequence, _ := strconv.Atoi(row.Cells[0].Value)
+
+		assert.Equal(t, sequence, events[i].Sequence, "sequence mismatch at row %d", i)
+		assert.Equal(t, EventType(row.Cells[1].Value), events[i].Type, "event type mismatch at row %d", i)
+	}
+}
+
+func theSnapshotShouldCoverEvents(ctx context.Context, count int) {
+	t := godog.T(ctx)
+
+	data, err := os.ReadFile(filepath.Join(tc.eventLogDir, eventSnapshotFile))
+	require.NoError(t, err, "unexpected error")
+
+	var snapshot eventSnapshot
+	require.NoError(t, json.Unmarshal(data, &snapshot), "unexpected error")
+	assert.Equal(t, count, snapshot.Sequence, "snapshot sequence mismatch")
+}
+
+func theEventLogShouldHaveSegments(ctx context.Context, count int) {
+	t := godog.T(ctx)
+
+	segments, err := tc.eventLog.segments()
+	require.NoError(t, err, "unexpected error")
+	assert.Len(t, segments, count, "segment count mismatch")
+}
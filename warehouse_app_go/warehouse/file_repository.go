@@ -0,0 +1,150 @@
+package warehouse
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileRepository keeps the warehouses in a JSON document on disk, in the
+// format written by Save. Reads are served from memory; every change is
+// written to the file before it becomes visible. The customers and the
+// waitlist of the document are kept as the state of the service, next to
+// its audit entries.
+type FileRepository struct {
+	mu     sync.RWMutex
+	path   string
+	memory *InMemoryRepository
+}
+
+// OpenFileRepository reads the warehouses from the document at path. A
+// missing file starts an empty repository that is created on the first change.
+func OpenFileRepository(path string) (*FileRepository, error) {
+	repository := &FileRepository{path: path, memory: NewInMemoryRepository(nil)}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return repository, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	document, err := readDocument(file)
+	if err != nil {
+		return nil, err
+	}
+	repository.memory = NewInMemoryRepository(document.Warehouses)
+	repository.memory.state = ServiceState{Customers: document.Customers, Waitlist: document.Waitlist}
+	repository.memory.audit = document.Audit
+	return repository, nil
+}
+
+func (r *FileRepository) GetWarehouse(warehouseId int) (Warehouse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.memory.GetWarehouse(warehouseId)
+}
+
+func (r *FileRepository) ListWarehouses() ([]Warehouse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.memory.ListWarehouses()
+}
+
+func (r *FileRepository) ListWarehousesOverlapping(startDate, endDate time.Time) ([]Warehouse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.memory.ListWarehousesOverlapping(startDate, endDate)
+}
+
+func (r *FileRepository) SaveWarehouse(warehouse Warehouse) error {
+	return r.update(func(memory *InMemoryRepository) error {
+		return memory.SaveWarehouse(warehouse)
+	})
+}
+
+func (r *FileRepository) RemoveWarehouse(warehouseId int) error {
+	return r.update(func(memory *InMemoryRepository) error {
+		return memory.RemoveWarehouse(warehouseId)
+	})
+}
+
+func (r *FileRepository) AddItem(warehouseId int, item Item) error {
+	return r.update(func(memory *InMemoryRepository) error {
+		return memory.AddItem(warehouseId, item)
+	})
+}
+
+func (r *FileRepository) UpdateItem(warehouseId int, item Item) error {
+	return r.update(func(memory *InMemoryRepository) error {
+		return memory.UpdateItem(warehouseId, item)
+	})
+}
+
+func (r *FileRepository) RemoveItem(warehouseId, itemId int) error {
+	return r.update(func(memory *InMemoryRepository) error {
+		return memory.RemoveItem(warehouseId, itemId)
+	})
+}
+
+func (r *FileRepository) LoadState() (ServiceState, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.memory.LoadState()
+}
+
+func (r *FileRepository) SaveState(state ServiceState) error {
+	return r.update(func(memory *InMemoryRepository) error {
+		return memory.SaveState(state)
+	})
+}
+
+func (r *FileRepository) LoadAudit() ([]AuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.memory.LoadAudit()
+}
+
+func (r *FileRepository) AppendAudit(entries []AuditEntry) error {
+	return r.update(func(memory *InMemoryRepository) error {
+		return memory.AppendAudit(entries)
+	})
+}
+
+// Record applies all writes of apply to the file at once.
+func (r *FileRepository) Record(_ EventType, apply func(repository WarehouseRepository) error) error {
+	return r.update(func(memory *InMemoryRepository) error {
+		return apply(memory)
+	})
+}
+
+// update applies the change to a copy of the warehouses and the state and
+// only keeps it once the file has been written.
+func (r *FileRepository) update(change func(memory *InMemoryRepository) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	candidate := r.memory.copy()
+	if err := change(candidate); err != nil {
+		return err
+	}
+
+	err := writeDocumentFile(r.path, Document{
+		Version:    DocumentVersion,
+		DateFormat: DocumentDateFormat,
+		Warehouses: candidate.warehouses,
+		Customers:  candidate.state.Customers,
+		Waitlist:   candidate.state.Waitlist,
+		Audit:      candidate.audit,
+	})
+	if err != nil {
+		return err
+	}
+
+	r.memory = candidate
+	return nil
+}
@@ -2,6 +2,7 @@ package warehouse
 
 import (
 	"context"
+	"time"
 
 	"github.com/cucumber/godog"
 	"github.com/stretchr/testify/assert"
@@ -30,6 +31,7 @@ func initFindAvailableWarehouseSteps(ctx *godog.ScenarioContext) {
 func todayIs(ctx context.Context, dateStr string) {
 	t := godog.T(ctx)
 	tc.currentDate = parseDate(t, dateStr)
+	tc.service.shared().Now = func() time.Time { return tc.currentDate }
 }
 
 func iHaveWarehouseWithVolume(ctx context.Context, count int, volume float64) {
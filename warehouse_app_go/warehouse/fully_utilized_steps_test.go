@@ -51,10 +51,12 @@ func iCallGetFullyUtilizedDatesFromTo(_ context.Context, startStr, endStr string
 // THEN Step (Assert)
 // ----------------------------------------------------------------
 
-func theFullyUtilizedDatesShouldBe(_ context.Context, table *godog.Table) error {
-	assert.NoError(tc.t, tc.fullyUtilizedDatesErr, "unexpected error getting fully utilized dates")
+func theFullyUtilizedDatesShouldBe(ctx context.Context, table *godog.Table) error {
+	t := godog.T(ctx)
 
-	expectedDates := tableToDateSlice(tc.t, table)
-	compareDates(tc.t, expectedDates, tc.fullyUtilizedDatesResult)
+	assert.NoError(t, tc.fullyUtilizedDatesErr, "unexpected error getting fully utilized dates")
+
+	expectedDates := tableToDateSlice(t, table)
+	compareDates(t, expectedDates, tc.fullyUtilizedDatesResult)
 	return nil
 }
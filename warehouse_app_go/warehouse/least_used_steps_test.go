@@ -45,9 +45,11 @@ func iCallGetLeastUsedWarehouse(_ context.Context, startStr, endStr string) erro
 // THEN Steps (Assert)
 // ------------------------------------------------------------------
 
-func theLeastUsedWarehouseShouldBe(_ context.Context, expectedID int) error {
-	assert.NoError(tc.t, tc.leastUsedWarehouseErr)
-	assert.Equal(tc.t, expectedID, tc.leastUsedWarehouseResult)
+func theLeastUsedWarehouseShouldBe(ctx context.Context, expectedID int) error {
+	t := godog.T(ctx)
+
+	assert.NoError(t, tc.leastUsedWarehouseErr)
+	assert.Equal(t, expectedID, tc.leastUsedWarehouseResult)
 	return nil
 }
 
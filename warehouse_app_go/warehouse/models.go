@@ -8,6 +8,40 @@ type ThreeDRoom struct {
 	Length float64
 }
 
+// Buffer is the number of days before and after a stay during which the
+// space is needed to receive and dispatch goods.
+type Buffer struct {
+	Before int
+	After  int
+}
+
+// DateRange is an inclusive range of days.
+type DateRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// OperatingCalendar describes when a warehouse can receive and dispatch goods.
+// A zero CommissionedOn or DecommissionedOn leaves that end of the lifetime
+// open; the warehouse is out of service from DecommissionedOn onwards.
+type OperatingCalendar struct {
+	CommissionedOn   time.Time
+	DecommissionedOn time.Time
+	ClosedWeekdays   []time.Weekday
+	Closures         []DateRange
+}
+
+// CapacityChange adjusts the capacity of a warehouse from EffectiveFrom until
+// EffectiveTo (inclusive, zero for open-ended). Room replaces the dimensions of
+// the warehouse while the change is effective, VolumeAdjustment is added on
+// top and is negative for space that is temporarily lost.
+type CapacityChange struct {
+	EffectiveFrom    time.Time
+	EffectiveTo      time.Time
+	Room             *ThreeDRoom
+	VolumeAdjustment float64
+}
+
 type Item struct {
 	ItemId     int
 	ItemName   string
@@ -17,10 +51,57 @@ type Item struct {
 	StartDate  time.Time
 	EndDate    time.Time
 	IsActive   bool
+	SeriesId   int
+
+	ShipmentId     int
+	PreviousItemId int
+
+	CheckedInAt  time.Time
+	CheckedOutAt time.Time
+
+	Buffer *Buffer
+
+	Priority int
+
+	CustomerId int
+
+	// Version is advanced by the repository on every stored change of the
+	// item; updates must carry the version they were based on.
+	Version int
+}
+
+// Customer owns items. VolumeQuota caps the volume the customer may store on
+// any day across all warehouses and WarehouseQuotas caps it per warehouse id;
+// a zero VolumeQuota or a missing warehouse entry means no limit.
+type Customer struct {
+	Id              int
+	Name            string
+	VolumeQuota     float64
+	WarehouseQuotas map[int]float64
+}
+
+// CapacityBlock is volume of a warehouse set aside for one customer over a
+// contract period. It is occupied for everybody else whether or not the
+// customer uses it.
+type CapacityBlock struct {
+	Id         int
+	CustomerId int
+	Volume     float64
+	StartDate  time.Time
+	EndDate    time.Time
 }
 
 type Warehouse struct {
 	Id          int
 	MaxCapacity ThreeDRoom
 	Items       []Item
+	Buffer      Buffer
+	Calendar    OperatingCalendar
+
+	CapacitySchedule []CapacityChange
+	Blocks           []CapacityBlock
+
+	// Version is advanced by the repository on every stored change of the
+	// warehouse or its items; saves must carry the version they were based on.
+	Version int
 }
@@ -0,0 +1,287 @@
+package warehouse
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+type AssignmentObjective int
+
+const (
+	MaximizeVolume AssignmentObjective = iota
+	MaximizeValue
+)
+
+// maxLocalSearchRounds bounds the improvement passes over rejected requests.
+const maxLocalSearchRounds = 10
+
+// StorageRequest is a pending request for storage. Requests with a higher
+// Priority are always considered before requests with a lower one; Value is
+// only used by the MaximizeValue objective.
+type StorageRequest struct {
+	RequestId  int
+	Dimensions ThreeDRoom
+	StartDate  time.Time
+	EndDate    time.Time
+	Priority   int
+	Value      float64
+}
+
+type RequestAssignment struct {
+	RequestId   int
+	WarehouseId int
+}
+
+type RejectedRequest struct {
+	RequestId int
+	Err       error
+}
+
+type AssignmentPlan struct {
+	Assignments    []RequestAssignment
+	Rejected       []RejectedRequest
+	AcceptedVolume float64
+	AcceptedValue  float64
+}
+
+type assignmentState struct {
+	service    WarehouseStorageService
+	warehouses []Warehouse
+	requests   []StorageRequest
+	objective  AssignmentObjective
+	assignedTo map[int]int
+	rejected   map[int]error
+}
+
+// -------------------------------------------------
+// OptimizeAssignment
+// -------------------------------------------------
+
+// OptimizeAssignment assigns the requests to warehouses so that the accepted
+// volume-days (or value) is as high as possible. Requests are placed with a
+// sorted best-fit and rejected requests are then retried by relocating or
+// replacing already assigned requests. The service itself is not changed.
+func (service *WarehouseStorageService) OptimizeAssignment(
+	requests []StorageRequest,
+	objective AssignmentObjective,
+) (AssignmentPlan, error) {
+
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return AssignmentPlan{}, err
+	}
+
+	if len(warehouses) == 0 {
+		return AssignmentPlan{}, ErrNoWarehouses
+	}
+
+	state := &assignmentState{
+		service:    *service,
+		warehouses: warehouses,
+		requests:   requests,
+		objective:  objective,
+		assignedTo: make(map[int]int),
+		rejected:   make(map[int]error),
+	}
+
+	order := state.sortedRequests()
+	for _, index := range order {
+		request := requests[index]
+		if err := state.service.validateStay(state.warehouses, request.StartDate, request.EndDate,
+			request.Dimensions.Height, request.Dimensions.Width, request.Dimensions.Length); err != nil {
+			state.rejected[index] = err
+			continue
+		}
+		if !state.placeBestFit(index, -1) {
+			state.rejected[index] = state.service.unavailableError(state.warehouses, state.item(index))
+		}
+	}
+
+	state.improve(order)
+	return state.plan(), nil
+}
+
+func (state *assignmentState) sortedRequests() []int {
+	order := make([]int, len(state.requests))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := state.requests[order[i]], state.requests[order[j]]
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		return state.score(order[i]) > state.score(order[j])
+	})
+	return order
+}
+
+func (state *assignmentState) score(index int) float64 {
+	if state.objective == MaximizeValue {
+		return state.requests[index].Value
+	}
+	return state.volumeDays(index)
+}
+
+func (state *assignmentState) volumeDays(index int) float64 {
+	request := state.requests[index]
+	return request.Dimensions.GetVolume() * float64(daysBetween(request.StartDate, request.EndDate)+1)
+}
+
+// item turns the request into a probe item. Negative ids keep probes apart
+// from the items already stored in the warehouses.
+func (state *assignmentState) item(index int) Item {
+	request := state.requests[index]
+	return Item{
+		ItemId:     -(index + 1),
+		ItemHeight: request.Dimensions.Height,
+		ItemWidth:  request.Dimensions.Width,
+		ItemLength: request.Dimensions.Length,
+		StartDate:  request.StartDate,
+		EndDate:    request.EndDate,
+		IsActive:   true,
+	}
+}
+
+// placeBestFit stores the request in the warehouse that is left with the
+// smallest free margin over the stay, skipping the excluded warehouse.
+func (state *assignmentState) placeBestFit(index, excluded int) bool {
+	item := state.item(index)
+	best := -1
+	bestMargin := 0.0
+	for i, warehouse := range state.warehouses {
+		if i == excluded || !warehouse.canAccommodate(item, state.service.now()) {
+			continue
+		}
+		duration := daysBetween(item.StartDate, item.EndDate) + 1
+		margin := warehouse.getStayMargins(item.GetItemVolume(), duration, item.StartDate, item.StartDate, state.service.now())[0]
+		if best == -1 || margin < bestMargin {
+			best, bestMargin = i, margin
+		}
+	}
+
+	if best == -1 {
+		return false
+	}
+	state.place(index, best)
+	return true
+}
+
+func (state *assignmentState) place(index, warehouseIndex int) {
+	warehouse := &state.warehouses[warehouseIndex]
+	warehouse.Items = append(warehouse.Items, state.item(index))
+	state.assignedTo[index] = warehouseIndex
+	delete(state.rejected, index)
+}
+
+func (state *assignmentState) remove(index int) int {
+	warehouseIndex := state.assignedTo[index]
+	warehouse := &state.warehouses[warehouseIndex]
+	itemId := state.item(index).ItemId
+	for i, item := range warehouse.Items {
+		if item.ItemId == itemId {
+			warehouse.Items = append(warehouse.Items[:i:i], warehouse.Items[i+1:]...)
+			break
+		}
+	}
+	delete(state.assignedTo, index)
+	return warehouseIndex
+}
+
+// improve retries rejected requests until a full round brings no gain.
+func (state *assignmentState) improve(order []int) {
+	for round := 0; round < maxLocalSearchRounds; round++ {
+		improved := false
+		for _, index := range order {
+			if _, isRejected := state.rejected[index]; !isRejected || state.isInvalid(index) {
+				continue
+			}
+			if state.relocateToFit(index) || state.replaceToFit(index) {
+				improved = true
+			}
+		}
+		if !improved {
+			return
+		}
+	}
+}
+
+func (state *assignmentState) isInvalid(index int) bool {
+	request := state.requests[index]
+	return state.service.validateStay(state.warehouses, request.StartDate, request.EndDate,
+		request.Dimensions.Height, request.Dimensions.Width, request.Dimensions.Length) != nil
+}
+
+// relocateToFit moves one assigned request to another warehouse when that
+// frees enough room for the rejected request.
+func (state *assignmentState) relocateToFit(index int) bool {
+	for _, other := range state.overlappingAssignments(index) {
+		warehouseIndex := state.remove(other)
+		if state.warehouses[warehouseIndex].canAccommodate(state.item(index), state.service.now()) &&
+			state.placeBestFit(other, warehouseIndex) {
+			state.place(index, warehouseIndex)
+			return true
+		}
+		state.place(other, warehouseIndex)
+	}
+	return false
+}
+
+// replaceToFit gives the space of an assigned request with a lower score and
+// priority to the rejected request. The replaced request is placed elsewhere
+// when possible.
+func (state *assignmentState) replaceToFit(index int) bool {
+	request := state.requests[index]
+	for _, other := range state.overlappingAssignments(index) {
+		if state.requests[other].Priority > request.Priority || state.score(other) >= state.score(index) {
+			continue
+		}
+		warehouseIndex := state.remove(other)
+		if !state.warehouses[warehouseIndex].canAccommodate(state.item(index), state.service.now()) {
+			state.place(other, warehouseIndex)
+			continue
+		}
+		state.place(index, warehouseIndex)
+		if !state.placeBestFit(other, -1) {
+			state.rejected[other] = errors.New("replaced by a request of higher value")
+		}
+		return true
+	}
+	return false
+}
+
+func (state *assignmentState) overlappingAssignments(index int) []int {
+	request := state.requests[index]
+	var overlapping []int
+	for other := range state.assignedTo {
+		candidate := state.requests[other]
+		if !candidate.EndDate.Before(request.StartDate) && !candidate.StartDate.After(request.EndDate) {
+			overlapping = append(overlapping, other)
+		}
+	}
+	sort.Slice(overlapping, func(i, j int) bool {
+		if state.score(overlapping[i]) != state.score(overlapping[j]) {
+			return state.score(overlapping[i]) < state.score(overlapping[j])
+		}
+		return overlapping[i] < overlapping[j]
+	})
+	return overlapping
+}
+
+func (state *assignmentState) plan() AssignmentPlan {
+	plan := AssignmentPlan{}
+	for index, request := range state.requests {
+		if warehouseIndex, assigned := state.assignedTo[index]; assigned {
+			plan.Assignments = append(plan.Assignments, RequestAssignment{
+				RequestId:   request.RequestId,
+				WarehouseId: state.warehouses[warehouseIndex].Id,
+			})
+			plan.AcceptedVolume += state.volumeDays(index)
+			plan.AcceptedValue += request.Value
+			continue
+		}
+		plan.Rejected = append(plan.Rejected, RejectedRequest{RequestId: request.RequestId, Err: state.rejected[index]})
+	}
+	return plan
+}
@@ -0,0 +1,106 @@
+package warehouse
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func initOptimizeAssignmentSteps(ctx *godog.ScenarioContext) {
+	// GIVEN
+	ctx.Given(`^the pending storage requests:$`, thePendingStorageRequests)
+
+	// WHEN
+	ctx.When(`^I optimize the assignment for (volume|value)$`, iOptimizeTheAssignmentFor)
+
+	// THEN
+	ctx.Then(`^the requests should be assigned as:$`, theRequestsShouldBeAssignedAs)
+	ctx.Then(`^the rejected requests should be:$`, theRejectedRequestsShouldBe)
+	ctx.Then(`^the accepted volume should be (\d+\.?\d*)$`, theAcceptedVolumeShouldBe)
+}
+
+// -------------------
+// GIVEN Steps (Arrange)
+// -------------------
+
+func thePendingStorageRequests(ctx context.Context, table *godog.Table) {
+	t := godog.T(ctx)
+
+	columns := make(map[string]int)
+	for i, header := range table.Rows[0].Cells {
+		columns[header.Value] = i
+	}
+
+	for _, row := range table.Rows[1:] {
+		request := StorageRequest{
+			Dimensions: ThreeDRoom{Height: parseFloat(row.Cells[columns["volume"]].Value), Width: 1, Length: 1},
+			StartDate:  parseDate(t, row.Cells[columns["start"]].Value),
+			EndDate:    parseDate(t, row.Cells[columns["end"]].Value),
+		}
+		request.RequestId, _ = strconv.Atoi(row.Cells[columns["id"]].Value)
+		if column, ok := columns["priority"]; ok {
+			request.Priority, _ = strconv.Atoi(row.Cells[column].Value)
+		}
+		if column, ok := columns["value"]; ok {
+			request.Value = parseFloat(row.Cells[column].Value)
+		}
+		tc.storageRequests = append(tc.storageRequests, request)
+	}
+}
+
+// -------------------
+// WHEN Step (Act)
+// -------------------
+
+func iOptimizeTheAssignmentFor(ctx context.Context, objective string) {
+	t := godog.T(ctx)
+
+	assignmentObjective := MaximizeVolume
+	if objective == "value" {
+		assignmentObjective = MaximizeValue
+	}
+
+	var err error
+	tc.assignmentPlan, err = tc.service.OptimizeAssignment(tc.storageRequests, assignmentObjective)
+	assert.NoError(t, err, "unexpected error")
+}
+
+// -------------------
+// THEN Steps (Assert)
+// -------------------
+
+func theRequestsShouldBeAssignedAs(ctx context.Context, table *godog.Table) {
+	t := godog.T(ctx)
+
+	var expected []RequestAssignment
+	for _, row := range table.Rows[1:] {
+		requestId, _ := strconv.Atoi(row.Cells[0].Value)
+		warehouseId, _ := strconv.Atoi(row.Cells[1].Value)
+		expected = append(expected, RequestAssignment{RequestId: requestId, WarehouseId: warehouseId})
+	}
+
+	assert.Equal(t, expected, tc.assignmentPlan.Assignments, "assignments mismatch")
+}
+
+func theRejectedRequestsShouldBe(ctx context.Context, table *godog.Table) {
+	t := godog.T(ctx)
+
+	if !assert.Len(t, tc.assignmentPlan.Rejected, len(table.Rows)-1, "rejected request count mismatch") {
+		return
+	}
+
+	for i, row := range table.Rows[1:] {
+		requestId, _ := strconv.Atoi(row.Cells[0].Value)
+		rejected := tc.assignmentPlan.Rejected[i]
+
+		assert.Equal(t, requestId, rejected.RequestId, "request mismatch at row %d", i)
+		assert.EqualError(t, rejected.Err, row.Cells[1].Value, "reason mismatch at row %d", i)
+	}
+}
+
+func theAcceptedVolumeShouldBe(ctx context.Context, volume float64) {
+	t := godog.T(ctx)
+	assert.Equal(t, volume, tc.assignmentPlan.AcceptedVolume, "accepted volume mismatch")
+}
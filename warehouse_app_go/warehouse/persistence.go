@@ -0,0 +1,205 @@
+package warehouse
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DocumentVersion 2 added the versions of warehouses and items, 3 the audit
+// entries. Older documents are still read; versions of version 1 documents
+// start at 1.
+const (
+	DocumentVersion    = 3
+	DocumentDateFormat = time.RFC3339
+)
+
+// Document is the JSON representation of the full service state. Dates and
+// timestamps are written in DocumentDateFormat; days are midnight UTC.
+type Document struct {
+	Version    int
+	DateFormat string
+	Warehouses []Warehouse
+	Customers  []Customer
+	Waitlist   []Item
+	Audit      []AuditEntry `json:",omitempty"`
+}
+
+// -------------------------------------------------
+// Save
+// -------------------------------------------------
+func (service *WarehouseStorageService) Save(w io.Writer) error {
+	document, err := service.document()
+	if err != nil {
+		return err
+	}
+	return writeDocument(w, document)
+}
+
+// SaveFile writes the document to a temporary file next to path and renames
+// it into place once it is synced, so that a crash never leaves a partially
+// written document behind.
+func (service *WarehouseStorageService) SaveFile(path string) error {
+	document, err := service.document()
+	if err != nil {
+		return err
+	}
+	return writeDocumentFile(path, document)
+}
+
+func (service *WarehouseStorageService) document() (Document, error) {
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return Document{}, err
+	}
+	shared := service.shared()
+
+	var audit []AuditEntry
+	if shared.Audit != nil {
+		audit = shared.Audit.Query(AuditQuery{})
+	}
+
+	return Document{
+		Version:    DocumentVersion,
+		DateFormat: DocumentDateFormat,
+		Warehouses: warehouses,
+		Customers:  shared.Customers,
+		Waitlist:   shared.Waitlist,
+		Audit:      audit,
+	}, nil
+}
+
+func writeDocument(w io.Writer, document Document) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
+
+func writeDocumentFile(path string, document Document) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if err := writeDocument(file, document); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// -------------------------------------------------
+// Load
+// -------------------------------------------------
+
+// Load replaces the warehouses, customers and waitlist of the service with the
+// ones in the document. A service that keeps no audit log takes over the audit
+// entries of the document; one that does keeps its own. The service is left
+// untouched when the document cannot be read.
+func (service *WarehouseStorageService) Load(r io.Reader) error {
+	document, err := readDocument(r)
+	if err != nil {
+		return err
+	}
+
+	shared := service.shared()
+	var audit []AuditEntry
+	if shared.Audit == nil {
+		audit = cloneAuditEntries(document.Audit)
+	}
+
+	state := ServiceState{Customers: document.Customers, Waitlist: document.Waitlist}
+	if err := service.replaceState(document.Warehouses, state, audit); err != nil {
+		return err
+	}
+	shared.Customers = document.Customers
+	shared.Waitlist = document.Waitlist
+	if len(audit) > 0 {
+		shared.Audit = &AuditLog{entries: audit}
+	}
+	return nil
+}
+
+func (service *WarehouseStorageService) replaceWarehouses(warehouses []Warehouse) error {
+	shared := service.shared()
+	return service.replaceState(warehouses, ServiceState{Customers: shared.Customers, Waitlist: shared.Waitlist}, nil)
+}
+
+// replaceState swaps the stored warehouses and state for the given ones and
+// stores the audit entries in one operation.
+func (service *WarehouseStorageService) replaceState(warehouses []Warehouse, state ServiceState, audit []AuditEntry) error {
+	return service.write(StateLoaded, func(repository WarehouseRepository) error {
+		if err := saveState(repository, state); err != nil {
+			return err
+		}
+		if len(audit) > 0 {
+			if err := appendAudit(repository, audit); err != nil {
+				return err
+			}
+		}
+
+		existing, err := repository.ListWarehouses()
+		if err != nil {
+			return err
+		}
+
+		for _, warehouse := range existing {
+			if err := repository.RemoveWarehouse(warehouse.Id); err != nil {
+				return err
+			}
+		}
+		for _, warehouse := range warehouses {
+			if err := repository.SaveWarehouse(warehouse); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func readDocument(r io.Reader) (Document, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	var document Document
+	if err := decoder.Decode(&document); err != nil {
+		return Document{}, errors.New("the document is not valid: " + err.Error())
+	}
+
+	if !supportedDocumentVersion(document.Version) {
+		return Document{}, errors.New("unsupported document version")
+	}
+
+	if document.DateFormat != DocumentDateFormat {
+		return Document{}, errors.New("unsupported date format")
+	}
+
+	return document, nil
+}
+
+func supportedDocumentVersion(version int) bool {
+	return version >= 1 && version <= DocumentVersion
+}
+
+func (service *WarehouseStorageService) LoadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return service.Load(file)
+}
@@ -0,0 +1,55 @@
+package warehouse
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func initPersistenceSteps(ctx *godog.ScenarioContext) {
+	// WHEN
+	ctx.When(`^I save the service state and load it into a new service$`, iSaveAndLoadTheServiceState)
+	ctx.When(`^I load the document:$`, iLoadTheDocument)
+
+	// THEN
+	ctx.Then(`^the loaded state should match the saved state$`, theLoadedStateShouldMatchTheSavedState)
+}
+
+// -------------------
+// WHEN Steps (Act)
+// -------------------
+
+func iSaveAndLoadTheServiceState(ctx context.Context) {
+	t := godog.T(ctx)
+
+	var document bytes.Buffer
+	assert.NoError(t, tc.service.Save(&document), "unexpected error")
+
+	tc.savedService = tc.service
+	tc.service = WarehouseStorageService{Now: tc.savedService.shared().Now}
+	tc.persistenceErr = tc.service.Load(&document)
+}
+
+func iLoadTheDocument(_ context.Context, document *godog.DocString) {
+	tc.persistenceErr = tc.service.Load(strings.NewReader(document.Content))
+}
+
+// -------------------
+// THEN Steps (Assert)
+// -------------------
+
+func theLoadedStateShouldMatchTheSavedState(ctx context.Context) {
+	t := godog.T(ctx)
+
+	assert.NoError(t, tc.persistenceErr, "unexpected error")
+	saved, err := tc.savedService.repository().ListWarehouses()
+	assert.NoError(t, err, "unexpected error")
+	loaded, err := tc.service.repository().ListWarehouses()
+	assert.NoError(t, err, "unexpected error")
+	assert.Equal(t, saved, loaded, "warehouses mismatch")
+	assert.Equal(t, tc.savedService.Customers, tc.service.Customers, "customers mismatch")
+	assert.Equal(t, tc.savedService.Waitlist, tc.service.Waitlist, "waitlist mismatch")
+}
@@ -0,0 +1,160 @@
+package warehouse
+
+import (
+	"errors"
+	"sort"
+)
+
+// DisplacedItem is a lower-priority reservation that had to make room. It
+// was either moved to ToWarehouseId or put on the waitlist.
+type DisplacedItem struct {
+	Item            Item
+	FromWarehouseId int
+	ToWarehouseId   int
+	Waitlisted      bool
+}
+
+type PreemptionReport struct {
+	WarehouseId int
+	ItemId      int
+	Displaced   []DisplacedItem
+}
+
+// -------------------------------------------------
+// ReserveWithPreemption
+// -------------------------------------------------
+
+// ReserveWithPreemption reserves the item like Reserve and, when there is no
+// room, bumps upcoming reservations with a lower priority from the warehouse
+// where the least volume has to be displaced. Bumped reservations are moved
+// to another warehouse when possible and put on the waitlist otherwise.
+func (service *WarehouseStorageService) ReserveWithPreemption(item Item) (PreemptionReport, error) {
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return PreemptionReport{}, err
+	}
+
+	if err := service.validateStay(warehouses, item.StartDate, item.EndDate, item.ItemHeight, item.ItemWidth, item.ItemLength); err != nil {
+		return PreemptionReport{}, err
+	}
+
+	if _, err := service.findCustomer(item.CustomerId); err != nil {
+		return PreemptionReport{}, err
+	}
+
+	if _, _, exists := findItem(warehouses, item.ItemId); item.ItemId != 0 && exists {
+		return PreemptionReport{}, ErrItemExists
+	}
+
+	if service.findWarehouseIndex(warehouses, item) != -1 {
+		warehouseId, stored, err := service.reserve(item)
+		return PreemptionReport{WarehouseId: warehouseId, ItemId: stored.ItemId}, err
+	}
+
+	if item.ItemId == 0 {
+		item.ItemId = nextItemId(warehouses)
+	}
+
+	warehouseIndex, bumped := service.findPreemption(warehouses, item)
+	if warehouseIndex == -1 {
+		return PreemptionReport{}, errors.New("required volume cannot be accommodated even by preempting lower-priority reservations")
+	}
+
+	after := cloneWarehouses(warehouses)
+	warehouse := &after[warehouseIndex]
+	warehouse.Items = removeItems(warehouse.Items, bumped)
+	item.IsActive = true
+	warehouse.Items = append(warehouse.Items, item)
+
+	waitlist := cloneSlice(service.shared().Waitlist)
+	report := PreemptionReport{WarehouseId: warehouse.Id, ItemId: item.ItemId}
+	for _, displaced := range bumped {
+		outcome := DisplacedItem{Item: displaced, FromWarehouseId: warehouse.Id}
+		if index := service.findWarehouseIndex(after, displaced); index != -1 {
+			target := &after[index]
+			target.Items = append(target.Items, displaced)
+			outcome.ToWarehouseId = target.Id
+		} else {
+			waitlist = append(waitlist, displaced)
+			outcome.Waitlisted = true
+		}
+		report.Displaced = append(report.Displaced, outcome)
+	}
+
+	if err := service.saveChanged(ReservationPreempted, warehouses, after, waitlist); err != nil {
+		return PreemptionReport{}, err
+	}
+	return report, nil
+}
+
+// findPreemption returns the warehouse where the item fits after bumping the
+// least volume of upcoming lower-priority reservations, and the reservations
+// to bump. Reservations with the lowest priority are bumped first.
+func (service *WarehouseStorageService) findPreemption(warehouses []Warehouse, item Item) (int, []Item) {
+	today := service.now()
+	bestIndex := -1
+	var bestBumped []Item
+	bestVolume := 0.0
+
+	for i, warehouse := range warehouses {
+		if !warehouse.canOperate(item, today) || !service.withinQuota(warehouses, warehouse, item, 0) {
+			continue
+		}
+
+		itemStart, itemEnd := warehouse.GetBufferedPeriod(item, today)
+		var candidates []Item
+		for _, other := range warehouse.Items {
+			otherStart, otherEnd := warehouse.GetBufferedPeriod(other, today)
+			overlaps := !otherEnd.Before(itemStart) && !otherStart.After(itemEnd)
+			upcoming := other.CheckedInAt.IsZero() && !other.StartDate.Before(today)
+			if other.IsActive && overlaps && upcoming && other.Priority < item.Priority {
+				candidates = append(candidates, other)
+			}
+		}
+		sort.SliceStable(candidates, func(a, b int) bool {
+			if candidates[a].Priority != candidates[b].Priority {
+				return candidates[a].Priority < candidates[b].Priority
+			}
+			return candidates[a].GetItemVolume() > candidates[b].GetItemVolume()
+		})
+
+		trial := warehouse
+		trial.Items = append([]Item(nil), warehouse.Items...)
+		var bumped []Item
+		volume := 0.0
+		for _, candidate := range candidates {
+			if trial.canAccommodate(item, today) {
+				break
+			}
+			trial.Items = removeItems(trial.Items, []Item{candidate})
+			bumped = append(bumped, candidate)
+			volume += candidate.GetItemVolume()
+		}
+
+		if !trial.canAccommodate(item, today) {
+			continue
+		}
+		if bestIndex == -1 || volume < bestVolume {
+			bestIndex, bestBumped, bestVolume = i, bumped, volume
+		}
+	}
+
+	return bestIndex, bestBumped
+}
+
+func removeItems(items []Item, removed []Item) []Item {
+	var kept []Item
+	for _, item := range items {
+		drop := false
+		for _, other := range removed {
+			if other.ItemId == item.ItemId {
+				drop = true
+				break
+			}
+		}
+		if !drop {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,88 @@
+package warehouse
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func initPreemptionSteps(ctx *godog.ScenarioContext) {
+	// GIVEN
+	ctx.Given(`^item (\d+) has priority (\d+)$`, itemHasPriority)
+
+	// WHEN
+	ctx.When(`^I reserve with priority (\d+) from "([^"]*)" to "([^"]*)" with dimensions:$`, iReserveWithPriority)
+
+	// THEN
+	ctx.Then(`^the reservation should be placed in warehouse (\d+)$`, theReservationShouldBePlacedInWarehouse)
+	ctx.Then(`^the displaced items should be:$`, theDisplacedItemsShouldBe)
+	ctx.Then(`^the waitlist should hold (\d+) items?$`, theWaitlistShouldHoldItems)
+}
+
+// -------------------
+// GIVEN Steps (Arrange)
+// -------------------
+
+func itemHasPriority(_ context.Context, itemId, priority int) {
+	tc.SetItemPriority(itemId, priority)
+}
+
+// -------------------
+// WHEN Step (Act)
+// -------------------
+
+func iReserveWithPriority(ctx context.Context, priority int, startStr, endStr string, table *godog.Table) {
+	t := godog.T(ctx)
+	dims := parseDimensionsTable(table)
+
+	tc.preemptionReport, tc.preemptionErr = tc.service.ReserveWithPreemption(Item{
+		ItemName:   "Priority",
+		ItemHeight: dims.Height,
+		ItemWidth:  dims.Width,
+		ItemLength: dims.Length,
+		StartDate:  parseDate(t, startStr),
+		EndDate:    parseDate(t, endStr),
+		Priority:   priority,
+	})
+}
+
+// -------------------
+// THEN Steps (Assert)
+// -------------------
+
+func theReservationShouldBePlacedInWarehouse(ctx context.Context, warehouseId int) {
+	t := godog.T(ctx)
+
+	assert.NoError(t, tc.preemptionErr, "unexpected error")
+	assert.Equal(t, warehouseId, tc.preemptionReport.WarehouseId, "warehouse ID mismatch")
+}
+
+func theDisplacedItemsShouldBe(ctx context.Context, table *godog.Table) {
+	t := godog.T(ctx)
+
+	displaced := tc.preemptionReport.Displaced
+	if !assert.Len(t, displaced, len(table.Rows)-1, "displaced item count mismatch") {
+		return
+	}
+
+	for i, row := range table.Rows[1:] {
+		itemId, _ := strconv.Atoi(row.Cells[0].Value)
+		fromId, _ := strconv.Atoi(row.Cells[1].Value)
+
+		assert.Equal(t, itemId, displaced[i].Item.ItemId, "item mismatch at row %d", i)
+		assert.Equal(t, fromId, displaced[i].FromWarehouseId, "source warehouse mismatch at row %d", i)
+		if row.Cells[2].Value == "waitlist" {
+			assert.True(t, displaced[i].Waitlisted, "item should be waitlisted at row %d", i)
+			continue
+		}
+		toId, _ := strconv.Atoi(row.Cells[2].Value)
+		assert.Equal(t, toId, displaced[i].ToWarehouseId, "target warehouse mismatch at row %d", i)
+	}
+}
+
+func theWaitlistShouldHoldItems(ctx context.Context, count int) {
+	t := godog.T(ctx)
+	assert.Len(t, tc.service.Waitlist, count, "waitlist size mismatch")
+}
@@ -0,0 +1,356 @@
+package warehouse
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+type MoveKind int
+
+const (
+	RelocateMove MoveKind = iota
+	TransferMove
+	ShiftMove
+)
+
+// ItemMove is a single change proposed by the rebalancing planner. Relocate
+// moves a whole upcoming stay to ToWarehouseId, Transfer moves the rest of a
+// running stay on TransferDate and Shift moves an upcoming stay by ShiftDays.
+type ItemMove struct {
+	Kind            MoveKind
+	ItemId          int
+	FromWarehouseId int
+	ToWarehouseId   int
+	TransferDate    time.Time
+	ShiftDays       int
+	Cost            float64
+}
+
+// UtilizationSummary counts the days on which all warehouses together are
+// full, as GetFullyUtilizedDates reports them, the warehouse-days on which a
+// single warehouse is full and the highest share of capacity in use on any
+// warehouse-day. Moving items between warehouses can only lower the last two.
+type UtilizationSummary struct {
+	FullyUtilizedDays int
+	FullWarehouseDays int
+	PeakUtilization   float64
+}
+
+type MovePlan struct {
+	Moves  []ItemMove
+	Cost   float64
+	Before UtilizationSummary
+	After  UtilizationSummary
+}
+
+// RebalanceOptions prices the moves. Moving goods between warehouses costs
+// MoveCostPerVolume for each unit of volume; shifting a stay costs
+// ShiftCostPerVolumeDay for each unit of volume and day it is shifted.
+type RebalanceOptions struct {
+	MaxShiftDays          int
+	MoveCostPerVolume     float64
+	ShiftCostPerVolumeDay float64
+	MaxPlans              int
+}
+
+// -------------------------------------------------
+// PlanRebalancing
+// -------------------------------------------------
+
+// PlanRebalancing proposes moves that lower the number of fully utilized days,
+// full warehouse-days or the peak utilization between startDate and endDate.
+// Every improving single move is returned as a plan, together with a combined
+// plan built by applying the best move repeatedly. Plans are ranked by the
+// fully utilized days they remove, then by the full warehouse-days, then by
+// the peak they remove, then by cost.
+func (service *WarehouseStorageService) PlanRebalancing(
+	startDate, endDate time.Time,
+	options RebalanceOptions,
+) ([]MovePlan, error) {
+
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(warehouses) == 0 {
+		return nil, ErrNoWarehouses
+	}
+
+	if startDate.After(endDate) {
+		return nil, ErrInvalidPeriod
+	}
+
+	before := getUtilizationSummary(warehouses, startDate, endDate, service.now())
+
+	var plans []MovePlan
+	for _, move := range service.candidateMoves(warehouses, startDate, endDate, options) {
+		after, ok := service.evaluateMoves(warehouses, []ItemMove{move}, startDate, endDate)
+		if ok && improves(before, after) {
+			plans = append(plans, MovePlan{Moves: []ItemMove{move}, Cost: move.Cost, Before: before, After: after})
+		}
+	}
+	rankMovePlans(plans)
+
+	if combined, ok := service.combineMoves(warehouses, startDate, endDate, options, before); ok {
+		plans = append(plans, combined)
+		rankMovePlans(plans)
+	}
+
+	if options.MaxPlans > 0 && len(plans) > options.MaxPlans {
+		plans = plans[:options.MaxPlans]
+	}
+	return plans, nil
+}
+
+// -------------------------------------------------
+// ApplyMovePlan
+// -------------------------------------------------
+
+// ApplyMovePlan carries out all moves of the plan or, when one of them is no
+// longer possible, none of them.
+func (service *WarehouseStorageService) ApplyMovePlan(plan MovePlan) error {
+	if len(plan.Moves) == 0 {
+		return errors.New("the plan does not contain any moves")
+	}
+
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return err
+	}
+
+	candidate := service.workingCopy(warehouses)
+	for _, move := range plan.Moves {
+		if err := candidate.applyMove(move); err != nil {
+			return err
+		}
+	}
+
+	return service.commit(MovePlanApplied, warehouses, candidate)
+}
+
+func (service *WarehouseStorageService) applyMove(move ItemMove) error {
+	switch move.Kind {
+	case RelocateMove:
+		return service.RelocateItem(move.ItemId, move.ToWarehouseId)
+	case TransferMove:
+		_, err := service.TransferItem(move.ItemId, move.ToWarehouseId, move.TransferDate)
+		return err
+	case ShiftMove:
+		return service.ShiftItem(move.ItemId, move.ShiftDays)
+	}
+	return errors.New("unknown move kind")
+}
+
+func getUtilizationSummary(warehouses []Warehouse, startDate, endDate, now time.Time) UtilizationSummary {
+	summary := UtilizationSummary{}
+	for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		totalCapacity := 0.0
+		totalVolume := 0.0
+		for _, warehouse := range warehouses {
+			capacity := warehouse.GetCapacityOnDay(day)
+			volume := warehouse.GetVolumeOccupiedOnDay(day, now)
+			totalCapacity += capacity
+			totalVolume += volume
+			if capacity <= 0 {
+				continue
+			}
+			utilization := volume / capacity
+			if utilization >= 1 {
+				summary.FullWarehouseDays++
+			}
+			if utilization > summary.PeakUtilization {
+				summary.PeakUtilization = utilization
+			}
+		}
+		// Same rule as GetFullyUtilizedDates.
+		if totalVolume >= totalCapacity {
+			summary.FullyUtilizedDays++
+		}
+	}
+	return summary
+}
+
+// candidateMoves lists moves for every item that takes up space on a day on
+// which its warehouse is full or at the peak utilization of the range.
+func (service *WarehouseStorageService) candidateMoves(
+	warehouses []Warehouse,
+	startDate, endDate time.Time,
+	options RebalanceOptions,
+) []ItemMove {
+
+	today := service.now()
+	peak := getUtilizationSummary(warehouses, startDate, endDate, today).PeakUtilization
+
+	var moves []ItemMove
+	for _, warehouse := range warehouses {
+		for _, item := range warehouse.Items {
+			if !item.IsActive {
+				continue
+			}
+			hotDay, isHot := warehouse.firstHotDay(item, startDate, endDate, today, peak)
+			if !isHot {
+				continue
+			}
+
+			volume := item.GetItemVolume()
+			upcoming := item.CheckedInAt.IsZero() && !item.StartDate.Before(today)
+			for _, target := range warehouses {
+				if target.Id == warehouse.Id {
+					continue
+				}
+				move := ItemMove{
+					ItemId:          item.ItemId,
+					FromWarehouseId: warehouse.Id,
+					ToWarehouseId:   target.Id,
+					Cost:            volume * options.MoveCostPerVolume,
+				}
+				if upcoming {
+					move.Kind = RelocateMove
+					moves = append(moves, move)
+				} else if hotDay.After(item.StartDate) && !hotDay.Before(today) {
+					move.Kind = TransferMove
+					move.TransferDate = hotDay
+					moves = append(moves, move)
+				}
+			}
+
+			if !upcoming {
+				continue
+			}
+			for days := 1; days <= options.MaxShiftDays; days++ {
+				for _, shift := range []int{days, -days} {
+					moves = append(moves, ItemMove{
+						Kind:            ShiftMove,
+						ItemId:          item.ItemId,
+						FromWarehouseId: warehouse.Id,
+						ToWarehouseId:   warehouse.Id,
+						ShiftDays:       shift,
+						Cost:            volume * float64(days) * options.ShiftCostPerVolumeDay,
+					})
+				}
+			}
+		}
+	}
+	return moves
+}
+
+func (w Warehouse) firstHotDay(item Item, startDate, endDate, now time.Time, peak float64) (time.Time, bool) {
+	itemStart, itemEnd := w.GetBufferedPeriod(item, now)
+	if itemStart.Before(startDate) {
+		itemStart = startDate
+	}
+	if itemEnd.After(endDate) {
+		itemEnd = endDate
+	}
+	for day := itemStart; !day.After(itemEnd); day = day.AddDate(0, 0, 1) {
+		capacity := w.GetCapacityOnDay(day)
+		if capacity <= 0 {
+			continue
+		}
+		utilization := w.GetVolumeOccupiedOnDay(day, now) / capacity
+		if utilization >= 1 || utilization >= peak {
+			return day, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (service *WarehouseStorageService) evaluateMoves(
+	warehouses []Warehouse,
+	moves []ItemMove,
+	startDate, endDate time.Time,
+) (UtilizationSummary, bool) {
+
+	candidate := service.workingCopy(warehouses)
+	for _, move := range moves {
+		if candidate.applyMove(move) != nil {
+			return UtilizationSummary{}, false
+		}
+	}
+
+	after, err := candidate.repository().ListWarehouses()
+	if err != nil {
+		return UtilizationSummary{}, false
+	}
+	return getUtilizationSummary(after, startDate, endDate, service.now()), true
+}
+
+// combineMoves greedily applies the best improving move until no move helps
+// any more. It only reports a plan when it needs more than one move.
+func (service *WarehouseStorageService) combineMoves(
+	warehouses []Warehouse,
+	startDate, endDate time.Time,
+	options RebalanceOptions,
+	before UtilizationSummary,
+) (MovePlan, bool) {
+
+	plan := MovePlan{Before: before, After: before}
+	current := service.workingCopy(warehouses)
+
+	for {
+		state, err := current.repository().ListWarehouses()
+		if err != nil {
+			break
+		}
+
+		var best *MovePlan
+		for _, move := range current.candidateMoves(state, startDate, endDate, options) {
+			after, ok := current.evaluateMoves(state, []ItemMove{move}, startDate, endDate)
+			if !ok || !improves(plan.After, after) {
+				continue
+			}
+			option := MovePlan{Moves: []ItemMove{move}, Cost: move.Cost, Before: plan.After, After: after}
+			if best == nil || ranksBefore(option, *best) {
+				best = &option
+			}
+		}
+		if best == nil {
+			break
+		}
+		if err := current.applyMove(best.Moves[0]); err != nil {
+			break
+		}
+		plan.Moves = append(plan.Moves, best.Moves[0])
+		plan.Cost += best.Cost
+		plan.After = best.After
+	}
+
+	return plan, len(plan.Moves) > 1
+}
+
+func improves(before, after UtilizationSummary) bool {
+	if after.FullyUtilizedDays != before.FullyUtilizedDays {
+		return after.FullyUtilizedDays < before.FullyUtilizedDays
+	}
+	if after.FullWarehouseDays != before.FullWarehouseDays {
+		return after.FullWarehouseDays < before.FullWarehouseDays
+	}
+	return after.PeakUtilization < before.PeakUtilization
+}
+
+func ranksBefore(a, b MovePlan) bool {
+	aFullyUtilized := a.Before.FullyUtilizedDays - a.After.FullyUtilizedDays
+	bFullyUtilized := b.Before.FullyUtilizedDays - b.After.FullyUtilizedDays
+	if aFullyUtilized != bFullyUtilized {
+		return aFullyUtilized > bFullyUtilized
+	}
+	aFull := a.Before.FullWarehouseDays - a.After.FullWarehouseDays
+	bFull := b.Before.FullWarehouseDays - b.After.FullWarehouseDays
+	if aFull != bFull {
+		return aFull > bFull
+	}
+	aPeak := a.Before.PeakUtilization - a.After.PeakUtilization
+	bPeak := b.Before.PeakUtilization - b.After.PeakUtilization
+	if aPeak != bPeak {
+		return aPeak > bPeak
+	}
+	return a.Cost < b.Cost
+}
+
+func rankMovePlans(plans []MovePlan) {
+	sort.SliceStable(plans, func(i, j int) bool {
+		return ranksBefore(plans[i], plans[j])
+	})
+}
@@ -0,0 +1,105 @@
+package warehouse
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func initRebalancingSteps(ctx *godog.ScenarioContext) {
+	// WHEN
+	ctx.When(`^I plan rebalancing from "([^"]*)" to "([^"]*)" allowing shifts of (\d+) days?$`, iPlanRebalancing)
+	ctx.When(`^I apply the best move plan$`, iApplyTheBestMovePlan)
+
+	// THEN
+	ctx.Then(`^the best move plan should be:$`, theBestMovePlanShouldBe)
+	ctx.Then(`^there should be no move plans$`, thereShouldBeNoMovePlans)
+	ctx.Then(`^the best move plan should lower the fully utilized days from (\d+) to (\d+)$`,
+		theBestMovePlanShouldLowerTheFullyUtilizedDays)
+	ctx.Then(`^the service should report the fully utilized dates from "([^"]*)" to "([^"]*)" as:$`,
+		theServiceShouldReportTheFullyUtilizedDates)
+}
+
+// -------------------
+// WHEN Steps (Act)
+// -------------------
+
+func iPlanRebalancing(ctx context.Context, startStr, endStr string, maxShiftDays int) {
+	t := godog.T(ctx)
+
+	tc.movePlans, tc.rebalanceErr = tc.service.PlanRebalancing(parseDate(t, startStr), parseDate(t, endStr), RebalanceOptions{
+		MaxShiftDays:          maxShiftDays,
+		MoveCostPerVolume:     1,
+		ShiftCostPerVolumeDay: 0.5,
+	})
+}
+
+func iApplyTheBestMovePlan(ctx context.Context) {
+	t := godog.T(ctx)
+
+	if assert.NotEmpty(t, tc.movePlans, "expected at least one move plan") {
+		tc.rebalanceErr = tc.service.ApplyMovePlan(tc.movePlans[0])
+	}
+}
+
+// -------------------
+// THEN Steps (Assert)
+// -------------------
+
+func theBestMovePlanShouldBe(ctx context.Context, table *godog.Table) {
+	t := godog.T(ctx)
+
+	assert.NoError(t, tc.rebalanceErr, "unexpected error")
+	if !assert.NotEmpty(t, tc.movePlans, "expected at least one move plan") {
+		return
+	}
+
+	kinds := map[string]MoveKind{"relocate": RelocateMove, "transfer": TransferMove, "shift": ShiftMove}
+	moves := tc.movePlans[0].Moves
+	if !assert.Len(t, moves, len(table.Rows)-1, "move count mismatch") {
+		return
+	}
+
+	for i, row := range table.Rows[1:] {
+		itemId, _ := strconv.Atoi(row.Cells[1].Value)
+		warehouseId, _ := strconv.Atoi(row.Cells[2].Value)
+		shiftDays, _ := strconv.Atoi(row.Cells[3].Value)
+
+		assert.Equal(t, kinds[row.Cells[0].Value], moves[i].Kind, "move kind mismatch at row %d", i)
+		assert.Equal(t, itemId, moves[i].ItemId, "item mismatch at row %d", i)
+		assert.Equal(t, warehouseId, moves[i].ToWarehouseId, "target warehouse mismatch at row %d", i)
+		assert.Equal(t, shiftDays, moves[i].ShiftDays, "shift mismatch at row %d", i)
+	}
+}
+
+func thereShouldBeNoMovePlans(ctx context.Context) {
+	t := godog.T(ctx)
+
+	assert.NoError(t, tc.rebalanceErr, "unexpected error")
+	assert.Empty(t, tc.movePlans, "expected no move plans")
+}
+
+func theBestMovePlanShouldLowerTheFullyUtilizedDays(ctx context.Context, before, after int) {
+	t := godog.T(ctx)
+
+	assert.NoError(t, tc.rebalanceErr, "unexpected error")
+	if assert.NotEmpty(t, tc.movePlans, "expected at least one move plan") {
+		assert.Equal(t, before, tc.movePlans[0].Before.FullyUtilizedDays, "fully utilized days before mismatch")
+		assert.Equal(t, after, tc.movePlans[0].After.FullyUtilizedDays, "fully utilized days after mismatch")
+	}
+}
+
+// theServiceShouldReportTheFullyUtilizedDates asks the service about the
+// stored items as they are, unlike the GetFullyUtilizedDates step, which
+// replaces them with the usage given in the scenario.
+func theServiceShouldReportTheFullyUtilizedDates(ctx context.Context, startStr, endStr string, table *godog.Table) {
+	t := godog.T(ctx)
+
+	assert.NoError(t, tc.rebalanceErr, "unexpected error")
+	dates, err := tc.service.GetFullyUtilizedDates(parseDate(t, startStr), parseDate(t, endStr))
+	if assert.NoError(t, err, "unexpected error getting fully utilized dates") {
+		compareDates(t, tableToDateSlice(t, table), dates)
+	}
+}
@@ -0,0 +1,240 @@
+package warehouse
+
+import "time"
+
+type Frequency int
+
+const (
+	Daily Frequency = iota
+	Weekly
+	Monthly
+)
+
+// maxRecurrencePeriods guards against rules whose filters never match,
+// e.g. the 31st of every month that is also a Monday with a large Count.
+const maxRecurrencePeriods = 10000
+
+// Recurrence is a subset of an iCalendar RRULE. ByDay filters weekdays for
+// daily and monthly rules and selects the days of the week for weekly rules.
+// ByMonthDay selects days of the month for monthly rules. Weeks start on Monday.
+type Recurrence struct {
+	Frequency  Frequency
+	Interval   int
+	ByDay      []time.Weekday
+	ByMonthDay []int
+	Count      int
+	Until      time.Time
+}
+
+type RecurringReservation struct {
+	Item       Item
+	Recurrence Recurrence
+}
+
+type RecurringMode int
+
+const (
+	AcceptAllOccurrences RecurringMode = iota
+	AcceptFittingOccurrences
+)
+
+type OccurrenceResult struct {
+	StartDate   time.Time
+	EndDate     time.Time
+	WarehouseId int
+	ItemId      int
+	Err         error
+}
+
+type RecurringReservationResult struct {
+	Reserved []OccurrenceResult
+	Rejected []OccurrenceResult
+}
+
+func (r Recurrence) validate() error {
+	if r.Count <= 0 && r.Until.IsZero() {
+		return ErrUnboundedRecurrence
+	}
+	if r.Interval < 0 {
+		return ErrNegativeInterval
+	}
+	if r.Frequency < Daily || r.Frequency > Monthly {
+		return ErrUnknownFrequency
+	}
+	for _, day := range r.ByMonthDay {
+		if day < 1 || day > 31 {
+			return ErrInvalidMonthDay
+		}
+	}
+	return nil
+}
+
+// Expand returns the start dates of all occurrences of the rule, beginning
+// on or after start.
+func (r Recurrence) Expand(start time.Time) ([]time.Time, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	interval := r.Interval
+	if interval == 0 {
+		interval = 1
+	}
+
+	var occurrences []time.Time
+	for period := 0; period < maxRecurrencePeriods; period++ {
+		periodStart, candidates := r.candidates(start, period*interval)
+		if !r.Until.IsZero() && periodStart.After(r.Until) {
+			return occurrences, nil
+		}
+
+		for _, day := range candidates {
+			if day.Before(start) {
+				continue
+			}
+			if !r.Until.IsZero() && day.After(r.Until) {
+				return occurrences, nil
+			}
+			occurrences = append(occurrences, day)
+			if r.Count > 0 && len(occurrences) == r.Count {
+				return occurrences, nil
+			}
+		}
+	}
+
+	return nil, ErrNoOccurrences
+}
+
+func (r Recurrence) candidates(start time.Time, offset int) (time.Time, []time.Time) {
+	switch r.Frequency {
+	case Weekly:
+		monday := start.AddDate(0, 0, -((int(start.Weekday())+6)%7)+7*offset)
+		if len(r.ByDay) == 0 {
+			return monday, []time.Time{start.AddDate(0, 0, 7*offset)}
+		}
+		var days []time.Time
+		for i := 0; i < 7; i++ {
+			day := monday.AddDate(0, 0, i)
+			if containsWeekday(r.ByDay, day.Weekday()) {
+				days = append(days, day)
+			}
+		}
+		return monday, days
+
+	case Monthly:
+		first := time.Date(start.Year(), start.Month()+time.Month(offset), 1, 0, 0, 0, 0, start.Location())
+		var days []time.Time
+		for day := first; day.Month() == first.Month(); day = day.AddDate(0, 0, 1) {
+			if r.matchesMonthDay(start, day) {
+				days = append(days, day)
+			}
+		}
+		return first, days
+
+	default:
+		day := start.AddDate(0, 0, offset)
+		if len(r.ByDay) > 0 && !containsWeekday(r.ByDay, day.Weekday()) {
+			return day, nil
+		}
+		return day, []time.Time{day}
+	}
+}
+
+func (r Recurrence) matchesMonthDay(start, day time.Time) bool {
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		return day.Day() == start.Day()
+	}
+	if len(r.ByMonthDay) > 0 && !containsInt(r.ByMonthDay, day.Day()) {
+		return false
+	}
+	return len(r.ByDay) == 0 || containsWeekday(r.ByDay, day.Weekday())
+}
+
+func containsWeekday(days []time.Weekday, day time.Weekday) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// -------------------------------------------------
+// ReserveRecurring
+// -------------------------------------------------
+func (service *WarehouseStorageService) ReserveRecurring(
+	reservation RecurringReservation,
+	mode RecurringMode,
+) (RecurringReservationResult, error) {
+
+	var result RecurringReservationResult
+
+	if reservation.Item.StartDate.After(reservation.Item.EndDate) {
+		return result, ErrInvalidStay
+	}
+
+	starts, err := reservation.Recurrence.Expand(reservation.Item.StartDate)
+	if err != nil {
+		return result, err
+	}
+
+	stayDays := daysBetween(reservation.Item.StartDate, reservation.Item.EndDate)
+
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return result, err
+	}
+
+	// Occurrences are placed on a copy so that an all-or-nothing request
+	// leaves the service untouched when one of them does not fit.
+	candidate := service.workingCopy(warehouses)
+	nextId := nextItemId(warehouses)
+
+	seriesId := 0
+	for _, start := range starts {
+		occurrence := reservation.Item
+		occurrence.StartDate = start
+		occurrence.EndDate = start.AddDate(0, 0, stayDays)
+		occurrence.ItemId = nextId
+		if seriesId == 0 {
+			seriesId = occurrence.ItemId
+		}
+		occurrence.SeriesId = seriesId
+
+		warehouseId, err := candidate.Reserve(occurrence)
+		outcome := OccurrenceResult{
+			StartDate:   occurrence.StartDate,
+			EndDate:     occurrence.EndDate,
+			WarehouseId: warehouseId,
+			ItemId:      occurrence.ItemId,
+			Err:         err,
+		}
+		if err != nil {
+			outcome.ItemId = 0
+			result.Rejected = append(result.Rejected, outcome)
+			continue
+		}
+		nextId++
+		result.Reserved = append(result.Reserved, outcome)
+	}
+
+	if mode == AcceptAllOccurrences && len(result.Rejected) > 0 {
+		result.Reserved = nil
+		return result, ErrOccurrenceFull
+	}
+
+	return result, service.commit(RecurringReserved, warehouses, candidate)
+}
+
+func daysBetween(startDate, endDate time.Time) int {
+	return int(endDate.Sub(startDate).Hours()/24 + 0.5)
+}
@@ -0,0 +1,146 @@
+package warehouse
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func initReserveRecurringSteps(ctx *godog.ScenarioContext) {
+	// GIVEN
+	ctx.Given(`^warehouse (\d+) is booked with volume (\d+\.?\d*) from "([^"]*)" to "([^"]*)"$`, warehouseIsBookedWithVolume)
+	ctx.Given(`^a recurring reservation from "([^"]*)" to "([^"]*)" with dimensions:$`, aRecurringReservationWithDimensions)
+	ctx.Given(`^the reservation recurs:$`, theReservationRecurs)
+
+	// WHEN
+	ctx.When(`^I reserve the recurring reservation accepting (all|fitting) occurrences$`, iReserveTheRecurringReservation)
+
+	// THEN
+	ctx.Then(`^the reserved occurrences should be:$`, theReservedOccurrencesShouldBe)
+	ctx.Then(`^the rejected occurrences should be:$`, theRejectedOccurrencesShouldBe)
+	ctx.Then(`^warehouse (\d+) should hold (\d+) items?$`, warehouseShouldHoldItems)
+}
+
+// -------------------
+// GIVEN Steps (Arrange)
+// -------------------
+
+func warehouseIsBookedWithVolume(ctx context.Context, warehouseId int, volume float64, startStr, endStr string) {
+	t := godog.T(ctx)
+	tc.AddItemToWarehouse(warehouseId, volume, parseDate(t, startStr), parseDate(t, endStr))
+}
+
+func aRecurringReservationWithDimensions(ctx context.Context, startStr, endStr string, table *godog.Table) {
+	t := godog.T(ctx)
+	dims := parseDimensionsTable(table)
+
+	tc.recurringReservation.Item = Item{
+		ItemName:   "Recurring",
+		ItemHeight: dims.Height,
+		ItemWidth:  dims.Width,
+		ItemLength: dims.Length,
+		StartDate:  parseDate(t, startStr),
+		EndDate:    parseDate(t, endStr),
+	}
+}
+
+func theReservationRecurs(ctx context.Context, table *godog.Table) {
+	t := godog.T(ctx)
+
+	values := make(map[string]string)
+	for i, header := range table.Rows[0].Cells {
+		values[header.Value] = table.Rows[1].Cells[i].Value
+	}
+
+	frequencies := map[string]Frequency{"daily": Daily, "weekly": Weekly, "monthly": Monthly}
+	weekdays := map[string]time.Weekday{}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		weekdays[day.String()] = day
+	}
+
+	recurrence := Recurrence{Frequency: frequencies[values["frequency"]]}
+	recurrence.Interval, _ = strconv.Atoi(values["interval"])
+	recurrence.Count, _ = strconv.Atoi(values["count"])
+	if values["until"] != "" {
+		recurrence.Until = parseDate(t, values["until"])
+	}
+	for _, day := range splitList(values["by day"]) {
+		recurrence.ByDay = append(recurrence.ByDay, weekdays[day])
+	}
+	for _, day := range splitList(values["by month day"]) {
+		monthDay, _ := strconv.Atoi(day)
+		recurrence.ByMonthDay = append(recurrence.ByMonthDay, monthDay)
+	}
+
+	tc.recurringReservation.Recurrence = recurrence
+}
+
+func splitList(value string) []string {
+	var parts []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// -------------------
+// WHEN Step (Act)
+// -------------------
+
+func iReserveTheRecurringReservation(_ context.Context, mode string) {
+	reserveMode := AcceptAllOccurrences
+	if mode == "fitting" {
+		reserveMode = AcceptFittingOccurrences
+	}
+
+	tc.recurringResult, tc.recurringErr = tc.service.ReserveRecurring(tc.recurringReservation, reserveMode)
+}
+
+// -------------------
+// THEN Steps (Assert)
+// -------------------
+
+func theReservedOccurrencesShouldBe(ctx context.Context, table *godog.Table) {
+	t := godog.T(ctx)
+
+	assert.NoError(t, tc.recurringErr, "unexpected error")
+	if !assert.Len(t, tc.recurringResult.Reserved, len(table.Rows)-1, "reserved occurrence count mismatch") {
+		return
+	}
+
+	for i, row := range table.Rows[1:] {
+		occurrence := tc.recurringResult.Reserved[i]
+		warehouseId, _ := strconv.Atoi(row.Cells[2].Value)
+
+		assert.Equal(t, row.Cells[0].Value, occurrence.StartDate.Format("2006-01-02"), "start date mismatch at row %d", i)
+		assert.Equal(t, row.Cells[1].Value, occurrence.EndDate.Format("2006-01-02"), "end date mismatch at row %d", i)
+		assert.Equal(t, warehouseId, occurrence.WarehouseId, "warehouse mismatch at row %d", i)
+	}
+}
+
+func theRejectedOccurrencesShouldBe(ctx context.Context, table *godog.Table) {
+	t := godog.T(ctx)
+
+	if !assert.Len(t, tc.recurringResult.Rejected, len(table.Rows)-1, "rejected occurrence count mismatch") {
+		return
+	}
+
+	for i, row := range table.Rows[1:] {
+		occurrence := tc.recurringResult.Rejected[i]
+
+		assert.Equal(t, row.Cells[0].Value, occurrence.StartDate.Format("2006-01-02"), "start date mismatch at row %d", i)
+		assert.Equal(t, row.Cells[1].Value, occurrence.EndDate.Format("2006-01-02"), "end date mismatch at row %d", i)
+		assert.Error(t, occurrence.Err, "rejected occurrence should carry a reason")
+	}
+}
+
+func warehouseShouldHoldItems(ctx context.Context, warehouseId, count int) {
+	t := godog.T(ctx)
+	assert.Equal(t, count, tc.CountWarehouseItems(warehouseId), "item count mismatch")
+}
@@ -0,0 +1,267 @@
+package warehouse
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const ReportDateFormat = "2006-01-02"
+
+// ReportOptions adds a column per warehouse, named warehouse_<id>, to the
+// daily reports.
+type ReportOptions struct {
+	PerWarehouse bool
+}
+
+// Report is a table ready to be written as CSV or JSON. Values are strings,
+// float64, int, bool or dates.
+type Report struct {
+	Name    string
+	Columns []string
+	Rows    [][]any
+}
+
+// -------------------------------------------------
+// AvailableCapacityReport
+// -------------------------------------------------
+
+// AvailableCapacityReport lists the capacity left on every day of the range,
+// oldest first. With PerWarehouse the capacity left in each warehouse follows.
+func (service *WarehouseStorageService) AvailableCapacityReport(
+	startDate, endDate time.Time,
+	options ReportOptions,
+) (Report, error) {
+
+	capacities, err := service.CalculateAvailableCapacity(startDate, endDate)
+	if err != nil {
+		return Report{}, err
+	}
+
+	warehouses, err := service.repository().ListWarehousesOverlapping(startDate, endDate)
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{Name: "available_capacity", Columns: []string{"date", "available"}}
+	report.addWarehouseColumns(warehouses, options)
+	for _, day := range sortedDays(capacities) {
+		row := []any{day, capacities[day]}
+		if options.PerWarehouse {
+			for _, warehouse := range sortedWarehouses(warehouses) {
+				row = append(row, warehouse.GetCapacityOnDay(day)-warehouse.GetVolumeOccupiedOnDay(day, service.now()))
+			}
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	return report, nil
+}
+
+// -------------------------------------------------
+// FullyUtilizedDatesReport
+// -------------------------------------------------
+
+// FullyUtilizedDatesReport lists the days of the range on which no capacity
+// is left, oldest first. With PerWarehouse the share of each warehouse that is
+// occupied on the day follows.
+func (service *WarehouseStorageService) FullyUtilizedDatesReport(
+	startDate, endDate time.Time,
+	options ReportOptions,
+) (Report, error) {
+
+	dates, err := service.GetFullyUtilizedDates(startDate, endDate)
+	if err != nil {
+		return Report{}, err
+	}
+
+	warehouses, err := service.repository().ListWarehousesOverlapping(startDate, endDate)
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{Name: "fully_utilized_dates", Columns: []string{"date"}}
+	report.addWarehouseColumns(warehouses, options)
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	for _, day := range dates {
+		row := []any{day}
+		if options.PerWarehouse {
+			for _, warehouse := range sortedWarehouses(warehouses) {
+				row = append(row, warehouse.getUtilizationOnDay(day, service.now()))
+			}
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	return report, nil
+}
+
+// -------------------------------------------------
+// WarehouseUsageReport
+// -------------------------------------------------
+
+// WarehouseUsageReport ranks the warehouses by the share of their capacity
+// that is occupied over the range, least used first, as GetLeastUsedWarehouse
+// compares them. Warehouses without capacity in the range are left out.
+func (service *WarehouseStorageService) WarehouseUsageReport(startDate, endDate time.Time) (Report, error) {
+	warehouses, err := service.repository().ListWarehousesOverlapping(startDate, endDate)
+	if err != nil {
+		return Report{}, err
+	}
+
+	if len(warehouses) == 0 {
+		return Report{}, ErrNoWarehouses
+	}
+
+	if startDate.After(endDate) {
+		return Report{}, ErrInvalidPeriod
+	}
+
+	usage := getUsageByWarehouse(warehouses, startDate, endDate, service.now())
+	ids := make([]int, 0, len(usage))
+	for id := range usage {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return usage[ids[i]] < usage[ids[j]] || usage[ids[i]] == usage[ids[j]] && ids[i] < ids[j]
+	})
+
+	report := Report{Name: "warehouse_usage", Columns: []string{"warehouse_id", "usage"}}
+	for _, id := range ids {
+		report.Rows = append(report.Rows, []any{id, usage[id]})
+	}
+	return report, nil
+}
+
+// getUsageByWarehouse returns the share of the capacity offered over the
+// range that is occupied, by warehouse id.
+func getUsageByWarehouse(warehouses []Warehouse, startDate, endDate, now time.Time) map[int]float64 {
+	usage := make(map[int]float64)
+	for _, warehouse := range warehouses {
+		totalVolumeDays := 0.0
+		totalCapacityDays := 0.0
+		for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+			totalVolumeDays += warehouse.GetVolumeOccupiedOnDay(day, now)
+			totalCapacityDays += warehouse.GetCapacityOnDay(day)
+		}
+		if totalCapacityDays > 0 {
+			usage[warehouse.Id] = totalVolumeDays / totalCapacityDays
+		}
+	}
+	return usage
+}
+
+func (w Warehouse) getUtilizationOnDay(day, now time.Time) float64 {
+	capacity := w.GetCapacityOnDay(day)
+	if capacity <= 0 {
+		return 1
+	}
+	return w.GetVolumeOccupiedOnDay(day, now) / capacity
+}
+
+func (r *Report) addWarehouseColumns(warehouses []Warehouse, options ReportOptions) {
+	if !options.PerWarehouse {
+		return
+	}
+	for _, warehouse := range sortedWarehouses(warehouses) {
+		r.Columns = append(r.Columns, fmt.Sprintf("warehouse_%d", warehouse.Id))
+	}
+}
+
+func sortedWarehouses(warehouses []Warehouse) []Warehouse {
+	sorted := append([]Warehouse{}, warehouses...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Id < sorted[j].Id })
+	return sorted
+}
+
+func sortedDays(values map[time.Time]float64) []time.Time {
+	days := make([]time.Time, 0, len(values))
+	for day := range values {
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days
+}
+
+// -------------------------------------------------
+// WriteCSV / WriteJSON
+// -------------------------------------------------
+
+// WriteCSV writes the report with a header line naming the columns.
+func (r Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(r.Columns); err != nil {
+		return err
+	}
+	for _, row := range r.Rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			record[i] = formatReportValue(value)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON writes the report as an array with one object per row, keyed by
+// column in column order.
+func (r Report) WriteJSON(w io.Writer) error {
+	rows := make([]reportRow, len(r.Rows))
+	for i, values := range r.Rows {
+		rows[i] = reportRow{columns: r.Columns, values: values}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(rows)
+}
+
+// reportRow marshals a row as an object whose keys keep the column order,
+// which a map would not.
+type reportRow struct {
+	columns []string
+	values  []any
+}
+
+func (row reportRow) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+	for i, value := range row.values {
+		if t, ok := value.(time.Time); ok {
+			value = t.Format(ReportDateFormat)
+		}
+		key, err := json.Marshal(row.columns[i])
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		buffer.Write(key)
+		buffer.WriteByte(':')
+		buffer.Write(data)
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
+
+func formatReportValue(value any) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(ReportDateFormat)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,60 @@
+package warehouse
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func initCapacityReportSteps(ctx *godog.ScenarioContext) {
+	// WHEN
+	ctx.When(`^I export the (available capacity|fully utilized dates|warehouse usage) report from "([^"]*)" to "([^"]*)" as (CSV|JSON)( per warehouse)?$`, iExportTheReport)
+
+	// THEN
+	ctx.Then(`^the report should be:$`, theReportShouldBe)
+}
+
+// -------------------
+// WHEN Steps (Act)
+// -------------------
+
+func iExportTheReport(ctx context.Context, name, startStr, endStr, format, perWarehouse string) {
+	t := godog.T(ctx)
+
+	start, end := parseDate(t, startStr), parseDate(t, endStr)
+	options := ReportOptions{PerWarehouse: perWarehouse != ""}
+
+	var report Report
+	switch name {
+	case "available capacity":
+		report, tc.reportErr = tc.service.AvailableCapacityReport(start, end, options)
+	case "fully utilized dates":
+		report, tc.reportErr = tc.service.FullyUtilizedDatesReport(start, end, options)
+	case "warehouse usage":
+		report, tc.reportErr = tc.service.WarehouseUsageReport(start, end)
+	}
+	if tc.reportErr != nil {
+		return
+	}
+
+	var output bytes.Buffer
+	if format == "CSV" {
+		tc.reportErr = report.WriteCSV(&output)
+	} else {
+		tc.reportErr = report.WriteJSON(&output)
+	}
+	tc.reportOutput = output.String()
+}
+
+// -------------------
+// THEN Steps (Assert)
+// -------------------
+
+func theReportShouldBe(ctx context.Context, doc *godog.DocString) {
+	t := godog.T(ctx)
+
+	assert.NoError(t, tc.reportErr, "unexpected error")
+	assert.Equal(t, doc.Content, tc.reportOutput, "report mismatch")
+}
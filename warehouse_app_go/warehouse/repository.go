@@ -0,0 +1,344 @@
+package warehouse
+
+import (
+	"maps"
+	"sync"
+	"time"
+)
+
+// WarehouseRepository stores the warehouses of the service together with
+// their items. Warehouses are handed out as copies, so changes only take
+// effect once they are written back. Saving a warehouse or updating an item
+// fails with a ConflictError when its version is no longer the stored one.
+type WarehouseRepository interface {
+	GetWarehouse(warehouseId int) (Warehouse, error)
+	ListWarehouses() ([]Warehouse, error)
+
+	// ListWarehousesOverlapping returns every warehouse with only the items
+	// whose buffered period may overlap the inclusive date range. Items still
+	// checked in are kept for every range after their check-in.
+	ListWarehousesOverlapping(startDate, endDate time.Time) ([]Warehouse, error)
+
+	// SaveWarehouse adds the warehouse or replaces the one with the same id,
+	// items included.
+	SaveWarehouse(warehouse Warehouse) error
+	RemoveWarehouse(warehouseId int) error
+
+	AddItem(warehouseId int, item Item) error
+	UpdateItem(warehouseId int, item Item) error
+	RemoveItem(warehouseId, itemId int) error
+}
+
+// ServiceState is what the service keeps besides the warehouses.
+type ServiceState struct {
+	Customers []Customer
+	Waitlist  []Item
+}
+
+// StateRepository is implemented by repositories that store the customers
+// and the waitlist of the service together with the warehouses, so that
+// they are written in the same operation as the warehouses they refer to.
+type StateRepository interface {
+	LoadState() (ServiceState, error)
+	SaveState(state ServiceState) error
+}
+
+// loadState returns the state the repository keeps, or an empty state when it
+// does not keep one.
+func loadState(repository WarehouseRepository) (ServiceState, error) {
+	if states, ok := repository.(StateRepository); ok {
+		return states.LoadState()
+	}
+	return ServiceState{}, nil
+}
+
+// saveState stores the state in the repository when it keeps one.
+func saveState(repository WarehouseRepository, state ServiceState) error {
+	if states, ok := repository.(StateRepository); ok {
+		return states.SaveState(state)
+	}
+	return nil
+}
+
+// InMemoryRepository keeps the warehouses in memory. It is safe for
+// concurrent use.
+type InMemoryRepository struct {
+	mu         sync.RWMutex
+	warehouses []Warehouse
+	state      ServiceState
+	audit      []AuditEntry
+}
+
+func NewInMemoryRepository(warehouses []Warehouse) *InMemoryRepository {
+	return &InMemoryRepository{warehouses: cloneWarehouses(warehouses)}
+}
+
+// copy returns a repository holding a copy of the warehouses, the state and
+// the audit entries. The caller holds the lock.
+func (r *InMemoryRepository) copy() *InMemoryRepository {
+	return &InMemoryRepository{
+		warehouses: cloneWarehouses(r.warehouses),
+		state:      cloneState(r.state),
+		audit:      cloneSlice(r.audit),
+	}
+}
+
+func (r *InMemoryRepository) GetWarehouse(warehouseId int) (Warehouse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	index, found := findWarehouse(r.warehouses, warehouseId)
+	if !found {
+		return Warehouse{}, ErrWarehouseNotFound
+	}
+	return cloneWarehouse(r.warehouses[index]), nil
+}
+
+func (r *InMemoryRepository) ListWarehouses() ([]Warehouse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return cloneWarehouses(r.warehouses), nil
+}
+
+func (r *InMemoryRepository) ListWarehousesOverlapping(startDate, endDate time.Time) ([]Warehouse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	warehouses := make([]Warehouse, len(r.warehouses))
+	for i, warehouse := range r.warehouses {
+		warehouses[i] = cloneWarehouse(warehouse)
+		warehouses[i].Items = nil
+		for _, item := range warehouse.Items {
+			itemStart, itemEnd := warehouse.getPossibleBufferedPeriod(item)
+			if !itemEnd.Before(startDate) && !itemStart.After(endDate) {
+				warehouses[i].Items = append(warehouses[i].Items, item)
+			}
+		}
+	}
+	return warehouses, nil
+}
+
+func (r *InMemoryRepository) SaveWarehouse(warehouse Warehouse) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	index, found := findWarehouse(r.warehouses, warehouse.Id)
+	if !found {
+		stored, _ := storeWarehouse(nil, warehouse)
+		r.warehouses = append(r.warehouses, stored)
+		return nil
+	}
+
+	stored, err := storeWarehouse(&r.warehouses[index], warehouse)
+	if err != nil {
+		return err
+	}
+	r.warehouses[index] = stored
+	return nil
+}
+
+func (r *InMemoryRepository) RemoveWarehouse(warehouseId int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	index, found := findWarehouse(r.warehouses, warehouseId)
+	if !found {
+		return ErrWarehouseNotFound
+	}
+	r.warehouses = append(r.warehouses[:index:index], r.warehouses[index+1:]...)
+	return nil
+}
+
+func (r *InMemoryRepository) AddItem(warehouseId int, item Item) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	index, found := findWarehouse(r.warehouses, warehouseId)
+	if !found {
+		return ErrWarehouseNotFound
+	}
+	if _, _, exists := findItem(r.warehouses, item.ItemId); exists {
+		return ErrItemExists
+	}
+	item.Version = 1
+	r.warehouses[index].Items = append(r.warehouses[index].Items, cloneItem(item))
+	r.warehouses[index].Version++
+	return nil
+}
+
+func (r *InMemoryRepository) UpdateItem(warehouseId int, item Item) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	warehouseIndex, _, err := r.locateItem(warehouseId, item.ItemId)
+	if err != nil {
+		return err
+	}
+	stored, err := storeItem(r.warehouses[warehouseIndex], item)
+	if err != nil {
+		return err
+	}
+	r.warehouses[warehouseIndex] = stored
+	return nil
+}
+
+func (r *InMemoryRepository) RemoveItem(warehouseId, itemId int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	warehouseIndex, itemIndex, err := r.locateItem(warehouseId, itemId)
+	if err != nil {
+		return err
+	}
+	items := r.warehouses[warehouseIndex].Items
+	r.warehouses[warehouseIndex].Items = append(items[:itemIndex:itemIndex], items[itemIndex+1:]...)
+	r.warehouses[warehouseIndex].Version++
+	return nil
+}
+
+func (r *InMemoryRepository) LoadState() (ServiceState, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return cloneState(r.state), nil
+}
+
+func (r *InMemoryRepository) SaveState(state ServiceState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state = cloneState(state)
+	return nil
+}
+
+func (r *InMemoryRepository) LoadAudit() ([]AuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return cloneAuditEntries(r.audit), nil
+}
+
+// AppendAudit adds the entries after the ones already kept. Entries are
+// never changed once added, so copies of the repository share them.
+func (r *InMemoryRepository) AppendAudit(entries []AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.audit = append(r.audit, cloneAuditEntries(entries)...)
+	return nil
+}
+
+// Record runs apply against a copy of the warehouses and keeps its changes
+// only when it succeeds. Other writers wait until it is done.
+func (r *InMemoryRepository) Record(_ EventType, apply func(repository WarehouseRepository) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	candidate := r.copy()
+	if err := apply(candidate); err != nil {
+		return err
+	}
+	r.warehouses = candidate.warehouses
+	r.state = candidate.state
+	r.audit = candidate.audit
+	return nil
+}
+
+func (r *InMemoryRepository) locateItem(warehouseId, itemId int) (int, int, error) {
+	warehouseIndex, found := findWarehouse(r.warehouses, warehouseId)
+	if !found {
+		return -1, -1, ErrWarehouseNotFound
+	}
+	for itemIndex, item := range r.warehouses[warehouseIndex].Items {
+		if item.ItemId == itemId {
+			return warehouseIndex, itemIndex, nil
+		}
+	}
+	return -1, -1, ErrItemNotFound
+}
+
+func findWarehouse(warehouses []Warehouse, warehouseId int) (int, bool) {
+	for i, warehouse := range warehouses {
+		if warehouse.Id == warehouseId {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+func findItem(warehouses []Warehouse, itemId int) (int, int, bool) {
+	for i, warehouse := range warehouses {
+		for j, item := range warehouse.Items {
+			if item.ItemId == itemId {
+				return i, j, true
+			}
+		}
+	}
+	return -1, -1, false
+}
+
+func nextItemId(warehouses []Warehouse) int {
+	maxId := 0
+	for _, warehouse := range warehouses {
+		for _, item := range warehouse.Items {
+			maxId = max(maxId, item.ItemId)
+		}
+	}
+	return maxId + 1
+}
+
+func cloneWarehouses(warehouses []Warehouse) []Warehouse {
+	if warehouses == nil {
+		return nil
+	}
+	clones := make([]Warehouse, len(warehouses))
+	for i, warehouse := range warehouses {
+		clones[i] = cloneWarehouse(warehouse)
+	}
+	return clones
+}
+
+func cloneWarehouse(warehouse Warehouse) Warehouse {
+	clone := warehouse
+	clone.Calendar.ClosedWeekdays = cloneSlice(warehouse.Calendar.ClosedWeekdays)
+	clone.Calendar.Closures = cloneSlice(warehouse.Calendar.Closures)
+	clone.CapacitySchedule = cloneSlice(warehouse.CapacitySchedule)
+	for i, change := range clone.CapacitySchedule {
+		if change.Room != nil {
+			room := *change.Room
+			clone.CapacitySchedule[i].Room = &room
+		}
+	}
+	clone.Blocks = cloneSlice(warehouse.Blocks)
+	clone.Items = cloneSlice(warehouse.Items)
+	for i, item := range clone.Items {
+		clone.Items[i] = cloneItem(item)
+	}
+	return clone
+}
+
+func cloneItem(item Item) Item {
+	if item.Buffer != nil {
+		buffer := *item.Buffer
+		item.Buffer = &buffer
+	}
+	return item
+}
+
+func cloneState(state ServiceState) ServiceState {
+	customers := cloneSlice(state.Customers)
+	for i, customer := range customers {
+		if customer.WarehouseQuotas != nil {
+			customers[i].WarehouseQuotas = maps.Clone(customer.WarehouseQuotas)
+		}
+	}
+	waitlist := cloneSlice(state.Waitlist)
+	for i, item := range waitlist {
+		waitlist[i] = cloneItem(item)
+	}
+	return ServiceState{Customers: customers, Waitlist: waitlist}
+}
+
+func cloneSlice[T any](values []T) []T {
+	if values == nil {
+		return nil
+	}
+	return append([]T{}, values...)
+}
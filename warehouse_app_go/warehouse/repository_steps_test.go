@@ -0,0 +1,135 @@
+package warehouse
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initRepositorySteps(ctx *godog.ScenarioContext) {
+	ctx.After(func(ctx context.Context, sc *godog.Scenario, err error) (context.Context, error) {
+		if tc.repositoryPath != "" {
+			os.RemoveAll(filepath.Dir(tc.repositoryPath))
+		}
+		return ctx, nil
+	})
+
+	// GIVEN
+	ctx.Given(`^the warehouses are stored in a file$`, theWarehousesAreStoredInAFile)
+
+	// WHEN
+	ctx.When(`^I reserve volume (\d+\.?\d*) from "([^"]*)" to "([^"]*)"$`, iReserveVolume)
+	ctx.When(`^I reopen the file$`, iReopenTheFile)
+	ctx.When(`^I save the service state to a file$`, iSaveTheServiceStateToAFile)
+	ctx.When(`^I load the file into a new service$`, iLoadTheFileIntoANewService)
+
+	// THEN
+	ctx.Then(`^the items overlapping "([^"]*)" to "([^"]*)" should be:$`, theItemsOverlappingShouldBe)
+	ctx.Then(`^the service should know (\d+) customers?$`, theServiceShouldKnowCustomers)
+}
+
+// -------------------
+// GIVEN Steps (Arrange)
+// -------------------
+
+func theWarehousesAreStoredInAFile(ctx context.Context) {
+	t := godog.T(ctx)
+
+	tc.repositoryPath = newRepositoryPath(t)
+	repository, err := OpenFileRepository(tc.repositoryPath)
+	require.NoError(t, err, "unexpected error")
+	tc.service.Repository = repository
+}
+
+// -------------------
+// WHEN Steps (Act)
+// -------------------
+
+func iReserveVolume(ctx context.Context, volume float64, startStr, endStr string) {
+	t := godog.T(ctx)
+
+	tc.searchResult, tc.searchError = tc.service.Reserve(Item{
+		ItemHeight: volume,
+		ItemWidth:  1,
+		ItemLength: 1,
+		StartDate:  parseDate(t, startStr),
+		EndDate:    parseDate(t, endStr),
+	})
+}
+
+func iReopenTheFile(ctx context.Context) {
+	t := godog.T(ctx)
+
+	now := tc.service.shared().Now
+	repository, err := OpenFileRepository(tc.repositoryPath)
+	tc.repositoryErr = err
+	if err != nil {
+		tc.service = WarehouseStorageService{Repository: repository, Now: now}
+		return
+	}
+
+	service, err := NewWarehouseStorageService(repository)
+	require.NoError(t, err, "unexpected error")
+	service.Now = now
+	tc.service = *service
+}
+
+func iSaveTheServiceStateToAFile(ctx context.Context) {
+	t := godog.T(ctx)
+
+	tc.repositoryPath = newRepositoryPath(t)
+	require.NoError(t, tc.service.SaveFile(tc.repositoryPath), "unexpected error")
+}
+
+func iLoadTheFileIntoANewService(ctx context.Context) {
+	t := godog.T(ctx)
+
+	tc.service = WarehouseStorageService{Now: tc.service.Now}
+	require.NoError(t, tc.service.LoadFile(tc.repositoryPath), "unexpected error")
+}
+
+func newRepositoryPath(t require.TestingT) string {
+	dir, err := os.MkdirTemp("", "warehouses")
+	require.NoError(t, err, "unexpected error")
+	return filepath.Join(dir, "warehouses.json")
+}
+
+// -------------------
+// THEN Steps (Assert)
+// -------------------
+
+func theItemsOverlappingShouldBe(ctx context.Context, startStr, endStr string, table *godog.Table) {
+	t := godog.T(ctx)
+
+	warehouses, err := tc.service.repository().ListWarehousesOverlapping(parseDate(t, startStr), parseDate(t, endStr))
+	assert.NoError(t, err, "unexpected error")
+
+	var expected, actual [][2]int
+	for _, row := range table.Rows[1:] {
+		warehouseId, _ := strconv.Atoi(row.Cells[0].Value)
+		itemId, _ := strconv.Atoi(row.Cells[1].Value)
+		expected = append(expected, [2]int{warehouseId, itemId})
+	}
+	for _, warehouse := range warehouses {
+		for _, item := range warehouse.Items {
+			actual = append(actual, [2]int{warehouse.Id, item.ItemId})
+		}
+	}
+	sort.Slice(actual, func(i, j int) bool {
+		return actual[i][0] < actual[j][0] || actual[i][0] == actual[j][0] && actual[i][1] < actual[j][1]
+	})
+
+	assert.Equal(t, expected, actual, "overlapping items mismatch")
+}
+
+func theServiceShouldKnowCustomers(ctx context.Context, count int) {
+	t := godog.T(ctx)
+
+	assert.Len(t, tc.service.Customers, count, "customer count mismatch")
+}
@@ -0,0 +1,172 @@
+package warehouse
+
+import "time"
+
+// -------------------------------------------------
+// GetItem / UpdateItem
+// -------------------------------------------------
+
+// GetItem returns the item with the id of the warehouse that holds it.
+func (service *WarehouseStorageService) GetItem(itemId int) (Item, int, error) {
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return Item{}, -1, err
+	}
+
+	warehouseIndex, itemIndex, found := findItem(warehouses, itemId)
+	if !found {
+		return Item{}, -1, ErrItemNotFound
+	}
+	return warehouses[warehouseIndex].Items[itemIndex], warehouses[warehouseIndex].Id, nil
+}
+
+// UpdateItem changes the name, dimensions and dates of an active item that
+// has not been checked in yet and returns it at its new version. The new
+// stay must fit into the warehouse that holds the item. The edit must be
+// based on the stored version, otherwise a ConflictError tells what changed
+// in the meantime.
+func (service *WarehouseStorageService) UpdateItem(item Item) (Item, error) {
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return Item{}, err
+	}
+
+	warehouseIndex, itemIndex, found := findItem(warehouses, item.ItemId)
+	if !found {
+		return Item{}, ErrItemNotFound
+	}
+
+	warehouse := &warehouses[warehouseIndex]
+	stored := warehouse.Items[itemIndex]
+	if !stored.IsActive {
+		return Item{}, ErrItemNotActive
+	}
+
+	if !stored.CheckedInAt.IsZero() {
+		return Item{}, ErrItemCheckedIn
+	}
+
+	if item.ItemHeight <= 0 || item.ItemWidth <= 0 || item.ItemLength <= 0 {
+		return Item{}, ErrInvalidDimensions
+	}
+
+	if item.StartDate.After(item.EndDate) {
+		return Item{}, ErrInvalidStay
+	}
+
+	if !item.StartDate.Equal(stored.StartDate) && item.StartDate.Before(service.now()) {
+		return Item{}, ErrStartInPast
+	}
+
+	updated := stored
+	updated.ItemName = item.ItemName
+	updated.ItemHeight = item.ItemHeight
+	updated.ItemWidth = item.ItemWidth
+	updated.ItemLength = item.ItemLength
+	updated.StartDate = item.StartDate
+	updated.EndDate = item.EndDate
+	updated.Version = item.Version
+
+	// The item must not compete with its own current stay.
+	warehouse.Items[itemIndex].IsActive = false
+	fits := service.canPlace(warehouses, *warehouse, updated, item.ItemId)
+	warehouse.Items[itemIndex].IsActive = true
+	if !fits {
+		return Item{}, ErrNoCapacity
+	}
+
+	err = service.write(ItemUpdated, func(repository WarehouseRepository) error {
+		if err := expectVersion(repository, *warehouse); err != nil {
+			return err
+		}
+		return repository.UpdateItem(warehouse.Id, updated)
+	})
+	if err != nil {
+		return Item{}, err
+	}
+
+	updated, _, err = service.GetItem(item.ItemId)
+	return updated, err
+}
+
+// -------------------------------------------------
+// CancelReservation
+// -------------------------------------------------
+
+// CancelReservation releases the space of the item. The item is kept as an
+// inactive record.
+func (service *WarehouseStorageService) CancelReservation(itemId int) error {
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return err
+	}
+
+	warehouseIndex, itemIndex, found := findItem(warehouses, itemId)
+	if !found {
+		return ErrItemNotFound
+	}
+
+	item := warehouses[warehouseIndex].Items[itemIndex]
+	if !item.IsActive {
+		return ErrItemNotActive
+	}
+
+	if !item.CheckedOutAt.IsZero() {
+		return ErrItemCheckedOut
+	}
+
+	item.IsActive = false
+	return service.write(ItemCancelled, func(repository WarehouseRepository) error {
+		return repository.UpdateItem(warehouses[warehouseIndex].Id, item)
+	})
+}
+
+// -------------------------------------------------
+// ExtendReservation
+// -------------------------------------------------
+
+// ExtendReservation moves the end date of the stay to endDate when the
+// warehouse can hold the item for the additional days.
+func (service *WarehouseStorageService) ExtendReservation(itemId int, endDate time.Time) error {
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return err
+	}
+
+	warehouseIndex, itemIndex, found := findItem(warehouses, itemId)
+	if !found {
+		return ErrItemNotFound
+	}
+
+	warehouse := &warehouses[warehouseIndex]
+	item := warehouse.Items[itemIndex]
+	if !item.IsActive {
+		return ErrItemNotActive
+	}
+
+	if !item.CheckedOutAt.IsZero() {
+		return ErrItemCheckedOut
+	}
+
+	if !endDate.After(item.EndDate) {
+		return ErrEndNotLater
+	}
+
+	extended := item
+	extended.EndDate = endDate
+
+	// The item must not compete with its own current stay.
+	warehouse.Items[itemIndex].IsActive = false
+	fits := service.canPlace(warehouses, *warehouse, extended, item.ItemId)
+	warehouse.Items[itemIndex].IsActive = true
+	if !fits {
+		return ErrNoCapacity
+	}
+
+	return service.write(ItemExtended, func(repository WarehouseRepository) error {
+		if err := expectVersion(repository, *warehouse); err != nil {
+			return err
+		}
+		return repository.UpdateItem(warehouse.Id, extended)
+	})
+}
@@ -0,0 +1,115 @@
+package warehouse
+
+import (
+	"errors"
+	"time"
+)
+
+type AvailableSlot struct {
+	StartDate   time.Time
+	EndDate     time.Time
+	WarehouseId int
+}
+
+// -------------------------------------------------
+// FindEarliestAvailableSlot
+// -------------------------------------------------
+
+// FindEarliestAvailableSlot returns the earliest start date between notBefore
+// and notAfter at which a warehouse can store the item for duration days.
+// Warehouses are tried in order when several can take the item on that day.
+func (service *WarehouseStorageService) FindEarliestAvailableSlot(
+	dimensions ThreeDRoom,
+	duration int,
+	notBefore, notAfter time.Time,
+) (AvailableSlot, error) {
+
+	if duration < 1 {
+		return AvailableSlot{}, errors.New("the duration must be at least one day")
+	}
+
+	if notBefore.After(notAfter) {
+		return AvailableSlot{}, ErrInvalidPeriod
+	}
+
+	lastEnd := notAfter.AddDate(0, 0, duration-1)
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return AvailableSlot{}, err
+	}
+
+	if err := service.validateStay(warehouses, notBefore, lastEnd, dimensions.Height, dimensions.Width, dimensions.Length); err != nil {
+		return AvailableSlot{}, err
+	}
+
+	var earliest AvailableSlot
+	found := false
+	for _, warehouse := range warehouses {
+		start, ok := warehouse.findEarliestStart(dimensions.GetVolume(), duration, notBefore, notAfter, service.now())
+		if ok && (!found || start.Before(earliest.StartDate)) {
+			earliest = AvailableSlot{
+				StartDate:   start,
+				EndDate:     start.AddDate(0, 0, duration-1),
+				WarehouseId: warehouse.Id,
+			}
+			found = true
+		}
+	}
+
+	if !found {
+		return AvailableSlot{}, errors.New("no warehouse can accommodate the item within the search window")
+	}
+
+	return earliest, nil
+}
+
+func (w Warehouse) findEarliestStart(
+	requiredVolume float64,
+	duration int,
+	notBefore, notAfter, now time.Time,
+) (time.Time, bool) {
+
+	margins := w.getStayMargins(requiredVolume, duration, notBefore, notAfter, now)
+	for i, margin := range margins {
+		start := notBefore.AddDate(0, 0, i)
+		if margin >= 0 && w.canOperate(Item{StartDate: start, EndDate: start.AddDate(0, 0, duration-1)}, now) {
+			return start, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// getStayMargins returns, for every start date from firstStart to lastStart,
+// the smallest free volume left over the buffered stay once the item is
+// stored. The free volume profile is scanned once and the minimum over each
+// window is kept in a monotonic queue.
+func (w Warehouse) getStayMargins(
+	requiredVolume float64,
+	duration int,
+	firstStart, lastStart, now time.Time,
+) []float64 {
+
+	buffer := w.Buffer
+	scanStart := firstStart.AddDate(0, 0, -buffer.Before)
+	scanEnd := lastStart.AddDate(0, 0, duration-1+buffer.After)
+	profile := w.getFreeVolumeProfile(scanStart, scanEnd, now)
+	window := buffer.Before + duration + buffer.After
+
+	margins := make([]float64, 0, len(profile)-window+1)
+	var queue []int
+	for i, free := range profile {
+		for len(queue) > 0 && profile[queue[len(queue)-1]] >= free {
+			queue = queue[:len(queue)-1]
+		}
+		queue = append(queue, i)
+		if queue[0] <= i-window {
+			queue = queue[1:]
+		}
+		if i >= window-1 {
+			margins = append(margins, profile[queue[0]]-requiredVolume)
+		}
+	}
+
+	return margins
+}
@@ -0,0 +1,45 @@
+package warehouse
+
+import (
+	"context"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func initEarliestAvailableSlotSteps(ctx *godog.ScenarioContext) {
+	// WHEN
+	ctx.When(`^I search the earliest slot of (\d+) days? between "([^"]*)" and "([^"]*)" with dimensions:$`,
+		iSearchTheEarliestSlot)
+
+	// THEN
+	ctx.Then(`^the earliest slot should be from "([^"]*)" to "([^"]*)" in warehouse (\d+)$`, theEarliestSlotShouldBe)
+}
+
+// -------------------
+// WHEN Step (Act)
+// -------------------
+
+func iSearchTheEarliestSlot(ctx context.Context, duration int, startStr, endStr string, table *godog.Table) {
+	t := godog.T(ctx)
+
+	tc.slotResult, tc.slotErr = tc.service.FindEarliestAvailableSlot(
+		*parseDimensionsTable(table),
+		duration,
+		parseDate(t, startStr),
+		parseDate(t, endStr),
+	)
+}
+
+// -------------------
+// THEN Steps (Assert)
+// -------------------
+
+func theEarliestSlotShouldBe(ctx context.Context, startStr, endStr string, warehouseId int) {
+	t := godog.T(ctx)
+
+	assert.NoError(t, tc.slotErr, "unexpected error")
+	assert.Equal(t, startStr, tc.slotResult.StartDate.Format("2006-01-02"), "start date mismatch")
+	assert.Equal(t, endStr, tc.slotResult.EndDate.Format("2006-01-02"), "end date mismatch")
+	assert.Equal(t, warehouseId, tc.slotResult.WarehouseId, "warehouse ID mismatch")
+}
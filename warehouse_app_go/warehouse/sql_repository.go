@@ -0,0 +1,1343 @@
+package warehouse
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// sqlMigrations are applied in order; the index of a migration plus one is
+// the schema version it brings the database to. Applied migrations must never
+// change, new ones are appended. A migration may hold several statements.
+var sqlMigrations = []string{
+	`CREATE TABLE warehouses (
+		id       INTEGER PRIMARY KEY,
+		position INTEGER NOT NULL,
+		data     TEXT NOT NULL
+	)`,
+	`CREATE TABLE items (
+		id            INTEGER PRIMARY KEY,
+		warehouse_id  INTEGER NOT NULL REFERENCES warehouses (id),
+		position      INTEGER NOT NULL,
+		occupied_from TEXT NOT NULL,
+		occupied_to   TEXT NOT NULL,
+		data          TEXT NOT NULL
+	)`,
+	`CREATE INDEX items_warehouse_period ON items (warehouse_id, occupied_from, occupied_to)`,
+	`ALTER TABLE warehouses ADD COLUMN version INTEGER NOT NULL DEFAULT 1`,
+	`ALTER TABLE items ADD COLUMN version INTEGER NOT NULL DEFAULT 1`,
+
+	// The JSON documents of the warehouses and items are split into typed
+	// columns; the lists of a warehouse get tables of their own.
+	`ALTER TABLE items RENAME TO legacy_items;
+	ALTER TABLE warehouses RENAME TO legacy_warehouses;
+	DROP INDEX items_warehouse_period;
+
+	CREATE TABLE warehouses (
+		id                INTEGER PRIMARY KEY CHECK (id > 0),
+		position          INTEGER NOT NULL,
+		height            REAL NOT NULL CHECK (height > 0),
+		width             REAL NOT NULL CHECK (width > 0),
+		length            REAL NOT NULL CHECK (length > 0),
+		buffer_before     INTEGER NOT NULL CHECK (buffer_before >= 0),
+		buffer_after      INTEGER NOT NULL CHECK (buffer_after >= 0),
+		commissioned_on   TEXT,
+		decommissioned_on TEXT CHECK (decommissioned_on >= commissioned_on),
+		version           INTEGER NOT NULL CHECK (version >= 1)
+	);
+	INSERT INTO warehouses
+	SELECT id, position,
+		json_extract(data, '$.MaxCapacity.Height'),
+		json_extract(data, '$.MaxCapacity.Width'),
+		json_extract(data, '$.MaxCapacity.Length'),
+		json_extract(data, '$.Buffer.Before'),
+		json_extract(data, '$.Buffer.After'),
+		NULLIF(json_extract(data, '$.Calendar.CommissionedOn'), '0001-01-01T00:00:00Z'),
+		NULLIF(json_extract(data, '$.Calendar.DecommissionedOn'), '0001-01-01T00:00:00Z'),
+		version
+	FROM legacy_warehouses;
+
+	CREATE TABLE warehouse_closed_weekdays (
+		warehouse_id INTEGER NOT NULL REFERENCES warehouses (id) ON DELETE CASCADE,
+		position     INTEGER NOT NULL,
+		weekday      INTEGER NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+		PRIMARY KEY (warehouse_id, position)
+	);
+	INSERT INTO warehouse_closed_weekdays
+	SELECT w.id, weekday.key + 1, weekday.value
+	FROM legacy_warehouses w, json_each(w.data, '$.Calendar.ClosedWeekdays') weekday
+	WHERE json_type(w.data, '$.Calendar.ClosedWeekdays') = 'array';
+
+	CREATE TABLE warehouse_closures (
+		warehouse_id INTEGER NOT NULL REFERENCES warehouses (id) ON DELETE CASCADE,
+		position     INTEGER NOT NULL,
+		start_date   TEXT NOT NULL,
+		end_date     TEXT NOT NULL CHECK (end_date >= start_date),
+		PRIMARY KEY (warehouse_id, position)
+	);
+	INSERT INTO warehouse_closures
+	SELECT w.id, closure.key + 1,
+		json_extract(closure.value, '$.Start'),
+		json_extract(closure.value, '$.End')
+	FROM legacy_warehouses w, json_each(w.data, '$.Calendar.Closures') closure
+	WHERE json_type(w.data, '$.Calendar.Closures') = 'array';
+
+	CREATE TABLE warehouse_capacity_changes (
+		warehouse_id      INTEGER NOT NULL REFERENCES warehouses (id) ON DELETE CASCADE,
+		position          INTEGER NOT NULL,
+		effective_from    TEXT NOT NULL,
+		effective_to      TEXT CHECK (effective_to >= effective_from),
+		room_height       REAL CHECK (room_height > 0),
+		room_width        REAL CHECK (room_width > 0),
+		room_length       REAL CHECK (room_length > 0),
+		volume_adjustment REAL NOT NULL,
+		PRIMARY KEY (warehouse_id, position),
+		CHECK ((room_height IS NULL) = (room_width IS NULL) AND (room_width IS NULL) = (room_length IS NULL))
+	);
+	INSERT INTO warehouse_capacity_changes
+	SELECT w.id, change.key + 1,
+		json_extract(change.value, '$.EffectiveFrom'),
+		NULLIF(json_extract(change.value, '$.EffectiveTo'), '0001-01-01T00:00:00Z'),
+		json_extract(change.value, '$.Room.Height'),
+		json_extract(change.value, '$.Room.Width'),
+		json_extract(change.value, '$.Room.Length'),
+		json_extract(change.value, '$.VolumeAdjustment')
+	FROM legacy_warehouses w, json_each(w.data, '$.CapacitySchedule') change
+	WHERE json_type(w.data, '$.CapacitySchedule') = 'array';
+
+	CREATE TABLE capacity_blocks (
+		warehouse_id INTEGER NOT NULL REFERENCES warehouses (id) ON DELETE CASCADE,
+		position     INTEGER NOT NULL,
+		id           INTEGER NOT NULL,
+		customer_id  INTEGER NOT NULL,
+		volume       REAL NOT NULL CHECK (volume > 0),
+		start_date   TEXT NOT NULL,
+		end_date     TEXT NOT NULL CHECK (end_date >= start_date),
+		PRIMARY KEY (warehouse_id, position)
+	);
+	INSERT INTO capacity_blocks
+	SELECT w.id, block.key + 1,
+		json_extract(block.value, '$.Id'),
+		json_extract(block.value, '$.CustomerId'),
+		json_extract(block.value, '$.Volume'),
+		json_extract(block.value, '$.StartDate'),
+		json_extract(block.value, '$.EndDate')
+	FROM legacy_warehouses w, json_each(w.data, '$.Blocks') block
+	WHERE json_type(w.data, '$.Blocks') = 'array';
+
+	CREATE TABLE items (
+		id               INTEGER PRIMARY KEY CHECK (id > 0),
+		warehouse_id     INTEGER NOT NULL REFERENCES warehouses (id),
+		position         INTEGER NOT NULL,
+		occupied_from    TEXT NOT NULL,
+		occupied_to      TEXT NOT NULL CHECK (occupied_to >= occupied_from),
+		name             TEXT NOT NULL,
+		height           REAL NOT NULL CHECK (height > 0),
+		width            REAL NOT NULL CHECK (width > 0),
+		length           REAL NOT NULL CHECK (length > 0),
+		start_date       TEXT NOT NULL,
+		end_date         TEXT NOT NULL CHECK (end_date >= start_date),
+		is_active        INTEGER NOT NULL CHECK (is_active IN (0, 1)),
+		series_id        INTEGER NOT NULL,
+		shipment_id      INTEGER NOT NULL,
+		previous_item_id INTEGER NOT NULL,
+		checked_in_at    TEXT,
+		checked_out_at   TEXT CHECK (checked_out_at >= checked_in_at),
+		buffer_before    INTEGER CHECK (buffer_before >= 0),
+		buffer_after     INTEGER CHECK (buffer_after >= 0),
+		priority         INTEGER NOT NULL,
+		customer_id      INTEGER NOT NULL,
+		version          INTEGER NOT NULL CHECK (version >= 1),
+		CHECK ((buffer_before IS NULL) = (buffer_after IS NULL))
+	);
+	INSERT INTO items
+	SELECT id, warehouse_id, position, occupied_from, occupied_to,
+		json_extract(data, '$.ItemName'),
+		json_extract(data, '$.ItemHeight'),
+		json_extract(data, '$.ItemWidth'),
+		json_extract(data, '$.ItemLength'),
+		json_extract(data, '$.StartDate'),
+		json_extract(data, '$.EndDate'),
+		json_extract(data, '$.IsActive'),
+		json_extract(data, '$.SeriesId'),
+		json_extract(data, '$.ShipmentId'),
+		json_extract(data, '$.PreviousItemId'),
+		NULLIF(json_extract(data, '$.CheckedInAt'), '0001-01-01T00:00:00Z'),
+		NULLIF(json_extract(data, '$.CheckedOutAt'), '0001-01-01T00:00:00Z'),
+		json_extract(data, '$.Buffer.Before'),
+		json_extract(data, '$.Buffer.After'),
+		json_extract(data, '$.Priority'),
+		json_extract(data, '$.CustomerId'),
+		version
+	FROM legacy_items;
+
+	DROP TABLE legacy_items;
+	DROP TABLE legacy_warehouses;
+	CREATE INDEX items_warehouse_period ON items (warehouse_id, occupied_from, occupied_to)`,
+
+	`CREATE TABLE customers (
+		id           INTEGER PRIMARY KEY,
+		position     INTEGER NOT NULL,
+		name         TEXT NOT NULL,
+		volume_quota REAL NOT NULL CHECK (volume_quota >= 0)
+	);
+	CREATE TABLE customer_warehouse_quotas (
+		customer_id  INTEGER NOT NULL REFERENCES customers (id) ON DELETE CASCADE,
+		warehouse_id INTEGER NOT NULL,
+		volume       REAL NOT NULL CHECK (volume >= 0),
+		PRIMARY KEY (customer_id, warehouse_id)
+	);
+	CREATE TABLE waitlist_items (
+		position         INTEGER PRIMARY KEY,
+		id               INTEGER NOT NULL,
+		name             TEXT NOT NULL,
+		height           REAL NOT NULL CHECK (height > 0),
+		width            REAL NOT NULL CHECK (width > 0),
+		length           REAL NOT NULL CHECK (length > 0),
+		start_date       TEXT NOT NULL,
+		end_date         TEXT NOT NULL CHECK (end_date >= start_date),
+		is_active        INTEGER NOT NULL CHECK (is_active IN (0, 1)),
+		series_id        INTEGER NOT NULL,
+		shipment_id      INTEGER NOT NULL,
+		previous_item_id INTEGER NOT NULL,
+		checked_in_at    TEXT,
+		checked_out_at   TEXT CHECK (checked_out_at >= checked_in_at),
+		buffer_before    INTEGER CHECK (buffer_before >= 0),
+		buffer_after     INTEGER CHECK (buffer_after >= 0),
+		priority         INTEGER NOT NULL,
+		customer_id      INTEGER NOT NULL,
+		version          INTEGER NOT NULL,
+		CHECK ((buffer_before IS NULL) = (buffer_after IS NULL))
+	)`,
+
+	// Every table keeps the rows it held in a history table, stamped with
+	// the times they were recorded and replaced.
+	sqlHistoryMigration(sqlHistoryTables),
+
+	// Audit entries are only ever added, so they need no history. The
+	// warehouse and the item before and after are kept as JSON documents.
+	`CREATE TABLE audit_entries (
+		sequence         INTEGER PRIMARY KEY CHECK (sequence > 0),
+		recorded_at      TEXT NOT NULL,
+		actor            TEXT NOT NULL CHECK (actor <> ''),
+		reason           TEXT NOT NULL,
+		operation        TEXT NOT NULL,
+		warehouse_id     INTEGER NOT NULL,
+		item_id          INTEGER NOT NULL,
+		changes          TEXT NOT NULL CHECK (json_valid(changes)),
+		warehouse_before TEXT CHECK (json_valid(warehouse_before)),
+		warehouse_after  TEXT CHECK (json_valid(warehouse_after)),
+		item_before      TEXT CHECK (json_valid(item_before)),
+		item_after       TEXT CHECK (json_valid(item_after))
+	)`,
+
+	// Check-in and check-out times keep their fractions of a second. Times
+	// written before get nine digits of them so that all compare as text;
+	// the history is rewritten in place rather than recording the change.
+	sqlTimestampMigration([]string{"items", "waitlist_items"}, "checked_in_at", "checked_out_at"),
+}
+
+// sqlHistoryTables are the tables whose rows are kept in a history. A table
+// added later needs a migration of its own that creates its history.
+var sqlHistoryTables = []string{
+	"warehouses", "warehouse_closed_weekdays", "warehouse_closures", "warehouse_capacity_changes",
+	"capacity_blocks", "items", "customers", "customer_warehouse_quotas", "waitlist_items",
+}
+
+// sqlHistoryMigration creates a <table>_history for each table, filled by
+// triggers: every row written is copied with the time held in history_clock
+// as recorded_from, and the copy it replaces gets that time as recorded_to.
+// Rows are matched by their rowid. Rows that exist already are taken as
+// recorded when the migration runs.
+func sqlHistoryMigration(tables []string) string {
+	const clock = `(SELECT recorded_at FROM history_clock)`
+	const migrated = `strftime('%Y-%m-%dT%H:%M:%S', 'now') || '.000000000Z'`
+
+	statements := []string{
+		`CREATE TABLE history_clock (recorded_at TEXT NOT NULL)`,
+		`INSERT INTO history_clock (recorded_at) VALUES (` + migrated + `)`,
+	}
+	for _, table := range tables {
+		history := table + "_history"
+		copyRow := `INSERT INTO ` + history + ` SELECT *, rowid, ` + clock + `, NULL FROM ` + table + ` WHERE rowid = NEW.rowid;`
+		closeRow := `UPDATE ` + history + ` SET recorded_to = ` + clock + ` WHERE row_id = OLD.rowid AND recorded_to IS NULL;`
+		statements = append(statements,
+			`CREATE TABLE `+history+` AS
+			SELECT *, rowid AS row_id, '' AS recorded_from, '' AS recorded_to FROM `+table+` WHERE 0`,
+			`INSERT INTO `+history+` SELECT *, rowid, `+migrated+`, NULL FROM `+table,
+			`CREATE INDEX `+history+`_row ON `+history+` (row_id, recorded_to)`,
+			`CREATE TRIGGER `+table+`_inserted AFTER INSERT ON `+table+` BEGIN `+copyRow+` END`,
+			sqlUpdatedTrigger(table),
+			`CREATE TRIGGER `+table+`_deleted AFTER DELETE ON `+table+` BEGIN `+closeRow+` END`,
+		)
+	}
+	return strings.Join(statements, ";\n")
+}
+
+// sqlUpdatedTrigger creates the trigger that closes the history row of an
+// updated row and copies the new one.
+func sqlUpdatedTrigger(table string) string {
+	const clock = `(SELECT recorded_at FROM history_clock)`
+	history := table + "_history"
+	copyRow := `INSERT INTO ` + history + ` SELECT *, rowid, ` + clock + `, NULL FROM ` + table + ` WHERE rowid = NEW.rowid;`
+	closeRow := `UPDATE ` + history + ` SET recorded_to = ` + clock + ` WHERE row_id = OLD.rowid AND recorded_to IS NULL;`
+	return `CREATE TRIGGER ` + table + `_updated AFTER UPDATE ON ` + table + ` BEGIN ` + closeRow + ` ` + copyRow + ` END`
+}
+
+// sqlTimestampMigration rewrites the UTC times in the columns of the tables
+// and of their histories in sqlTimestampFormat. The update triggers
+// are dropped meanwhile, so that the rewrite is not recorded as a change.
+func sqlTimestampMigration(tables []string, columns ...string) string {
+	var statements []string
+	for _, table := range tables {
+		statements = append(statements, `DROP TRIGGER `+table+`_updated`)
+		for _, target := range []string{table, table + "_history"} {
+			for _, column := range columns {
+				statements = append(statements,
+					`UPDATE `+target+` SET `+column+` = substr(`+column+`, 1, 19) || '.' ||
+						substr(rtrim(substr(`+column+`, 21), 'Z') || '000000000', 1, 9) || 'Z'
+					WHERE length(`+column+`) <> 30`)
+			}
+		}
+		statements = append(statements, sqlUpdatedTrigger(table))
+	}
+	return strings.Join(statements, ";\n")
+}
+
+// sqlDateFormat keeps dates comparable as text, as does sqlTimestampFormat
+// for the times changes were recorded at and items were checked in and out.
+const (
+	sqlDateFormat      = "2006-01-02T15:04:05Z"
+	sqlTimestampFormat = "2006-01-02T15:04:05.000000000Z"
+)
+
+// The columns of a warehouse and of an item, in the order they are scanned
+// and written. Items on the waitlist have the same columns.
+const (
+	sqlWarehouseColumns = `id, height, width, length, buffer_before, buffer_after, commissioned_on, decommissioned_on, version`
+	sqlItemColumns      = `id, name, height, width, length, start_date, end_date, is_active, series_id, shipment_id,
+		previous_item_id, checked_in_at, checked_out_at, buffer_before, buffer_after, priority, customer_id, version`
+	sqlAuditColumns = `sequence, recorded_at, actor, reason, operation, warehouse_id, item_id,
+		changes, warehouse_before, warehouse_after, item_before, item_after`
+)
+
+// sqlQueryer is the part of *sql.DB and *sql.Tx the repository uses.
+type sqlQueryer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// sqlHistoryQueryer reads the tables as they were at knownAt. Every query is
+// prefixed with common table expressions that shadow the tables with the rows
+// of their history that were current then; knownAt is bound to ?1, so the ?
+// placeholders of the query take the arguments that follow it.
+type sqlHistoryQueryer struct {
+	q       sqlQueryer
+	knownAt time.Time
+}
+
+func (h sqlHistoryQueryer) Exec(string, ...any) (sql.Result, error) {
+	return nil, errors.New("the history cannot be changed")
+}
+
+func (h sqlHistoryQueryer) Query(query string, args ...any) (*sql.Rows, error) {
+	return h.q.Query(h.with(query), h.args(args)...)
+}
+
+func (h sqlHistoryQueryer) QueryRow(query string, args ...any) *sql.Row {
+	return h.q.QueryRow(h.with(query), h.args(args)...)
+}
+
+func (h sqlHistoryQueryer) with(query string) string {
+	expressions := make([]string, len(sqlHistoryTables))
+	for i, table := range sqlHistoryTables {
+		expressions[i] = table + ` AS (SELECT * FROM ` + table + `_history
+			WHERE recorded_from <= ?1 AND (recorded_to IS NULL OR recorded_to > ?1))`
+	}
+	return `WITH ` + strings.Join(expressions, ", ") + ` ` + query
+}
+
+func (h sqlHistoryQueryer) args(args []any) []any {
+	return append([]any{h.knownAt.UTC().Format(sqlTimestampFormat)}, args...)
+}
+
+// SQLRepository stores the warehouses, their items, the customers and the
+// waitlist in a relational database, every field in a typed column. Items
+// are kept in their own table together with their buffered period, so range
+// queries only read the items they need. Versions live in their own columns
+// and are compared in the statements that write them. Every row replaced is
+// kept in a history, so the repository can answer as-of queries. Statements
+// use ? placeholders. SQLite only enforces the foreign keys on connections
+// that enable them, such as with the _pragma=foreign_keys(1) parameter, and
+// only waits for the writes of other connections with a busy_timeout.
+type SQLRepository struct {
+	// Now is the clock changes are recorded with in the history; time.Now
+	// when nil.
+	Now func() time.Time
+
+	db *sql.DB
+	tx *sql.Tx
+}
+
+// OpenSQLRepository brings the schema of the database up to date and returns
+// a repository working on it.
+func OpenSQLRepository(db *sql.DB) (*SQLRepository, error) {
+	repository := &SQLRepository{db: db}
+	if err := repository.migrate(); err != nil {
+		return nil, err
+	}
+	return repository, nil
+}
+
+// SchemaVersion returns the number of migrations applied to the database.
+func (r *SQLRepository) SchemaVersion() (int, error) {
+	var version int
+	err := r.queryer().QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
+
+func (r *SQLRepository) migrate() error {
+	_, err := r.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`)
+	if err != nil {
+		return err
+	}
+
+	version, err := r.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if version > len(sqlMigrations) {
+		return errors.New("the database schema is newer than this version supports")
+	}
+
+	for i := version; i < len(sqlMigrations); i++ {
+		tx, err := r.db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(sqlMigrations[i]); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, i+1); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *SQLRepository) queryer() sqlQueryer {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+// transaction runs fn in a transaction, or in the one the repository already
+// works in.
+func (r *SQLRepository) transaction(fn func(q sqlQueryer) error) error {
+	if r.tx != nil {
+		return fn(r.tx)
+	}
+
+	tx, err := r.begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// begin starts a transaction whose changes are recorded in the history at
+// the current time.
+func (r *SQLRepository) begin() (*sql.Tx, error) {
+	now := time.Now
+	if r.Now != nil {
+		now = r.Now
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`UPDATE history_clock SET recorded_at = ?`, now().UTC().Format(sqlTimestampFormat)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return tx, nil
+}
+
+// Record runs apply in one transaction, so that either all of its writes are
+// stored or none.
+func (r *SQLRepository) Record(_ EventType, apply func(repository WarehouseRepository) error) error {
+	if r.tx != nil {
+		return apply(r)
+	}
+
+	tx, err := r.begin()
+	if err != nil {
+		return err
+	}
+	if err := apply(&SQLRepository{Now: r.Now, db: r.db, tx: tx}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *SQLRepository) GetWarehouse(warehouseId int) (Warehouse, error) {
+	return readSQLWarehouseWithItems(r.queryer(), warehouseId)
+}
+
+func (r *SQLRepository) ListWarehouses() ([]Warehouse, error) {
+	return querySQLWarehouses(r.queryer(), 0, "")
+}
+
+func (r *SQLRepository) ListWarehousesOverlapping(startDate, endDate time.Time) ([]Warehouse, error) {
+	return querySQLWarehouses(r.queryer(), 0,
+		`AND i.occupied_from <= ? AND i.occupied_to >= ?`,
+		formatSQLDate(endDate), formatSQLDate(startDate),
+	)
+}
+
+func (r *SQLRepository) SaveWarehouse(warehouse Warehouse) error {
+	return r.transaction(func(q sqlQueryer) error {
+		var exists bool
+		if err := q.QueryRow(`SELECT EXISTS (SELECT 1 FROM warehouses WHERE id = ?)`, warehouse.Id).Scan(&exists); err != nil {
+			return err
+		}
+		var current *Warehouse
+		if exists {
+			existing, err := readSQLWarehouseWithItems(q, warehouse.Id)
+			if err != nil {
+				return err
+			}
+			current = &existing
+		}
+
+		stored, err := storeWarehouse(current, warehouse)
+		if err != nil {
+			return err
+		}
+
+		if current == nil {
+			_, err = q.Exec(
+				`INSERT INTO warehouses (position, `+sqlWarehouseColumns+`)
+				VALUES ((SELECT COALESCE(MAX(position), 0) + 1 FROM warehouses), `+sqlPlaceholders(9)+`)`,
+				sqlWarehouseValues(stored)...,
+			)
+		} else {
+			err = updateSQLWarehouse(q, stored, current.Version)
+		}
+		if err != nil {
+			return err
+		}
+		if err := writeSQLWarehouseDetails(q, stored); err != nil {
+			return err
+		}
+
+		// The buffered periods depend on the warehouse, so its items are
+		// always written again. An item that moved here from a warehouse
+		// that is saved afterwards is taken over right away.
+		if _, err := q.Exec(`DELETE FROM items WHERE warehouse_id = ?`, stored.Id); err != nil {
+			return err
+		}
+		for i, item := range stored.Items {
+			if _, err := q.Exec(`DELETE FROM items WHERE id = ?`, item.ItemId); err != nil {
+				return err
+			}
+			if err := insertSQLItem(q, stored, item, i+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *SQLRepository) RemoveWarehouse(warehouseId int) error {
+	return r.transaction(func(q sqlQueryer) error {
+		if _, err := q.Exec(`DELETE FROM items WHERE warehouse_id = ?`, warehouseId); err != nil {
+			return err
+		}
+		if err := deleteSQLWarehouseDetails(q, warehouseId); err != nil {
+			return err
+		}
+		result, err := q.Exec(`DELETE FROM warehouses WHERE id = ?`, warehouseId)
+		if err != nil {
+			return err
+		}
+		return requireSQLRow(result, ErrWarehouseNotFound)
+	})
+}
+
+func (r *SQLRepository) AddItem(warehouseId int, item Item) error {
+	return r.transaction(func(q sqlQueryer) error {
+		warehouse, err := readSQLWarehouse(q, warehouseId)
+		if err != nil {
+			return err
+		}
+
+		var exists bool
+		if err := q.QueryRow(`SELECT EXISTS (SELECT 1 FROM items WHERE id = ?)`, item.ItemId).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			return ErrItemExists
+		}
+
+		var position int
+		err = q.QueryRow(`SELECT COALESCE(MAX(position), 0) + 1 FROM items WHERE warehouse_id = ?`, warehouseId).Scan(&position)
+		if err != nil {
+			return err
+		}
+		item.Version = 1
+		if err := insertSQLItem(q, warehouse, item, position); err != nil {
+			return err
+		}
+		return advanceSQLWarehouse(q, warehouseId)
+	})
+}
+
+func (r *SQLRepository) UpdateItem(warehouseId int, item Item) error {
+	return r.transaction(func(q sqlQueryer) error {
+		current, err := readSQLWarehouseWithItems(q, warehouseId)
+		if err != nil {
+			return err
+		}
+		stored, err := storeItem(current, item)
+		if err != nil {
+			return err
+		}
+
+		_, itemIndex, _ := findItem([]Warehouse{stored}, item.ItemId)
+		item = stored.Items[itemIndex]
+		from, to := stored.getPossibleBufferedPeriod(item)
+		args := append([]any{formatSQLDate(from), formatSQLDate(to)}, sqlItemValues(item)...)
+		result, err := q.Exec(
+			`UPDATE items SET (occupied_from, occupied_to, `+sqlItemColumns+`) = (`+sqlPlaceholders(len(args))+`)
+			WHERE id = ? AND warehouse_id = ? AND version = ?`,
+			append(args, item.ItemId, warehouseId, item.Version-1)...,
+		)
+		if err != nil {
+			return err
+		}
+		if err := requireSQLRow(result, ErrItemNotFound); err != nil {
+			return err
+		}
+		return advanceSQLWarehouse(q, warehouseId)
+	})
+}
+
+func (r *SQLRepository) RemoveItem(warehouseId, itemId int) error {
+	return r.transaction(func(q sqlQueryer) error {
+		if _, err := readSQLWarehouse(q, warehouseId); err != nil {
+			return err
+		}
+		result, err := q.Exec(`DELETE FROM items WHERE id = ? AND warehouse_id = ?`, itemId, warehouseId)
+		if err != nil {
+			return err
+		}
+		if err := requireSQLRow(result, ErrItemNotFound); err != nil {
+			return err
+		}
+		return advanceSQLWarehouse(q, warehouseId)
+	})
+}
+
+// LoadState reads the customers and the waitlist.
+func (r *SQLRepository) LoadState() (ServiceState, error) {
+	return loadSQLState(r.queryer())
+}
+
+// ListWarehousesAsOf reads the warehouses from the rows of the history that
+// were current at knownAt.
+func (r *SQLRepository) ListWarehousesAsOf(knownAt time.Time) ([]Warehouse, error) {
+	return querySQLWarehouses(sqlHistoryQueryer{q: r.queryer(), knownAt: knownAt}, 0, "")
+}
+
+// LoadStateAsOf reads the customers and the waitlist from the rows of the
+// history that were current at knownAt.
+func (r *SQLRepository) LoadStateAsOf(knownAt time.Time) (ServiceState, error) {
+	return loadSQLState(sqlHistoryQueryer{q: r.queryer(), knownAt: knownAt})
+}
+
+func loadSQLState(q sqlQueryer) (ServiceState, error) {
+	var state ServiceState
+
+	customerIndex := make(map[int]int)
+	err := querySQLRows(q, `SELECT id, name, volume_quota FROM customers ORDER BY position`, nil, func(rows *sql.Rows) error {
+		var customer Customer
+		if err := rows.Scan(&customer.Id, &customer.Name, &customer.VolumeQuota); err != nil {
+			return err
+		}
+		customerIndex[customer.Id] = len(state.Customers)
+		state.Customers = append(state.Customers, customer)
+		return nil
+	})
+	if err != nil {
+		return ServiceState{}, err
+	}
+
+	err = querySQLRows(q, `SELECT customer_id, warehouse_id, volume FROM customer_warehouse_quotas`, nil, func(rows *sql.Rows) error {
+		var customerId, warehouseId int
+		var volume float64
+		if err := rows.Scan(&customerId, &warehouseId, &volume); err != nil {
+			return err
+		}
+		customer := &state.Customers[customerIndex[customerId]]
+		if customer.WarehouseQuotas == nil {
+			customer.WarehouseQuotas = make(map[int]float64)
+		}
+		customer.WarehouseQuotas[warehouseId] = volume
+		return nil
+	})
+	if err != nil {
+		return ServiceState{}, err
+	}
+
+	err = querySQLRows(q, `SELECT `+sqlColumns("i", sqlItemColumns)+` FROM waitlist_items i ORDER BY position`, nil, func(rows *sql.Rows) error {
+		var scan sqlItemScan
+		if err := rows.Scan(scan.targets()...); err != nil {
+			return err
+		}
+		item, err := scan.item()
+		if err != nil {
+			return err
+		}
+		state.Waitlist = append(state.Waitlist, item)
+		return nil
+	})
+	if err != nil {
+		return ServiceState{}, err
+	}
+	return state, nil
+}
+
+// SaveState replaces the customers and the waitlist.
+func (r *SQLRepository) SaveState(state ServiceState) error {
+	return r.transaction(func(q sqlQueryer) error {
+		for _, table := range []string{"customer_warehouse_quotas", "customers", "waitlist_items"} {
+			if _, err := q.Exec(`DELETE FROM ` + table); err != nil {
+				return err
+			}
+		}
+
+		for i, customer := range state.Customers {
+			_, err := q.Exec(
+				`INSERT INTO customers (id, position, name, volume_quota) VALUES (?, ?, ?, ?)`,
+				customer.Id, i+1, customer.Name, customer.VolumeQuota,
+			)
+			if err != nil {
+				return err
+			}
+			for _, warehouseId := range slices.Sorted(maps.Keys(customer.WarehouseQuotas)) {
+				_, err := q.Exec(
+					`INSERT INTO customer_warehouse_quotas (customer_id, warehouse_id, volume) VALUES (?, ?, ?)`,
+					customer.Id, warehouseId, customer.WarehouseQuotas[warehouseId],
+				)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		for i, item := range state.Waitlist {
+			_, err := q.Exec(
+				`INSERT INTO waitlist_items (position, `+sqlItemColumns+`) VALUES (?, `+sqlPlaceholders(18)+`)`,
+				append([]any{i + 1}, sqlItemValues(item)...)...,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// LoadAudit reads the audit entries in the order they were recorded.
+func (r *SQLRepository) LoadAudit() ([]AuditEntry, error) {
+	var entries []AuditEntry
+	err := querySQLRows(r.queryer(), `SELECT `+sqlAuditColumns+` FROM audit_entries ORDER BY sequence`, nil, func(rows *sql.Rows) error {
+		var entry AuditEntry
+		var recordedAt, operation, changes string
+		var warehouseBefore, warehouseAfter, itemBefore, itemAfter sql.NullString
+		err := rows.Scan(
+			&entry.Sequence, &recordedAt, &entry.Actor, &entry.Reason, &operation, &entry.WarehouseId, &entry.ItemId,
+			&changes, &warehouseBefore, &warehouseAfter, &itemBefore, &itemAfter,
+		)
+		if err != nil {
+			return err
+		}
+
+		if entry.RecordedAt, err = time.Parse(sqlTimestampFormat, recordedAt); err != nil {
+			return err
+		}
+		entry.Operation = EventType(operation)
+		if err := json.Unmarshal([]byte(changes), &entry.Changes); err != nil {
+			return err
+		}
+		for _, snapshot := range []struct {
+			column sql.NullString
+			target any
+		}{
+			{warehouseBefore, &entry.WarehouseBefore},
+			{warehouseAfter, &entry.WarehouseAfter},
+			{itemBefore, &entry.ItemBefore},
+			{itemAfter, &entry.ItemAfter},
+		} {
+			if !snapshot.column.Valid {
+				continue
+			}
+			if err := json.Unmarshal([]byte(snapshot.column.String), snapshot.target); err != nil {
+				return err
+			}
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// AppendAudit adds the entries after the ones already stored.
+func (r *SQLRepository) AppendAudit(entries []AuditEntry) error {
+	return r.transaction(func(q sqlQueryer) error {
+		for _, entry := range entries {
+			changes, err := json.Marshal(append([]string{}, entry.Changes...))
+			if err != nil {
+				return err
+			}
+			snapshots := make([]any, 4)
+			for i, snapshot := range []any{entry.WarehouseBefore, entry.WarehouseAfter, entry.ItemBefore, entry.ItemAfter} {
+				if snapshots[i], err = nullableSQLJSON(snapshot); err != nil {
+					return err
+				}
+			}
+
+			_, err = q.Exec(
+				`INSERT INTO audit_entries (`+sqlAuditColumns+`) VALUES (`+sqlPlaceholders(12)+`)`,
+				append([]any{
+					entry.Sequence, entry.RecordedAt.UTC().Format(sqlTimestampFormat), entry.Actor, entry.Reason,
+					string(entry.Operation), entry.WarehouseId, entry.ItemId, string(changes),
+				}, snapshots...)...,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// -------------------------------------------------
+// Reading
+// -------------------------------------------------
+
+// readSQLWarehouse reads the warehouse without its items.
+func readSQLWarehouse(q sqlQueryer, warehouseId int) (Warehouse, error) {
+	var scan sqlWarehouseScan
+	err := q.QueryRow(`SELECT `+sqlColumns("w", sqlWarehouseColumns)+` FROM warehouses w WHERE w.id = ?`, warehouseId).
+		Scan(scan.targets()...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Warehouse{}, ErrWarehouseNotFound
+	}
+	if err != nil {
+		return Warehouse{}, err
+	}
+
+	warehouses := []Warehouse{scan.warehouse}
+	if err := scan.finish(&warehouses[0]); err != nil {
+		return Warehouse{}, err
+	}
+	if err := readSQLWarehouseDetails(q, warehouses, warehouseId); err != nil {
+		return Warehouse{}, err
+	}
+	return warehouses[0], nil
+}
+
+func readSQLWarehouseWithItems(q sqlQueryer, warehouseId int) (Warehouse, error) {
+	warehouses, err := querySQLWarehouses(q, warehouseId, "")
+	if err != nil {
+		return Warehouse{}, err
+	}
+	if len(warehouses) == 0 {
+		return Warehouse{}, ErrWarehouseNotFound
+	}
+	return warehouses[0], nil
+}
+
+// querySQLWarehouses reads the warehouse with the given id, or all of them
+// for id 0, together with their items in a single join. itemCondition
+// narrows down the items that are read, its arguments are passed in args.
+func querySQLWarehouses(q sqlQueryer, warehouseId int, itemCondition string, args ...any) ([]Warehouse, error) {
+	query := `SELECT ` + sqlColumns("w", sqlWarehouseColumns) + `, ` + sqlColumns("i", sqlItemColumns) + `
+		FROM warehouses w
+		LEFT JOIN items i ON i.warehouse_id = w.id ` + itemCondition
+	if warehouseId != 0 {
+		query += ` WHERE w.id = ?`
+		args = append(args, warehouseId)
+	}
+	query += ` ORDER BY w.position, i.position`
+
+	var warehouses []Warehouse
+	err := querySQLRows(q, query, args, func(rows *sql.Rows) error {
+		var warehouseScan sqlWarehouseScan
+		var itemScan sqlItemScan
+		if err := rows.Scan(append(warehouseScan.targets(), itemScan.targets()...)...); err != nil {
+			return err
+		}
+
+		if len(warehouses) == 0 || warehouses[len(warehouses)-1].Id != warehouseScan.warehouse.Id {
+			warehouse := warehouseScan.warehouse
+			if err := warehouseScan.finish(&warehouse); err != nil {
+				return err
+			}
+			warehouses = append(warehouses, warehouse)
+		}
+		if !itemScan.id.Valid {
+			return nil
+		}
+		item, err := itemScan.item()
+		if err != nil {
+			return err
+		}
+		warehouse := &warehouses[len(warehouses)-1]
+		warehouse.Items = append(warehouse.Items, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := readSQLWarehouseDetails(q, warehouses, warehouseId); err != nil {
+		return nil, err
+	}
+	return warehouses, nil
+}
+
+// readSQLWarehouseDetails reads the calendars, capacity schedules and blocks
+// of the warehouses with one query per table. warehouseId limits the queries
+// to one warehouse as in querySQLWarehouses.
+func readSQLWarehouseDetails(q sqlQueryer, warehouses []Warehouse, warehouseId int) error {
+	byId := make(map[int]*Warehouse, len(warehouses))
+	for i := range warehouses {
+		byId[warehouses[i].Id] = &warehouses[i]
+	}
+
+	filter, args := "", []any(nil)
+	if warehouseId != 0 {
+		filter, args = ` WHERE warehouse_id = ?`, []any{warehouseId}
+	}
+	order := ` ORDER BY warehouse_id, position`
+
+	err := querySQLRows(q, `SELECT warehouse_id, weekday FROM warehouse_closed_weekdays`+filter+order, args, func(rows *sql.Rows) error {
+		var id int
+		var weekday time.Weekday
+		if err := rows.Scan(&id, &weekday); err != nil {
+			return err
+		}
+		if warehouse := byId[id]; warehouse != nil {
+			warehouse.Calendar.ClosedWeekdays = append(warehouse.Calendar.ClosedWeekdays, weekday)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = querySQLRows(q, `SELECT warehouse_id, start_date, end_date FROM warehouse_closures`+filter+order, args, func(rows *sql.Rows) error {
+		var id int
+		var start, end string
+		if err := rows.Scan(&id, &start, &end); err != nil {
+			return err
+		}
+		var closure DateRange
+		if err := parseSQLDates(&closure.Start, start, &closure.End, end); err != nil {
+			return err
+		}
+		if warehouse := byId[id]; warehouse != nil {
+			warehouse.Calendar.Closures = append(warehouse.Calendar.Closures, closure)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = querySQLRows(q,
+		`SELECT warehouse_id, effective_from, effective_to, room_height, room_width, room_length, volume_adjustment
+		FROM warehouse_capacity_changes`+filter+order,
+		args, func(rows *sql.Rows) error {
+			var id int
+			var from string
+			var to sql.NullString
+			var height, width, length sql.NullFloat64
+			var change CapacityChange
+			if err := rows.Scan(&id, &from, &to, &height, &width, &length, &change.VolumeAdjustment); err != nil {
+				return err
+			}
+			if err := parseSQLDates(&change.EffectiveFrom, from, &change.EffectiveTo, to.String); err != nil {
+				return err
+			}
+			if height.Valid {
+				change.Room = &ThreeDRoom{Height: height.Float64, Width: width.Float64, Length: length.Float64}
+			}
+			if warehouse := byId[id]; warehouse != nil {
+				warehouse.CapacitySchedule = append(warehouse.CapacitySchedule, change)
+			}
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+
+	return querySQLRows(q,
+		`SELECT warehouse_id, id, customer_id, volume, start_date, end_date FROM capacity_blocks`+filter+order,
+		args, func(rows *sql.Rows) error {
+			var id int
+			var start, end string
+			var block CapacityBlock
+			if err := rows.Scan(&id, &block.Id, &block.CustomerId, &block.Volume, &start, &end); err != nil {
+				return err
+			}
+			if err := parseSQLDates(&block.StartDate, start, &block.EndDate, end); err != nil {
+				return err
+			}
+			if warehouse := byId[id]; warehouse != nil {
+				warehouse.Blocks = append(warehouse.Blocks, block)
+			}
+			return nil
+		})
+}
+
+// querySQLRows runs the query and calls scan for every row.
+func querySQLRows(q sqlQueryer, query string, args []any, scan func(rows *sql.Rows) error) error {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// sqlWarehouseScan receives the columns of a warehouse.
+type sqlWarehouseScan struct {
+	warehouse                        Warehouse
+	commissionedOn, decommissionedOn sql.NullString
+}
+
+func (s *sqlWarehouseScan) targets() []any {
+	w := &s.warehouse
+	return []any{
+		&w.Id, &w.MaxCapacity.Height, &w.MaxCapacity.Width, &w.MaxCapacity.Length,
+		&w.Buffer.Before, &w.Buffer.After, &s.commissionedOn, &s.decommissionedOn, &w.Version,
+	}
+}
+
+// finish sets the dates of the calendar of warehouse.
+func (s *sqlWarehouseScan) finish(warehouse *Warehouse) error {
+	return parseSQLDates(
+		&warehouse.Calendar.CommissionedOn, s.commissionedOn.String,
+		&warehouse.Calendar.DecommissionedOn, s.decommissionedOn.String,
+	)
+}
+
+// sqlItemScan receives the columns of an item. All of them are NULL in the
+// rows of a join that found no item.
+type sqlItemScan struct {
+	id, seriesId, shipmentId, previousItemId sql.NullInt64
+	priority, customerId, version            sql.NullInt64
+	bufferBefore, bufferAfter                sql.NullInt64
+	name                                     sql.NullString
+	startDate, endDate                       sql.NullString
+	checkedInAt, checkedOutAt                sql.NullString
+	height, width, length                    sql.NullFloat64
+	isActive                                 sql.NullBool
+}
+
+func (s *sqlItemScan) targets() []any {
+	return []any{
+		&s.id, &s.name, &s.height, &s.width, &s.length, &s.startDate, &s.endDate, &s.isActive,
+		&s.seriesId, &s.shipmentId, &s.previousItemId, &s.checkedInAt, &s.checkedOutAt,
+		&s.bufferBefore, &s.bufferAfter, &s.priority, &s.customerId, &s.version,
+	}
+}
+
+func (s *sqlItemScan) item() (Item, error) {
+	item := Item{
+		ItemId:         int(s.id.Int64),
+		ItemName:       s.name.String,
+		ItemHeight:     s.height.Float64,
+		ItemWidth:      s.width.Float64,
+		ItemLength:     s.length.Float64,
+		IsActive:       s.isActive.Bool,
+		SeriesId:       int(s.seriesId.Int64),
+		ShipmentId:     int(s.shipmentId.Int64),
+		PreviousItemId: int(s.previousItemId.Int64),
+		Priority:       int(s.priority.Int64),
+		CustomerId:     int(s.customerId.Int64),
+		Version:        int(s.version.Int64),
+	}
+	if s.bufferBefore.Valid {
+		item.Buffer = &Buffer{Before: int(s.bufferBefore.Int64), After: int(s.bufferAfter.Int64)}
+	}
+	err := parseSQLDates(
+		&item.StartDate, s.startDate.String,
+		&item.EndDate, s.endDate.String,
+	)
+	if err != nil {
+		return item, err
+	}
+	err = parseSQLTimestamps(
+		&item.CheckedInAt, s.checkedInAt.String,
+		&item.CheckedOutAt, s.checkedOutAt.String,
+	)
+	return item, err
+}
+
+// parseSQLDates parses pairs of a destination and a date; an empty date,
+// read from a NULL column, leaves the destination zero.
+func parseSQLDates(pairs ...any) error {
+	return parseSQLTimes(sqlDateFormat, pairs)
+}
+
+// parseSQLTimestamps parses pairs of a destination and a timestamp like
+// parseSQLDates.
+func parseSQLTimestamps(pairs ...any) error {
+	return parseSQLTimes(sqlTimestampFormat, pairs)
+}
+
+func parseSQLTimes(layout string, pairs []any) error {
+	for i := 0; i < len(pairs); i += 2 {
+		value := pairs[i+1].(string)
+		if value == "" {
+			continue
+		}
+		date, err := time.Parse(layout, value)
+		if err != nil {
+			return err
+		}
+		*pairs[i].(*time.Time) = date
+	}
+	return nil
+}
+
+// -------------------------------------------------
+// Writing
+// -------------------------------------------------
+
+func sqlWarehouseValues(warehouse Warehouse) []any {
+	return []any{
+		warehouse.Id, warehouse.MaxCapacity.Height, warehouse.MaxCapacity.Width, warehouse.MaxCapacity.Length,
+		warehouse.Buffer.Before, warehouse.Buffer.After,
+		nullableSQLDate(warehouse.Calendar.CommissionedOn), nullableSQLDate(warehouse.Calendar.DecommissionedOn),
+		warehouse.Version,
+	}
+}
+
+func sqlItemValues(item Item) []any {
+	var bufferBefore, bufferAfter any
+	if item.Buffer != nil {
+		bufferBefore, bufferAfter = item.Buffer.Before, item.Buffer.After
+	}
+	return []any{
+		item.ItemId, item.ItemName, item.ItemHeight, item.ItemWidth, item.ItemLength,
+		formatSQLDate(item.StartDate), formatSQLDate(item.EndDate), item.IsActive,
+		item.SeriesId, item.ShipmentId, item.PreviousItemId,
+		nullableSQLTimestamp(item.CheckedInAt), nullableSQLTimestamp(item.CheckedOutAt),
+		bufferBefore, bufferAfter, item.Priority, item.CustomerId, item.Version,
+	}
+}
+
+// updateSQLWarehouse writes the warehouse only while it is still at the
+// version it was read at.
+func updateSQLWarehouse(q sqlQueryer, warehouse Warehouse, expected int) error {
+	result, err := q.Exec(
+		`UPDATE warehouses SET (`+sqlWarehouseColumns+`) = (`+sqlPlaceholders(9)+`) WHERE id = ? AND version = ?`,
+		append(sqlWarehouseValues(warehouse), warehouse.Id, expected)...,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		current, err := readSQLWarehouseWithItems(q, warehouse.Id)
+		if err != nil {
+			return err
+		}
+		return &ConflictError{
+			WarehouseId:     warehouse.Id,
+			ExpectedVersion: expected,
+			ActualVersion:   current.Version,
+			Current:         current,
+		}
+	}
+	return nil
+}
+
+func deleteSQLWarehouseDetails(q sqlQueryer, warehouseId int) error {
+	for _, table := range []string{"warehouse_closed_weekdays", "warehouse_closures", "warehouse_capacity_changes", "capacity_blocks"} {
+		if _, err := q.Exec(`DELETE FROM `+table+` WHERE warehouse_id = ?`, warehouseId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSQLWarehouseDetails replaces the calendar, capacity schedule and
+// blocks of the warehouse.
+func writeSQLWarehouseDetails(q sqlQueryer, warehouse Warehouse) error {
+	if err := deleteSQLWarehouseDetails(q, warehouse.Id); err != nil {
+		return err
+	}
+
+	for i, weekday := range warehouse.Calendar.ClosedWeekdays {
+		_, err := q.Exec(
+			`INSERT INTO warehouse_closed_weekdays (warehouse_id, position, weekday) VALUES (?, ?, ?)`,
+			warehouse.Id, i+1, int(weekday),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	for i, closure := range warehouse.Calendar.Closures {
+		_, err := q.Exec(
+			`INSERT INTO warehouse_closures (warehouse_id, position, start_date, end_date) VALUES (?, ?, ?, ?)`,
+			warehouse.Id, i+1, formatSQLDate(closure.Start), formatSQLDate(closure.End),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	for i, change := range warehouse.CapacitySchedule {
+		var height, width, length any
+		if change.Room != nil {
+			height, width, length = change.Room.Height, change.Room.Width, change.Room.Length
+		}
+		_, err := q.Exec(
+			`INSERT INTO warehouse_capacity_changes
+			(warehouse_id, position, effective_from, effective_to, room_height, room_width, room_length, volume_adjustment)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			warehouse.Id, i+1, formatSQLDate(change.EffectiveFrom), nullableSQLDate(change.EffectiveTo),
+			height, width, length, change.VolumeAdjustment,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	for i, block := range warehouse.Blocks {
+		_, err := q.Exec(
+			`INSERT INTO capacity_blocks (warehouse_id, position, id, customer_id, volume, start_date, end_date)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			warehouse.Id, i+1, block.Id, block.CustomerId, block.Volume,
+			formatSQLDate(block.StartDate), formatSQLDate(block.EndDate),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func advanceSQLWarehouse(q sqlQueryer, warehouseId int) error {
+	_, err := q.Exec(`UPDATE warehouses SET version = version + 1 WHERE id = ?`, warehouseId)
+	return err
+}
+
+func insertSQLItem(q sqlQueryer, warehouse Warehouse, item Item, position int) error {
+	from, to := warehouse.getPossibleBufferedPeriod(item)
+	args := append([]any{warehouse.Id, position, formatSQLDate(from), formatSQLDate(to)}, sqlItemValues(item)...)
+	_, err := q.Exec(
+		`INSERT INTO items (warehouse_id, position, occupied_from, occupied_to, `+sqlItemColumns+`)
+		VALUES (`+sqlPlaceholders(len(args))+`)`,
+		args...,
+	)
+	return err
+}
+
+func requireSQLRow(result sql.Result, notFound error) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notFound
+	}
+	return nil
+}
+
+// sqlColumns qualifies each of the columns with the table alias.
+func sqlColumns(alias, columns string) string {
+	fields := strings.FieldsFunc(columns, func(r rune) bool { return r == ',' || r == ' ' || r == '\n' || r == '\t' })
+	for i, field := range fields {
+		fields[i] = alias + "." + field
+	}
+	return strings.Join(fields, ", ")
+}
+
+func sqlPlaceholders(count int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", count), ", ")
+}
+
+func formatSQLDate(date time.Time) string {
+	return date.UTC().Format(sqlDateFormat)
+}
+
+// nullableSQLJSON stores the warehouse or item as a JSON document and a nil
+// one as NULL.
+func nullableSQLJSON(value any) (any, error) {
+	switch v := value.(type) {
+	case *Warehouse:
+		if v == nil {
+			return nil, nil
+		}
+	case *Item:
+		if v == nil {
+			return nil, nil
+		}
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// nullableSQLDate stores a zero date as NULL.
+func nullableSQLDate(date time.Time) any {
+	if date.IsZero() {
+		return nil
+	}
+	return formatSQLDate(date)
+}
+
+// nullableSQLTimestamp stores a zero time as NULL.
+func nullableSQLTimestamp(timestamp time.Time) any {
+	if timestamp.IsZero() {
+		return nil
+	}
+	return timestamp.UTC().Format(sqlTimestampFormat)
+}
@@ -0,0 +1,228 @@
+package warehouse
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func initSQLRepositorySteps(ctx *godog.ScenarioContext) {
+	ctx.After(func(ctx context.Context, sc *godog.Scenario, err error) (context.Context, error) {
+		if tc.database != nil {
+			tc.database.Close()
+		}
+		if tc.databasePath != "" {
+			os.RemoveAll(filepath.Dir(tc.databasePath))
+		}
+		return ctx, nil
+	})
+
+	// GIVEN
+	ctx.Given(`^the warehouses are stored in a database$`, theWarehousesAreStoredInADatabase)
+	ctx.Given(`^a database at schema version 5 holds a warehouse with every field set$`, aDatabaseAtSchemaVersion5HoldsAWarehouseWithEveryFieldSet)
+
+	// WHEN
+	ctx.When(`^I reopen the database$`, iReopenTheDatabase)
+	ctx.When(`^I save a warehouse with every field set$`, iSaveAWarehouseWithEveryFieldSet)
+	ctx.When(`^I store an item in warehouse (\d+) that ends before it starts$`, iStoreAnItemInWarehouseThatEndsBeforeItStarts)
+
+	// THEN
+	ctx.Then(`^the database schema should be at version (\d+)$`, theDatabaseSchemaShouldBeAtVersion)
+	ctx.Then(`^range queries should use the index "([^"]*)"$`, rangeQueriesShouldUseTheIndex)
+	ctx.Then(`^the warehouse should be read back unchanged$`, theWarehouseShouldBeReadBackUnchanged)
+}
+
+// -------------------
+// GIVEN Steps (Arrange)
+// -------------------
+
+func theWarehousesAreStoredInADatabase(ctx context.Context) {
+	t := godog.T(ctx)
+
+	dir, err := os.MkdirTemp("", "warehouses")
+	require.NoError(t, err, "unexpected error")
+	tc.databasePath = filepath.Join(dir, "warehouses.db")
+
+	openDatabase(t)
+}
+
+// aDatabaseAtSchemaVersion5HoldsAWarehouseWithEveryFieldSet writes the
+// warehouse the way the schema before the typed columns kept it.
+func aDatabaseAtSchemaVersion5HoldsAWarehouseWithEveryFieldSet(ctx context.Context) {
+	t := godog.T(ctx)
+
+	dir, err := os.MkdirTemp("", "warehouses")
+	require.NoError(t, err, "unexpected error")
+	tc.databasePath = filepath.Join(dir, "warehouses.db")
+
+	db, err := sql.Open("sqlite", tc.databasePath)
+	require.NoError(t, err, "unexpected error")
+	tc.database = db
+
+	_, err = db.Exec(`CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY)`)
+	require.NoError(t, err, "unexpected error")
+	for i, migration := range sqlMigrations[:5] {
+		_, err := db.Exec(migration)
+		require.NoError(t, err, "unexpected error")
+		_, err = db.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, i+1)
+		require.NoError(t, err, "unexpected error")
+	}
+
+	tc.storedWarehouse = warehouseWithEveryFieldSet()
+	warehouse := cloneWarehouse(tc.storedWarehouse)
+	warehouse.Items = nil
+	data, err := json.Marshal(warehouse)
+	require.NoError(t, err, "unexpected error")
+	_, err = db.Exec(`INSERT INTO warehouses (id, position, data) VALUES (?, 1, ?)`, warehouse.Id, string(data))
+	require.NoError(t, err, "unexpected error")
+
+	for i, item := range tc.storedWarehouse.Items {
+		data, err := json.Marshal(item)
+		require.NoError(t, err, "unexpected error")
+		from, to := tc.storedWarehouse.getPossibleBufferedPeriod(item)
+		_, err = db.Exec(
+			`INSERT INTO items (id, warehouse_id, position, occupied_from, occupied_to, data) VALUES (?, ?, ?, ?, ?, ?)`,
+			item.ItemId, warehouse.Id, i+1, formatSQLDate(from), formatSQLDate(to), string(data),
+		)
+		require.NoError(t, err, "unexpected error")
+	}
+}
+
+func openDatabase(t godog.TestingT) {
+	var err error
+	tc.database, err = sql.Open("sqlite", tc.databasePath+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	require.NoError(t, err, "unexpected error")
+
+	repository, err := OpenSQLRepository(tc.database)
+	require.NoError(t, err, "unexpected error")
+	repository.Now = scenarioClock
+	service, err := NewWarehouseStorageService(repository)
+	require.NoError(t, err, "unexpected error")
+	service.Now = tc.service.shared().Now
+	tc.service = *service
+}
+
+// warehouseWithEveryFieldSet returns a warehouse in which every field of the
+// warehouse and of its items differs from its zero value.
+func warehouseWithEveryFieldSet() Warehouse {
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	checkedOut := time.Date(2025, 1, 8, 10, 30, 15, 500000000, time.UTC)
+	return Warehouse{
+		Id:          3,
+		MaxCapacity: ThreeDRoom{Height: 2, Width: 3, Length: 4},
+		Buffer:      Buffer{Before: 1, After: 2},
+		Calendar: OperatingCalendar{
+			CommissionedOn:   day(1),
+			DecommissionedOn: day(31),
+			ClosedWeekdays:   []time.Weekday{time.Sunday, time.Saturday},
+			Closures:         []DateRange{{Start: day(20), End: day(22)}},
+		},
+		CapacitySchedule: []CapacityChange{
+			{EffectiveFrom: day(5), EffectiveTo: day(6), Room: &ThreeDRoom{Height: 1, Width: 3, Length: 4}},
+			{EffectiveFrom: day(10), VolumeAdjustment: -2.5},
+		},
+		Blocks: []CapacityBlock{{Id: 4, CustomerId: 7, Volume: 5, StartDate: day(2), EndDate: day(8)}},
+		Items: []Item{
+			{
+				ItemId: 11, ItemName: "Pallet", ItemHeight: 1, ItemWidth: 1.5, ItemLength: 2,
+				StartDate: day(3), EndDate: day(9), IsActive: true, SeriesId: 2,
+				ShipmentId: 5, PreviousItemId: 10, CheckedInAt: day(3), CheckedOutAt: checkedOut,
+				Buffer: &Buffer{Before: 0, After: 1}, Priority: 4, CustomerId: 7, Version: 1,
+			},
+			{
+				ItemId: 12, ItemName: "Crate", ItemHeight: 0.5, ItemWidth: 0.5, ItemLength: 0.5,
+				StartDate: day(12), EndDate: day(12), Version: 1,
+			},
+		},
+		Version: 1,
+	}
+}
+
+// -------------------
+// WHEN Steps (Act)
+// -------------------
+
+func iReopenTheDatabase(ctx context.Context) {
+	t := godog.T(ctx)
+
+	require.NoError(t, tc.database.Close(), "unexpected error")
+	openDatabase(t)
+}
+
+func iSaveAWarehouseWithEveryFieldSet(ctx context.Context) {
+	t := godog.T(ctx)
+
+	tc.storedWarehouse = warehouseWithEveryFieldSet()
+	require.NoError(t, tc.service.Repository.SaveWarehouse(tc.storedWarehouse), "unexpected error")
+}
+
+func iStoreAnItemInWarehouseThatEndsBeforeItStarts(ctx context.Context, warehouseId int) {
+	t := godog.T(ctx)
+
+	tc.databaseErr = tc.service.Repository.AddItem(warehouseId, Item{
+		ItemId:     1,
+		ItemHeight: 1,
+		ItemWidth:  1,
+		ItemLength: 1,
+		StartDate:  parseDate(t, "2025-01-10"),
+		EndDate:    parseDate(t, "2025-01-09"),
+	})
+}
+
+// -------------------
+// THEN Steps (Assert)
+// -------------------
+
+func theDatabaseSchemaShouldBeAtVersion(ctx context.Context, version int) {
+	t := godog.T(ctx)
+
+	actual, err := tc.service.Repository.(*SQLRepository).SchemaVersion()
+	assert.NoError(t, err, "unexpected error")
+	assert.Equal(t, version, actual, "schema version mismatch")
+}
+
+func rangeQueriesShouldUseTheIndex(ctx context.Context, index string) {
+	t := godog.T(ctx)
+
+	rows, err := tc.database.Query(
+		`EXPLAIN QUERY PLAN SELECT w.id, i.id FROM warehouses w
+		LEFT JOIN items i ON i.warehouse_id = w.id AND i.occupied_from <= ? AND i.occupied_to >= ?
+		ORDER BY w.position, i.position`,
+		"2025-01-08T00:00:00Z", "2025-01-06T00:00:00Z",
+	)
+	require.NoError(t, err, "unexpected error")
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	require.NoError(t, err, "unexpected error")
+
+	var plan []string
+	for rows.Next() {
+		values := make([]any, len(columns))
+		for i := range values {
+			values[i] = new(any)
+		}
+		require.NoError(t, rows.Scan(values...), "unexpected error")
+		detail := *values[len(values)-1].(*any)
+		plan = append(plan, detail.(string))
+	}
+
+	assert.Contains(t, strings.Join(plan, "\n"), index, "query plan does not use the index")
+}
+
+func theWarehouseShouldBeReadBackUnchanged(ctx context.Context) {
+	t := godog.T(ctx)
+
+	warehouse, err := tc.service.Repository.GetWarehouse(tc.storedWarehouse.Id)
+	assert.NoError(t, err, "unexpected error")
+	assert.Equal(t, tc.storedWarehouse, warehouse, "warehouse mismatch")
+}
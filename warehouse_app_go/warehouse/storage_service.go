@@ -1,58 +1,450 @@
 package warehouse
 
 import (
-	"errors"
+	"reflect"
 	"time"
 )
 
 type WarehouseStorageService struct {
-	Warehouses []Warehouse
+	Repository WarehouseRepository
+	Customers  []Customer
+	Waitlist   []Item
+	Now        func() time.Time
+
+	// Audit, when set, records every change together with the actor and
+	// the reason given to As. Changes made without an actor are refused.
+	Audit *AuditLog
+
+	// parent is the service a view made by As reads and changes the
+	// repository, customers, waitlist and audit log of.
+	parent *WarehouseStorageService
+	actor  string
+	reason string
+}
+
+// shared returns the service holding the state the service works on: the
+// parent of a view, the service itself otherwise.
+func (s *WarehouseStorageService) shared() *WarehouseStorageService {
+	if s.parent != nil {
+		return s.parent
+	}
+	return s
+}
+
+func (s WarehouseStorageService) now() time.Time {
+	if now := s.shared().Now; now != nil {
+		return now()
+	}
+	return time.Now()
+}
+
+// NewWarehouseStorageService returns a service on the repository, starting
+// with the customers and the waitlist the repository keeps. A repository
+// holding audit entries keeps being audited.
+func NewWarehouseStorageService(repository WarehouseRepository) (*WarehouseStorageService, error) {
+	state, err := loadState(repository)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := loadAudit(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	service := &WarehouseStorageService{
+		Repository: repository,
+		Customers:  state.Customers,
+		Waitlist:   state.Waitlist,
+	}
+	if len(entries) > 0 {
+		service.Audit = &AuditLog{entries: entries}
+	}
+	return service, nil
+}
+
+// SetCustomers replaces the customers of the service and stores them with
+// the warehouses.
+func (service *WarehouseStorageService) SetCustomers(customers []Customer) error {
+	shared := service.shared()
+	err := service.write(CustomersChanged, func(repository WarehouseRepository) error {
+		return saveState(repository, ServiceState{Customers: customers, Waitlist: shared.Waitlist})
+	})
+	if err != nil {
+		return err
+	}
+	shared.Customers = customers
+	return nil
+}
+
+// repository returns the storage of the service, starting with an empty
+// in-memory one when none has been set.
+func (s *WarehouseStorageService) repository() WarehouseRepository {
+	s = s.shared()
+	if s.Repository == nil {
+		s.Repository = NewInMemoryRepository(nil)
+	}
+	return s.Repository
 }
 
 // -------------------------------------------------
 // FindAvailableWarehouse
 // -------------------------------------------------
-func (s WarehouseStorageService) FindAvailableWarehouse(
+
+// FindAvailableWarehouse returns the first warehouse, in stored order, that
+// can hold the volume on every day of the range. Warehouses without enough
+// space are skipped; an error is only returned when none of them fits.
+func (s *WarehouseStorageService) FindAvailableWarehouse(
 	startDate, endDate time.Time,
 	requiredHeight, requiredWidth, requiredLength float64,
 ) (int, error) {
 
-	if len(s.Warehouses) == 0 {
-		return -1, errors.New("no warehouses available")
+	warehouses, err := s.repository().ListWarehouses()
+	if err != nil {
+		return -1, err
+	}
+
+	if err := s.validateStay(warehouses, startDate, endDate, requiredHeight, requiredWidth, requiredLength); err != nil {
+		return -1, err
+	}
+
+	return s.findAvailable(warehouses, Item{
+		ItemHeight: requiredHeight,
+		ItemWidth:  requiredWidth,
+		ItemLength: requiredLength,
+		StartDate:  startDate,
+		EndDate:    endDate,
+		IsActive:   true,
+	})
+}
+
+func (s WarehouseStorageService) findAvailable(warehouses []Warehouse, item Item) (int, error) {
+	index := s.findWarehouseIndex(warehouses, item)
+	if index == -1 {
+		return -1, s.unavailableError(warehouses, item)
+	}
+
+	return warehouses[index].Id, nil
+}
+
+func (s WarehouseStorageService) validateStay(
+	warehouses []Warehouse,
+	startDate, endDate time.Time,
+	requiredHeight, requiredWidth, requiredLength float64,
+) error {
+
+	if len(warehouses) == 0 {
+		return ErrNoWarehouses
 	}
 
 	if requiredHeight <= 0 || requiredWidth <= 0 || requiredLength <= 0 {
-		return -1, errors.New("the 3D model has invalid dimensions (zero or negative)")
+		return ErrInvalidDimensions
 	}
 
 	if startDate.After(endDate) {
-		return -1, errors.New("start date cannot be later than end date")
+		return ErrInvalidStay
 	}
 
-	if startDate.Before(time.Now()) {
-		return -1, errors.New("start date cannot be in the past")
+	if startDate.Before(s.now()) {
+		return ErrStartInPast
 	}
 
-	requiredVolume := requiredHeight * requiredWidth * requiredLength
+	return nil
+}
 
-	for _, warehouse := range s.Warehouses {
-		warehouseVolume := warehouse.GetWarehouseVolume()
-		canAccommodate := true
+func (s WarehouseStorageService) unavailableError(warehouses []Warehouse, item Item) error {
+	canOperate := false
+	for _, warehouse := range warehouses {
+		if warehouse.canAccommodate(item, s.now()) {
+			return ErrQuotaExceeded
+		}
+		canOperate = canOperate || warehouse.canOperate(item, s.now())
+	}
+	if canOperate {
+		return ErrNoCapacity
+	}
+	return ErrNoOpenWarehouse
+}
 
-		for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
-			occupiedVolume := warehouse.GetVolumeOccupiedOnDay(day)
-			if occupiedVolume+requiredVolume > warehouseVolume {
-				canAccommodate = false
-				return -1, errors.New("required volume cannot be accommodated within the specified dates")
+func (s WarehouseStorageService) findWarehouseIndex(warehouses []Warehouse, item Item) int {
+	for i, warehouse := range warehouses {
+		if s.canPlace(warehouses, warehouse, item, 0) {
+			return i
+		}
+	}
+	return -1
+}
+
+// -------------------------------------------------
+// Reserve
+// -------------------------------------------------
+func (service *WarehouseStorageService) Reserve(item Item) (int, error) {
+	warehouseId, _, err := service.reserve(item)
+	return warehouseId, err
+}
+
+// reserve stores the item in the first warehouse that can hold it and
+// returns the id of the warehouse and the item as it was stored.
+func (service *WarehouseStorageService) reserve(item Item) (int, Item, error) {
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return -1, Item{}, err
+	}
+
+	if err := service.validateStay(warehouses, item.StartDate, item.EndDate, item.ItemHeight, item.ItemWidth, item.ItemLength); err != nil {
+		return -1, Item{}, err
+	}
+
+	if _, err := service.findCustomer(item.CustomerId); err != nil {
+		return -1, Item{}, err
+	}
+
+	index := service.findWarehouseIndex(warehouses, item)
+	if index == -1 {
+		return -1, Item{}, service.unavailableError(warehouses, item)
+	}
+
+	allocated := item.ItemId == 0
+	if allocated {
+		item.ItemId = nextItemId(warehouses)
+	} else if _, _, exists := findItem(warehouses, item.ItemId); exists {
+		return -1, Item{}, ErrItemExists
+	}
+	item.IsActive = true
+
+	err = service.write(ItemReserved, func(repository WarehouseRepository) error {
+		if err := expectVersion(repository, warehouses[index]); err != nil {
+			return err
+		}
+		if allocated {
+			if err := expectNewItems(repository, item); err != nil {
+				return err
 			}
 		}
+		return repository.AddItem(warehouses[index].Id, item)
+	})
+	if err != nil {
+		return -1, Item{}, err
+	}
 
-		if canAccommodate {
-			return warehouse.Id, nil
+	stored, _, err := service.GetItem(item.ItemId)
+	if err != nil {
+		return -1, Item{}, err
+	}
+	return warehouses[index].Id, stored, nil
+}
+
+// -------------------------------------------------
+// AddWarehouse
+// -------------------------------------------------
+
+// AddWarehouse stores a new warehouse and returns its id. A zero id is
+// replaced by the next free one.
+func (service *WarehouseStorageService) AddWarehouse(warehouse Warehouse) (int, error) {
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return -1, err
+	}
+
+	room := warehouse.MaxCapacity
+	if room.Height <= 0 || room.Width <= 0 || room.Length <= 0 {
+		return -1, ErrInvalidDimensions
+	}
+
+	if warehouse.Id == 0 {
+		for _, existing := range warehouses {
+			warehouse.Id = max(warehouse.Id, existing.Id)
 		}
+		warehouse.Id++
+	} else if _, exists := findWarehouse(warehouses, warehouse.Id); exists {
+		return -1, ErrWarehouseExists
 	}
 
-	return -1, nil
+	err = service.write(WarehouseCreated, func(repository WarehouseRepository) error {
+		return repository.SaveWarehouse(warehouse)
+	})
+	if err != nil {
+		return -1, err
+	}
+	return warehouse.Id, nil
+}
+
+// -------------------------------------------------
+// GetWarehouse / UpdateWarehouse / RemoveWarehouse
+// -------------------------------------------------
+func (service *WarehouseStorageService) GetWarehouse(warehouseId int) (Warehouse, error) {
+	return service.repository().GetWarehouse(warehouseId)
+}
+
+// UpdateWarehouse stores the edited warehouse and returns it at its new
+// version. The edit must be based on the stored version, otherwise a
+// ConflictError tells what changed in the meantime.
+func (service *WarehouseStorageService) UpdateWarehouse(warehouse Warehouse) (Warehouse, error) {
+	room := warehouse.MaxCapacity
+	if room.Height <= 0 || room.Width <= 0 || room.Length <= 0 {
+		return Warehouse{}, ErrInvalidDimensions
+	}
+
+	err := service.write(WarehouseSaved, func(repository WarehouseRepository) error {
+		if _, err := repository.GetWarehouse(warehouse.Id); err != nil {
+			return err
+		}
+		return repository.SaveWarehouse(warehouse)
+	})
+	if err != nil {
+		return Warehouse{}, err
+	}
+	return service.repository().GetWarehouse(warehouse.Id)
+}
+
+// RemoveWarehouse deletes an empty warehouse. Warehouses that still hold
+// active items are kept.
+func (service *WarehouseStorageService) RemoveWarehouse(warehouseId int) error {
+	warehouse, err := service.repository().GetWarehouse(warehouseId)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range warehouse.Items {
+		if item.IsActive && item.CheckedOutAt.IsZero() {
+			return ErrWarehouseInUse
+		}
+	}
+
+	return service.write(WarehouseRemoved, func(repository WarehouseRepository) error {
+		if err := expectVersion(repository, warehouse); err != nil {
+			return err
+		}
+		return repository.RemoveWarehouse(warehouseId)
+	})
+}
+
+// -------------------------------------------------
+// ChangeCapacity
+// -------------------------------------------------
+
+// ChangeCapacity adds the change to the capacity schedule of the warehouse.
+func (service *WarehouseStorageService) ChangeCapacity(warehouseId int, change CapacityChange) error {
+	warehouse, err := service.repository().GetWarehouse(warehouseId)
+	if err != nil {
+		return err
+	}
+
+	if !change.EffectiveTo.IsZero() && change.EffectiveTo.Before(change.EffectiveFrom) {
+		return ErrInvalidPeriod
+	}
+
+	if room := change.Room; room != nil && (room.Height <= 0 || room.Width <= 0 || room.Length <= 0) {
+		return ErrInvalidDimensions
+	}
+
+	warehouse.CapacitySchedule = append(warehouse.CapacitySchedule, change)
+	return service.write(CapacityChanged, func(repository WarehouseRepository) error {
+		return repository.SaveWarehouse(warehouse)
+	})
+}
+
+// workingCopy returns a service of its own that works on the warehouses in
+// memory, so that all-or-nothing operations can try their changes before
+// they are committed.
+func (service *WarehouseStorageService) workingCopy(warehouses []Warehouse) *WarehouseStorageService {
+	shared := service.shared()
+	return &WarehouseStorageService{
+		Repository: NewInMemoryRepository(warehouses),
+		Customers:  shared.Customers,
+		Waitlist:   cloneSlice(shared.Waitlist),
+		Now:        shared.Now,
+		actor:      service.actor,
+		reason:     service.reason,
+	}
+}
+
+// commit writes the warehouses the working copy changed back to the
+// repository as one operation and takes over its waitlist.
+func (service *WarehouseStorageService) commit(
+	eventType EventType,
+	before []Warehouse,
+	candidate *WarehouseStorageService,
+) error {
+
+	after, err := candidate.repository().ListWarehouses()
+	if err != nil {
+		return err
+	}
+	return service.saveChanged(eventType, before, after, candidate.Waitlist)
+}
+
+// saveChanged writes the warehouses that differ between before and after
+// and the waitlist, when it changed, as one operation. The service takes over
+// the waitlist once the write succeeded.
+func (service *WarehouseStorageService) saveChanged(eventType EventType, before, after []Warehouse, waitlist []Item) error {
+	shared := service.shared()
+	err := service.write(eventType, func(repository WarehouseRepository) error {
+		if err := expectNewItems(repository, addedItems(before, after)...); err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(waitlist, shared.Waitlist) {
+			if err := saveState(repository, ServiceState{Customers: shared.Customers, Waitlist: waitlist}); err != nil {
+				return err
+			}
+		}
+		for _, warehouse := range after {
+			// Saves are based on the versions read before the working copy
+			// advanced them.
+			if index, found := findWarehouse(before, warehouse.Id); found {
+				warehouse.Version = before[index].Version
+				if reflect.DeepEqual(before[index], warehouse) {
+					continue
+				}
+			}
+			if err := repository.SaveWarehouse(warehouse); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	shared.Waitlist = waitlist
+	return nil
+}
+
+// addedItems returns the items of after that none of the warehouses in
+// before holds.
+func addedItems(before, after []Warehouse) []Item {
+	var added []Item
+	for _, warehouse := range after {
+		for _, item := range warehouse.Items {
+			if _, _, found := findItem(before, item.ItemId); !found {
+				added = append(added, item)
+			}
+		}
+	}
+	return added
+}
+
+// write runs apply against the repository as one operation of the given
+// type. Repositories that record changes keep all writes of apply or none.
+func (service *WarehouseStorageService) write(
+	eventType EventType,
+	apply func(repository WarehouseRepository) error,
+) error {
+
+	if service.shared().Audit != nil {
+		return service.writeAudited(eventType, apply)
+	}
+	return service.store(eventType, apply)
+}
+
+func (service *WarehouseStorageService) store(
+	eventType EventType,
+	apply func(repository WarehouseRepository) error,
+) error {
+
+	if recorder, ok := service.repository().(ChangeRecorder); ok {
+		return recorder.Record(eventType, apply)
+	}
+	return apply(service.repository())
 }
 
 // -------------------------------------------------
@@ -62,25 +454,28 @@ func (service *WarehouseStorageService) GetFullyUtilizedDates(
 	startDate, endDate time.Time,
 ) ([]time.Time, error) {
 
-	if len(service.Warehouses) == 0 {
-		return nil, errors.New("no warehouses available")
+	warehouses, err := service.repository().ListWarehousesOverlapping(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(warehouses) == 0 {
+		return nil, ErrNoWarehouses
 	}
 
 	if startDate.After(endDate) {
-		return nil, errors.New("the start date cannot be later than the end date")
+		return nil, ErrInvalidPeriod
 	}
 
+	now := service.now()
 	var fullyUtilizedDates []time.Time
-	totalCapacity := 0.0
-
-	for _, warehouse := range service.Warehouses {
-		totalCapacity += warehouse.GetWarehouseVolume()
-	}
 
 	for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		totalCapacity := 0.0
 		totalVolumeForDay := 0.0
-		for _, warehouse := range service.Warehouses {
-			totalVolumeForDay += warehouse.GetVolumeOccupiedOnDay(day)
+		for _, warehouse := range warehouses {
+			totalCapacity += warehouse.GetCapacityOnDay(day)
+			totalVolumeForDay += warehouse.GetVolumeOccupiedOnDay(day, now)
 		}
 		if totalVolumeForDay >= totalCapacity {
 			fullyUtilizedDates = append(fullyUtilizedDates, day)
@@ -97,24 +492,27 @@ func (service *WarehouseStorageService) CalculateAvailableCapacity(
 	startDate, endDate time.Time,
 ) (map[time.Time]float64, error) {
 
-	if len(service.Warehouses) == 0 {
-		return nil, errors.New("no warehouses available")
+	warehouses, err := service.repository().ListWarehousesOverlapping(startDate, endDate)
+	if err != nil {
+		return nil, err
 	}
 
-	if startDate.After(endDate) {
-		return nil, errors.New("the start date cannot be later than the end date")
+	if len(warehouses) == 0 {
+		return nil, ErrNoWarehouses
 	}
 
-	totalCapacity := 0.0
-	for _, warehouse := range service.Warehouses {
-		totalCapacity += warehouse.GetWarehouseVolume()
+	if startDate.After(endDate) {
+		return nil, ErrInvalidPeriod
 	}
 
+	now := service.now()
 	capacityMap := make(map[time.Time]float64)
 	for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		totalCapacity := 0.0
 		totalVolumeForDay := 0.0
-		for _, warehouse := range service.Warehouses {
-			totalVolumeForDay += warehouse.GetVolumeOccupiedOnDay(day)
+		for _, warehouse := range warehouses {
+			totalCapacity += warehouse.GetCapacityOnDay(day)
+			totalVolumeForDay += warehouse.GetVolumeOccupiedOnDay(day, now)
 		}
 		available := totalCapacity - totalVolumeForDay
 		capacityMap[day] = available
@@ -130,29 +528,31 @@ func (service *WarehouseStorageService) GetLeastUsedWarehouse(
 	startDate, endDate time.Time,
 ) (int, error) {
 
-	if len(service.Warehouses) == 0 {
-		return -1, errors.New("no warehouses available")
+	warehouses, err := service.repository().ListWarehousesOverlapping(startDate, endDate)
+	if err != nil {
+		return -1, err
 	}
 
-	if startDate.After(endDate) {
-		return -1, errors.New("the start date cannot be later than the end date")
+	if len(warehouses) == 0 {
+		return -1, ErrNoWarehouses
 	}
 
-	usageMap := make(map[int]float64)
-	for _, warehouse := range service.Warehouses {
-		totalVolumeDays := 0.0
-		for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
-			totalVolumeDays += warehouse.GetVolumeOccupiedOnDay(day)
-		}
-		usageMap[warehouse.Id] = totalVolumeDays
+	if startDate.After(endDate) {
+		return -1, ErrInvalidPeriod
 	}
 
+	// Warehouses are visited in id order so that ties go to the lowest id.
+	now := service.now()
 	leastUsedWarehouseId := -1
 	minUsage := float64(-1)
-	for id, usage := range usageMap {
-		if minUsage == -1 || usage < minUsage {
-			minUsage = usage
-			leastUsedWarehouseId = id
+	for _, warehouse := range sortedWarehouses(warehouses) {
+		totalVolumeDays := 0.0
+		for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+			totalVolumeDays += warehouse.GetVolumeOccupiedOnDay(day, now)
+		}
+		if minUsage == -1 || totalVolumeDays < minUsage {
+			minUsage = totalVolumeDays
+			leastUsedWarehouseId = warehouse.Id
 		}
 	}
 
@@ -1,6 +1,8 @@
 package warehouse
 
 import (
+	"database/sql"
+	"net/http/httptest"
 	"strconv"
 	"strings"
 	"testing"
@@ -27,6 +29,66 @@ type TestState struct {
 	currentDate              time.Time
 	fullyUtilizedDatesResult []time.Time
 	leastUsedWarehouseResult int
+
+	recurringReservation RecurringReservation
+	recurringResult      RecurringReservationResult
+	recurringErr         error
+
+	transferErr error
+
+	actualsErr error
+
+	slotResult AvailableSlot
+	slotErr    error
+
+	matrixResult AvailabilityMatrix
+	matrixErr    error
+
+	batchItems  []Item
+	batchResult BatchReservationResult
+	batchErr    error
+
+	storageRequests []StorageRequest
+	assignmentPlan  AssignmentPlan
+
+	movePlans    []MovePlan
+	rebalanceErr error
+
+	preemptionReport PreemptionReport
+	preemptionErr    error
+
+	blockErr error
+
+	savedService   WarehouseStorageService
+	persistenceErr error
+
+	repositoryPath string
+	repositoryErr  error
+
+	eventLog    *EventLog
+	eventLogDir string
+	eventLogErr error
+
+	database        *sql.DB
+	databasePath    string
+	storedWarehouse Warehouse
+	databaseErr     error
+
+	planners        map[string]Warehouse
+	concurrencyErr  error
+	reservationErrs []error
+
+	warehouseCSV string
+	itemCSV      string
+	importResult ImportResult
+	importErr    error
+
+	reportOutput string
+	reportErr    error
+
+	violations []Violation
+
+	apiResponse *httptest.ResponseRecorder
 }
 
 func NewTestContext(t *testing.T) *TestState {
@@ -47,13 +109,14 @@ func (tc *TestState) CreateWarehouses(count int, volume float64) {
 				Length: 1,
 			},
 		}
-		tc.service.Warehouses = append(tc.service.Warehouses, w)
+		tc.saveWarehouse(w)
 	}
 }
 
 func (tc *TestState) ClearAllWarehousesUsage() {
-	for i := range tc.service.Warehouses {
-		tc.service.Warehouses[i].Items = nil
+	for _, wh := range tc.warehouses() {
+		wh.Items = nil
+		tc.saveWarehouse(wh)
 	}
 }
 
@@ -71,21 +134,20 @@ func (tc *TestState) CreateWarehousesWithVolume(count int, volume float64) {
 				Length: 1,
 			},
 		}
-		tc.service.Warehouses = append(tc.service.Warehouses, wh)
+		tc.saveWarehouse(wh)
 	}
 }
 
 func (tc *TestState) GetAllWarehouseItems() []Item {
 	var allItems []Item
-	for _, wh := range tc.service.Warehouses {
+	for _, wh := range tc.warehouses() {
 		allItems = append(allItems, wh.Items...)
 	}
 	return allItems
 }
 
 func (tc *TestState) ApplyUsageToWarehouses(usage map[time.Time]float64) {
-	for i := range tc.service.Warehouses {
-		wh := &tc.service.Warehouses[i]
+	for _, wh := range tc.warehouses() {
 		wh.Items = nil
 		for day, usageVal := range usage {
 			wh.Items = append(wh.Items, Item{
@@ -99,13 +161,14 @@ func (tc *TestState) ApplyUsageToWarehouses(usage map[time.Time]float64) {
 				IsActive:   true,
 			})
 		}
+		tc.saveWarehouse(wh)
 	}
 }
 
 func (tc *TestState) ApplyUsageMap(usageMap map[time.Time]float64) {
-	for i := range tc.service.Warehouses {
+	for _, wh := range tc.warehouses() {
 		for day, usageVal := range usageMap {
-			tc.service.Warehouses[i].Items = append(tc.service.Warehouses[i].Items, Item{
+			wh.Items = append(wh.Items, Item{
 				ItemId:     1,
 				ItemName:   "GeneratedUsage",
 				ItemHeight: usageVal,
@@ -116,6 +179,7 @@ func (tc *TestState) ApplyUsageMap(usageMap map[time.Time]float64) {
 				IsActive:   true,
 			})
 		}
+		tc.saveWarehouse(wh)
 	}
 }
 
@@ -123,25 +187,114 @@ func (tc *TestState) CalculateAvailableCapacityWithUsage(
 	start, end time.Time,
 	usage map[time.Time]float64,
 ) (map[time.Time]float64, error) {
-	for i := range tc.service.Warehouses {
-		w := &tc.service.Warehouses[i]
-		w.Items = nil
-		for day, usageVal := range usage {
-			w.Items = append(w.Items, Item{
-				ItemId:     1,
-				ItemName:   "GeneratedUsage",
-				ItemHeight: usageVal,
-				ItemWidth:  1,
-				ItemLength: 1,
-				StartDate:  day,
-				EndDate:    day,
-				IsActive:   true,
-			})
-		}
+	if len(usage) == 0 {
+		return tc.service.CalculateAvailableCapacity(start, end)
 	}
+	tc.ApplyUsageToWarehouses(usage)
 	return tc.service.CalculateAvailableCapacity(start, end)
 }
 
+func (tc *TestState) AddItemToWarehouse(warehouseId int, volume float64, start, end time.Time) {
+	_ = tc.service.repository().AddItem(warehouseId, Item{
+		ItemId:     nextItemId(tc.warehouses()),
+		ItemName:   "GeneratedUsage",
+		ItemHeight: volume,
+		ItemWidth:  1,
+		ItemLength: 1,
+		StartDate:  start,
+		EndDate:    end,
+		IsActive:   true,
+	})
+}
+
+func (tc *TestState) SetWarehouseBuffer(warehouseId int, buffer Buffer) {
+	tc.updateWarehouse(warehouseId, func(wh *Warehouse) {
+		wh.Buffer = buffer
+	})
+}
+
+func (tc *TestState) SetItemBuffer(itemId int, buffer Buffer) {
+	tc.updateItem(itemId, func(item *Item) {
+		item.Buffer = &buffer
+	})
+}
+
+func (tc *TestState) UpdateWarehouseCalendar(warehouseId int, update func(calendar *OperatingCalendar)) {
+	tc.updateWarehouse(warehouseId, func(wh *Warehouse) {
+		update(&wh.Calendar)
+	})
+}
+
+func (tc *TestState) AddCapacityChange(warehouseId int, change CapacityChange) {
+	tc.updateWarehouse(warehouseId, func(wh *Warehouse) {
+		wh.CapacitySchedule = append(wh.CapacitySchedule, change)
+	})
+}
+
+func (tc *TestState) SetItemPriority(itemId, priority int) {
+	tc.updateItem(itemId, func(item *Item) {
+		item.Priority = priority
+	})
+}
+
+func (tc *TestState) AddCustomer(customer Customer) {
+	tc.service.Customers = append(tc.service.Customers, customer)
+}
+
+func (tc *TestState) SetWarehouseQuota(customerId, warehouseId int, quota float64) {
+	for i := range tc.service.Customers {
+		customer := &tc.service.Customers[i]
+		if customer.Id != customerId {
+			continue
+		}
+		if customer.WarehouseQuotas == nil {
+			customer.WarehouseQuotas = make(map[int]float64)
+		}
+		customer.WarehouseQuotas[warehouseId] = quota
+	}
+}
+
+func (tc *TestState) SetItemCustomer(itemId, customerId int) {
+	tc.updateItem(itemId, func(item *Item) {
+		item.CustomerId = customerId
+	})
+}
+
+func (tc *TestState) CountWarehouseItems(warehouseId int) int {
+	wh, err := tc.service.repository().GetWarehouse(warehouseId)
+	if err != nil {
+		return 0
+	}
+	return len(wh.Items)
+}
+
+func (tc *TestState) warehouses() []Warehouse {
+	warehouses, _ := tc.service.repository().ListWarehouses()
+	return warehouses
+}
+
+func (tc *TestState) saveWarehouse(wh Warehouse) {
+	_ = tc.service.repository().SaveWarehouse(wh)
+}
+
+func (tc *TestState) updateWarehouse(warehouseId int, update func(wh *Warehouse)) {
+	wh, err := tc.service.repository().GetWarehouse(warehouseId)
+	if err != nil {
+		return
+	}
+	update(&wh)
+	tc.saveWarehouse(wh)
+}
+
+func (tc *TestState) updateItem(itemId int, update func(item *Item)) {
+	warehouses := tc.warehouses()
+	if warehouseIndex, itemIndex, found := findItem(warehouses, itemId); found {
+		item := warehouses[warehouseIndex].Items[itemIndex]
+		update(&item)
+		_ = tc.service.repository().UpdateItem(warehouses[warehouseIndex].Id, item)
+	}
+}
+
 func findMatchingError(errors []error, msg string) bool {
 	for _, err := range errors {
 		if err != nil && strings.Contains(err.Error(), msg) {
@@ -250,8 +403,7 @@ func (tc *TestState) CreateWarehousesFromTable(table *godog.Table) error {
 		}
 	}
 
-	tc.service.Warehouses = warehouses
-	return nil
+	return tc.service.replaceWarehouses(warehouses)
 }
 
 func makeDate(dateStr string) time.Time {
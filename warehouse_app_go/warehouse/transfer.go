@@ -0,0 +1,233 @@
+package warehouse
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+type ShipmentLeg struct {
+	WarehouseId int
+	Item        Item
+}
+
+// -------------------------------------------------
+// TransferItem
+// -------------------------------------------------
+
+// TransferItem moves an item to another warehouse on transferDate. The stay
+// in the source warehouse ends the day before the transfer and a linked
+// continuation occupies the target warehouse from transferDate until the
+// original end date.
+func (service *WarehouseStorageService) TransferItem(
+	itemId, targetWarehouseId int,
+	transferDate time.Time,
+) (Item, error) {
+
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return Item{}, err
+	}
+
+	sourceIndex, itemIndex, found := findItem(warehouses, itemId)
+	if !found {
+		return Item{}, ErrItemNotFound
+	}
+
+	targetIndex, found := findWarehouse(warehouses, targetWarehouseId)
+	if !found {
+		return Item{}, ErrTargetNotFound
+	}
+
+	if sourceIndex == targetIndex {
+		return Item{}, ErrInTarget
+	}
+
+	item := warehouses[sourceIndex].Items[itemIndex]
+	if !item.IsActive {
+		return Item{}, ErrItemNotActive
+	}
+
+	if !transferDate.After(item.StartDate) || transferDate.After(item.EndDate) {
+		return Item{}, ErrTransferDate
+	}
+
+	if transferDate.Before(service.now()) {
+		return Item{}, ErrTransferInPast
+	}
+
+	if item.ShipmentId == 0 {
+		item.ShipmentId = item.ItemId
+	}
+
+	continuation := item
+	continuation.ItemId = nextItemId(warehouses)
+	continuation.PreviousItemId = item.ItemId
+	continuation.StartDate = transferDate
+	continuation.CheckedInAt = time.Time{}
+	continuation.CheckedOutAt = time.Time{}
+
+	if !service.canPlace(warehouses, warehouses[targetIndex], continuation, item.ItemId) {
+		return Item{}, fmt.Errorf("%w for the remaining days", ErrTargetFull)
+	}
+
+	item.EndDate = transferDate.AddDate(0, 0, -1)
+	err = service.write(ItemTransferred, func(repository WarehouseRepository) error {
+		if err := expectVersion(repository, warehouses[targetIndex]); err != nil {
+			return err
+		}
+		if err := expectNewItems(repository, continuation); err != nil {
+			return err
+		}
+		if err := repository.UpdateItem(warehouses[sourceIndex].Id, item); err != nil {
+			return err
+		}
+		return repository.AddItem(warehouses[targetIndex].Id, continuation)
+	})
+	if err != nil {
+		return Item{}, err
+	}
+
+	return continuation, nil
+}
+
+// -------------------------------------------------
+// GetShipment
+// -------------------------------------------------
+
+// GetShipment returns every leg of the shipment that contains itemId,
+// ordered by start date.
+func (service *WarehouseStorageService) GetShipment(itemId int) ([]ShipmentLeg, error) {
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return nil, err
+	}
+
+	warehouseIndex, itemIndex, found := findItem(warehouses, itemId)
+	if !found {
+		return nil, ErrItemNotFound
+	}
+
+	item := warehouses[warehouseIndex].Items[itemIndex]
+	if item.ShipmentId == 0 {
+		return []ShipmentLeg{{WarehouseId: warehouses[warehouseIndex].Id, Item: item}}, nil
+	}
+
+	var legs []ShipmentLeg
+	for _, warehouse := range warehouses {
+		for _, leg := range warehouse.Items {
+			if leg.ShipmentId == item.ShipmentId {
+				legs = append(legs, ShipmentLeg{WarehouseId: warehouse.Id, Item: leg})
+			}
+		}
+	}
+
+	sort.Slice(legs, func(i, j int) bool {
+		return legs[i].Item.StartDate.Before(legs[j].Item.StartDate)
+	})
+
+	return legs, nil
+}
+
+// -------------------------------------------------
+// RelocateItem
+// -------------------------------------------------
+
+// RelocateItem moves a stay that has not started yet to another warehouse
+// as a whole.
+func (service *WarehouseStorageService) RelocateItem(itemId, targetWarehouseId int) error {
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return err
+	}
+
+	sourceIndex, itemIndex, err := service.findUpcomingItem(warehouses, itemId)
+	if err != nil {
+		return err
+	}
+
+	targetIndex, found := findWarehouse(warehouses, targetWarehouseId)
+	if !found {
+		return ErrTargetNotFound
+	}
+
+	if sourceIndex == targetIndex {
+		return ErrInTarget
+	}
+
+	item := warehouses[sourceIndex].Items[itemIndex]
+	if !service.canPlace(warehouses, warehouses[targetIndex], item, item.ItemId) {
+		return ErrTargetFull
+	}
+
+	return service.write(ItemRelocated, func(repository WarehouseRepository) error {
+		if err := expectVersion(repository, warehouses[targetIndex]); err != nil {
+			return err
+		}
+		if err := repository.RemoveItem(warehouses[sourceIndex].Id, item.ItemId); err != nil {
+			return err
+		}
+		return repository.AddItem(warehouses[targetIndex].Id, item)
+	})
+}
+
+// -------------------------------------------------
+// ShiftItem
+// -------------------------------------------------
+
+// ShiftItem moves a stay that has not started yet by the given number of
+// days within its warehouse.
+func (service *WarehouseStorageService) ShiftItem(itemId, days int) error {
+	warehouses, err := service.repository().ListWarehouses()
+	if err != nil {
+		return err
+	}
+
+	warehouseIndex, itemIndex, err := service.findUpcomingItem(warehouses, itemId)
+	if err != nil {
+		return err
+	}
+
+	warehouse := &warehouses[warehouseIndex]
+	item := warehouse.Items[itemIndex]
+	shifted := item
+	shifted.StartDate = item.StartDate.AddDate(0, 0, days)
+	shifted.EndDate = item.EndDate.AddDate(0, 0, days)
+
+	if shifted.StartDate.Before(service.now()) {
+		return ErrStartInPast
+	}
+
+	// The item must not compete with its own current stay.
+	warehouse.Items[itemIndex].IsActive = false
+	fits := service.canPlace(warehouses, *warehouse, shifted, item.ItemId)
+	warehouse.Items[itemIndex].IsActive = true
+	if !fits {
+		return ErrNoCapacity
+	}
+
+	return service.write(ItemShifted, func(repository WarehouseRepository) error {
+		if err := expectVersion(repository, *warehouse); err != nil {
+			return err
+		}
+		return repository.UpdateItem(warehouse.Id, shifted)
+	})
+}
+
+func (service *WarehouseStorageService) findUpcomingItem(warehouses []Warehouse, itemId int) (int, int, error) {
+	warehouseIndex, itemIndex, found := findItem(warehouses, itemId)
+	if !found {
+		return -1, -1, ErrItemNotFound
+	}
+
+	item := warehouses[warehouseIndex].Items[itemIndex]
+	if !item.IsActive {
+		return -1, -1, ErrItemNotActive
+	}
+
+	if !item.CheckedInAt.IsZero() || item.StartDate.Before(service.now()) {
+		return -1, -1, ErrStayStarted
+	}
+
+	return warehouseIndex, itemIndex, nil
+}
@@ -0,0 +1,48 @@
+package warehouse
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func initTransferItemSteps(ctx *godog.ScenarioContext) {
+	// WHEN
+	ctx.When(`^I transfer item (\d+) to warehouse (\d+) on "([^"]*)"$`, iTransferItemToWarehouseOn)
+
+	// THEN
+	ctx.Then(`^the shipment of item (\d+) should be:$`, theShipmentOfItemShouldBe)
+}
+
+// -------------------
+// WHEN Step (Act)
+// -------------------
+
+func iTransferItemToWarehouseOn(ctx context.Context, itemId, warehouseId int, dateStr string) {
+	t := godog.T(ctx)
+	_, tc.transferErr = tc.service.TransferItem(itemId, warehouseId, parseDate(t, dateStr))
+}
+
+// -------------------
+// THEN Steps (Assert)
+// -------------------
+
+func theShipmentOfItemShouldBe(ctx context.Context, itemId int, table *godog.Table) {
+	t := godog.T(ctx)
+
+	legs, err := tc.service.GetShipment(itemId)
+	assert.NoError(t, err, "unexpected error")
+	if !assert.Len(t, legs, len(table.Rows)-1, "shipment leg count mismatch") {
+		return
+	}
+
+	for i, row := range table.Rows[1:] {
+		warehouseId, _ := strconv.Atoi(row.Cells[0].Value)
+
+		assert.Equal(t, warehouseId, legs[i].WarehouseId, "warehouse mismatch at row %d", i)
+		assert.Equal(t, row.Cells[1].Value, legs[i].Item.StartDate.Format("2006-01-02"), "start date mismatch at row %d", i)
+		assert.Equal(t, row.Cells[2].Value, legs[i].Item.EndDate.Format("2006-01-02"), "end date mismatch at row %d", i)
+	}
+}
@@ -1,21 +1,205 @@
 package warehouse
 
-import "time"
+import (
+	"math"
+	"time"
+)
 
-func (w Warehouse) GetWarehouseVolume() float64 {
-	return w.MaxCapacity.Height * w.MaxCapacity.Width * w.MaxCapacity.Length
+func (r ThreeDRoom) GetVolume() float64 {
+	return r.Height * r.Width * r.Length
+}
+
+// GetCapacityOnDay returns the volume the warehouse offers on the day, taking
+// its lifetime and capacity schedule into account. When several room changes
+// are effective the one that started last wins.
+func (w Warehouse) GetCapacityOnDay(day time.Time) float64 {
+	if !w.Calendar.IsInService(day) {
+		return 0
+	}
+
+	room := w.MaxCapacity
+	roomEffectiveFrom := time.Time{}
+	adjustment := 0.0
+	for _, change := range w.CapacitySchedule {
+		if !change.isEffective(day) {
+			continue
+		}
+		if change.Room != nil && !change.EffectiveFrom.Before(roomEffectiveFrom) {
+			room = *change.Room
+			roomEffectiveFrom = change.EffectiveFrom
+		}
+		adjustment += change.VolumeAdjustment
+	}
+
+	return math.Max(0, room.GetVolume()+adjustment)
+}
+
+func (c CapacityChange) isEffective(day time.Time) bool {
+	if day.Before(c.EffectiveFrom) {
+		return false
+	}
+	return c.EffectiveTo.IsZero() || !day.After(c.EffectiveTo)
 }
 
 func (i Item) GetItemVolume() float64 {
 	return i.ItemHeight * i.ItemWidth * i.ItemLength
 }
 
-func (w Warehouse) GetVolumeOccupiedOnDay(day time.Time) float64 {
+// GetOccupiedPeriod returns the days the item takes up space as seen at now:
+// actuals for the past and the plan for the future. A checked-in item counts
+// from its check-in day until its check-out day or, while it is still in,
+// until today or its planned end, whichever is later. An item that has not
+// been checked in counts with its planned dates.
+func (i Item) GetOccupiedPeriod(now time.Time) (time.Time, time.Time) {
+	today := truncateToDay(now)
+	start, end := i.StartDate, i.EndDate
+	if !i.CheckedInAt.IsZero() {
+		start = truncateToDay(i.CheckedInAt)
+		if !i.CheckedOutAt.IsZero() {
+			end = truncateToDay(i.CheckedOutAt)
+		} else if today.After(end) {
+			end = today
+		}
+	}
+	return start, end
+}
+
+// getPossiblePeriod returns the widest period the item may occupy whenever
+// it is looked at: items still checked in have no end yet. Repositories use
+// it to select the warehouses an item may be relevant to.
+func (i Item) getPossiblePeriod() (time.Time, time.Time) {
+	start, end := i.StartDate, i.EndDate
+	if !i.CheckedInAt.IsZero() {
+		start = truncateToDay(i.CheckedInAt)
+		if !i.CheckedOutAt.IsZero() {
+			end = truncateToDay(i.CheckedOutAt)
+		} else {
+			end = openEnd
+		}
+	}
+	return start, end
+}
+
+// openEnd stands for the end of a period that has not ended yet.
+var openEnd = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+func (w Warehouse) GetItemBuffer(item Item) Buffer {
+	if item.Buffer != nil {
+		return *item.Buffer
+	}
+	return w.Buffer
+}
+
+// GetBufferedPeriod returns the occupied period of the item widened by the
+// buffer days that apply to it in this warehouse.
+func (w Warehouse) GetBufferedPeriod(item Item, now time.Time) (time.Time, time.Time) {
+	start, end := item.GetOccupiedPeriod(now)
+	return w.widenByBuffer(item, start, end)
+}
+
+// getPossibleBufferedPeriod is getPossiblePeriod widened by the buffer days.
+func (w Warehouse) getPossibleBufferedPeriod(item Item) (time.Time, time.Time) {
+	start, end := item.getPossiblePeriod()
+	if end.Equal(openEnd) {
+		start, _ = w.widenByBuffer(item, start, end)
+		return start, end
+	}
+	return w.widenByBuffer(item, start, end)
+}
+
+func (w Warehouse) widenByBuffer(item Item, start, end time.Time) (time.Time, time.Time) {
+	buffer := w.GetItemBuffer(item)
+	return start.AddDate(0, 0, -buffer.Before), end.AddDate(0, 0, buffer.After)
+}
+
+func (w Warehouse) GetVolumeOccupiedOnDay(day, now time.Time) float64 {
+	return w.GetVolumeStoredOnDay(day, now) + w.GetVolumeBufferedOnDay(day, now) + w.GetVolumeBlockedOnDay(day, now)
+}
+
+func (w Warehouse) GetVolumeStoredOnDay(day, now time.Time) float64 {
 	volume := 0.0
 	for _, item := range w.Items {
-		if item.IsActive && !day.Before(item.StartDate) && !day.After(item.EndDate) {
+		start, end := item.GetOccupiedPeriod(now)
+		if item.IsActive && withinDays(day, start, end) {
 			volume += item.GetItemVolume()
 		}
 	}
 	return volume
 }
+
+func (w Warehouse) GetVolumeBufferedOnDay(day, now time.Time) float64 {
+	volume := 0.0
+	for _, item := range w.Items {
+		start, end := item.GetOccupiedPeriod(now)
+		bufferedStart, bufferedEnd := w.GetBufferedPeriod(item, now)
+		if item.IsActive && withinDays(day, bufferedStart, bufferedEnd) && !withinDays(day, start, end) {
+			volume += item.GetItemVolume()
+		}
+	}
+	return volume
+}
+
+func withinDays(day, start, end time.Time) bool {
+	return !day.Before(start) && !day.After(end)
+}
+
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// canAccommodate reports whether the item, including its buffer days, fits
+// next to everything already stored in the warehouse and the warehouse
+// operates on the days the item needs it. Items of a customer may use the
+// unused part of that customer's capacity blocks.
+func (w Warehouse) canAccommodate(item Item, now time.Time) bool {
+	if !w.canOperate(item, now) {
+		return false
+	}
+
+	requiredVolume := item.GetItemVolume()
+	startDate, endDate := w.GetBufferedPeriod(item, now)
+	for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		free := w.GetCapacityOnDay(day) - w.GetVolumeOccupiedOnDay(day, now) + w.getUnusedBlockVolume(item.CustomerId, day, now)
+		if requiredVolume > free {
+			return false
+		}
+	}
+	return true
+}
+
+// getFreeVolumeProfile returns the free volume of the warehouse for every day
+// from startDate to endDate. Occupancy is accumulated in a single pass over the
+// items so that long ranges stay cheap to scan.
+func (w Warehouse) getFreeVolumeProfile(startDate, endDate, now time.Time) []float64 {
+	days := daysBetween(startDate, endDate) + 1
+	if days <= 0 {
+		return nil
+	}
+
+	delta := make([]float64, days+1)
+	for _, item := range w.Items {
+		if !item.IsActive {
+			continue
+		}
+		itemStart, itemEnd := w.GetBufferedPeriod(item, now)
+		if itemEnd.Before(startDate) || itemStart.After(endDate) {
+			continue
+		}
+		first := max(daysBetween(startDate, itemStart), 0)
+		last := min(daysBetween(startDate, itemEnd), days-1)
+		delta[first] += item.GetItemVolume()
+		delta[last+1] -= item.GetItemVolume()
+	}
+
+	profile := make([]float64, days)
+	occupied := 0.0
+	for i := range profile {
+		occupied += delta[i]
+		day := startDate.AddDate(0, 0, i)
+		profile[i] = w.GetCapacityOnDay(day) - occupied
+		if len(w.Blocks) > 0 {
+			profile[i] -= w.GetVolumeBlockedOnDay(day, now)
+		}
+	}
+	return profile
+}
@@ -48,4 +48,29 @@ func InitializeScenario(ctx *godog.ScenarioContext) {
 	initFindAvailableWarehouseSteps(ctx)
 	initGetFullyUtilizedDatesSteps(ctx)
 	initGetLeastUsedWarehouseSteps(ctx)
+	initReserveRecurringSteps(ctx)
+	initTransferItemSteps(ctx)
+	initPlannedVersusActualSteps(ctx)
+	initBufferDaysSteps(ctx)
+	initOperatingCalendarSteps(ctx)
+	initCapacityScheduleSteps(ctx)
+	initEarliestAvailableSlotSteps(ctx)
+	initAvailabilityMatrixSteps(ctx)
+	initReserveBatchSteps(ctx)
+	initOptimizeAssignmentSteps(ctx)
+	initRebalancingSteps(ctx)
+	initPreemptionSteps(ctx)
+	initCustomerQuotaSteps(ctx)
+	initCapacityBlockSteps(ctx)
+	initPersistenceSteps(ctx)
+	initRepositorySteps(ctx)
+	initEventLogSteps(ctx)
+	initSQLRepositorySteps(ctx)
+	initConcurrencySteps(ctx)
+	initCSVImportSteps(ctx)
+	initCapacityReportSteps(ctx)
+	initAsOfSteps(ctx)
+	initAuditSteps(ctx)
+	initCheckSteps(ctx)
+	initAPISteps(ctx)
 }